@@ -1,10 +1,20 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"os"
+	"time"
 
 	"foodbuddy/internal/api"
+	"foodbuddy/internal/controllers"
 	"foodbuddy/internal/database"
+	"foodbuddy/internal/database/migrations"
+	"foodbuddy/internal/mail"
+	"foodbuddy/internal/payment"
+	"foodbuddy/internal/repository"
+	"foodbuddy/internal/service"
 	"foodbuddy/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -12,29 +22,93 @@ import (
 
 func init() {
 	database.ConnectToDB()
-	database.AutoMigrate()
 	fmt.Println("Database intitialization done")
 }
 
 func main() {
+	//schema changes are applied with "foodbuddy migrate up|down|status"
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrations.Run(database.DB, os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	pending, err := migrations.Pending(database.DB)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(pending) > 0 {
+		log.Fatalf("database schema is %d migration(s) behind, run \"foodbuddy migrate up\" first", len(pending))
+	}
+
+	//mail goes through smtp, .eml files or memory depending on MAILER
+	mailConfig := mail.NewConfig(utils.GetEnvVariables())
+	mailer, err := mail.New(mailConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+	templates, err := mail.LoadTemplates(mailConfig.TemplateDir, mailConfig.DefaultLocale)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	//orders are paid through razorpay, stripe, the wallet and, when enabled, the offline fake gateway
+	gateways := payment.New(payment.NewConfig(utils.GetEnvVariables()))
+
+	//the first admin is created with "foodbuddy admin invite <email>", the invite token is printed
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		if len(os.Args) != 4 || os.Args[2] != "invite" {
+			log.Fatal("usage: admin invite <email>")
+		}
+		token, err := service.New(repository.New(database.DB), templates, gateways).Admins.BootstrapInvite(os.Args[3])
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("super admin invite created for %s, accept it at /api/v1/auth/admin/invite/accept with the token\n%s\n", os.Args[3], token)
+		return
+	}
+
 	//start server with default logger and recovery
 	router := gin.Default()
 	//load html from templates folder
 	// router.LoadHTMLGlob("./templates/*")
 
+	//wire the handlers to the services and the database
+	repos := repository.New(database.DB)
+	services := service.New(repos, templates, gateways)
+	h := controllers.NewHandler(services)
+
+	//providers send bursts of webhooks and retry the ones that fail, so they are
+	//registered before the rate limiter
+	api.WebhookRoutes(router, h)
+
 	router.Use(utils.RateLimitMiddleware())
 	router.Use(utils.CorsMiddleware())
 
+	//mails are queued in the outbox by the services and delivered in the background
+	go service.NewOutboxWorker(repos, mailer, 4).Run(context.Background())
+	//open payments are checked against the gateways and unpaid orders expired on a schedule
+	go services.Reconciliation.Schedule(context.Background())
+	//responses kept for Idempotency-Key retries are dropped once they expire
+	go services.Idempotency.PurgeExpired(context.Background(), time.Hour)
+	//kitchen events are kept a day for kitchens replaying what they missed
+	go services.Kitchen.PurgeExpired(context.Background(), time.Hour)
+	//stock held for online orders is let go of once the hold expires or the payment fails
+	go services.Stock.ReleaseExpired(context.Background(), time.Minute)
+	//paid orders the restaurants didn't accept within ACCEPTANCE_DEADLINE are rejected and refunded
+	go services.Orders.RejectOverdue(context.Background(), time.Minute)
+
 	//access all the routes
 	api.ServerHealth(router)
-	api.PublicRoutes(router)
-	api.AuthenticationRoutes(router)
-	api.AdminRoutes(router)
-	api.UserRoutes(router)
-	api.RestaurantRoutes(router)
-	api.AdditionalRoutes(router)
-
-	err := router.Run(":"+utils.GetEnvVariables().Port)
+	api.PublicRoutes(router, h)
+	api.AuthenticationRoutes(router, h)
+	api.AdminRoutes(router, h)
+	api.UserRoutes(router, h)
+	api.RestaurantRoutes(router, h)
+	api.AdditionalRoutes(router, h)
+
+	err = router.Run(":"+utils.GetEnvVariables().Port)
 	if err != nil {
 		panic(err)
 	}
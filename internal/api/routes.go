@@ -2,6 +2,7 @@ package api
 
 import (
 	"foodbuddy/internal/controllers"
+	"foodbuddy/internal/model"
 	"foodbuddy/view"
 	"net/http"
 
@@ -16,175 +17,232 @@ func ServerHealth(router *gin.Engine) {
 	})
 }
 
-func AuthenticationRoutes(router *gin.Engine) {
+func AuthenticationRoutes(router *gin.Engine, h *controllers.Handler) {
 	//admin
-	router.POST("/api/v1/auth/admin/login", controllers.AdminLogin) //
+	router.POST("/api/v1/auth/admin/login", h.AdminLogin)                //password and totp code or recovery code
+	router.POST("/api/v1/auth/admin/invite/accept", h.AcceptAdminInvite) //
+	router.POST("/api/v1/auth/admin/totp/confirm", h.ConfirmAdminTOTP)   //finish the enrollment, returns recovery codes
 
 	//user
-	router.POST("/api/v1/auth/user/email/login", controllers.EmailLogin)   //
-	router.POST("/api/v1/auth/user/email/signup", controllers.EmailSignup) //
-	router.GET("/api/v1/auth/google/login", controllers.GoogleHandleLogin) //
-	router.GET("/api/v1/googlecallback", controllers.GoogleHandleCallback) //
+	router.POST("/api/v1/auth/user/email/login", h.EmailLogin)   //
+	router.POST("/api/v1/auth/user/email/signup", h.EmailSignup) //
+	router.GET("/api/v1/auth/google/login", h.GoogleHandleLogin) //
+	router.GET("/api/v1/googlecallback", h.GoogleHandleCallback) //
 
 	//additional endpoints for email verification and password reset
-	router.GET("/api/v1/auth/verifyemail/:role/:email/:otp", controllers.VerifyEmail) //use for admin token gen as well.
+	router.GET("/api/v1/auth/verifyemail/:role/:email/:otp", h.VerifyEmail) //use for admin token gen as well.
 
-	router.POST("/api/v1/auth/passwordreset/step1", controllers.Step1PasswordReset) //
-	router.GET("/api/v1/auth/passwordreset", controllers.LoadPasswordReset)         //
-	router.POST("/api/v1/auth/passwordreset/step2", controllers.Step2PasswordReset) //
+	router.POST("/api/v1/auth/passwordreset/step1", h.Step1PasswordReset) //
+	router.GET("/api/v1/auth/passwordreset", h.LoadPasswordReset)         //
+	router.POST("/api/v1/auth/passwordreset/step2", h.Step2PasswordReset) //
+
+	//sessions, exchange the refresh token for new tokens
+	router.POST("/api/v1/auth/refresh", h.RefreshSession)
 
 	//restaurant
-	router.POST("/api/v1/auth/restaurant/signup", controllers.RestaurantSignup) //
-	router.POST("/api/v1/auth/restaurant/login", controllers.RestaurantLogin)   //
+	router.POST("/api/v1/auth/restaurant/signup", h.RestaurantSignup) //
+	router.POST("/api/v1/auth/restaurant/login", h.RestaurantLogin)   //
 }
 
-func UserRoutes(router *gin.Engine) {
-	userRoutes := router.Group("/api/v1/user")
+func UserRoutes(router *gin.Engine, h *controllers.Handler) {
+	userRoutes := router.Group("/api/v1/user", h.Authenticate(model.UserRole))
 	{
 		// User Profile Management
-		userRoutes.GET("/profile", controllers.GetUserProfile)       //
-		userRoutes.POST("/edit", controllers.UpdateUserInformation)  //
-		userRoutes.GET("/wallet/all", controllers.GetUserWalletData) //
+		userRoutes.GET("/profile", h.GetUserProfile)       //
+		userRoutes.POST("/edit", h.UpdateUserInformation)  //
+		userRoutes.GET("/wallet/all", h.GetUserWalletData) //
+
+		// Sessions
+		userRoutes.GET("/sessions", h.ListSessions)
+		userRoutes.DELETE("/sessions/:id", h.RevokeSession)
+		userRoutes.DELETE("/sessions", h.RevokeAllSessions) //logout everywhere
 
 		// Favorite Products
-		userRoutes.GET("/favorites/all", controllers.GetUsersFavouriteProduct)     //
-		userRoutes.POST("/favorites/add", controllers.AddFavouriteProduct)         //
-		userRoutes.DELETE("/favorites/delete", controllers.RemoveFavouriteProduct) //
+		userRoutes.GET("/favorites/all", h.GetUsersFavouriteProduct)     //
+		userRoutes.POST("/favorites/add", h.AddFavouriteProduct)         //
+		userRoutes.DELETE("/favorites/delete", h.RemoveFavouriteProduct) //
 
 		// User Address Management
-		userRoutes.GET("/address/all", controllers.GetUserAddress)          //
-		userRoutes.POST("/address/add", controllers.AddUserAddress)         //
-		userRoutes.PATCH("/address/edit", controllers.EditUserAddress)      //
-		userRoutes.DELETE("/address/delete", controllers.DeleteUserAddress) //
+		userRoutes.GET("/address/all", h.GetUserAddress)          //
+		userRoutes.POST("/address/add", h.AddUserAddress)         //
+		userRoutes.PATCH("/address/edit", h.EditUserAddress)      //
+		userRoutes.DELETE("/address/delete", h.DeleteUserAddress) //
 
 		// Cart Management
-		userRoutes.POST("/cart/add", controllers.AddToCart) //
-		userRoutes.POST("/cart/cookingrequest", controllers.AddCookingRequest)
-		userRoutes.GET("/cart/all", controllers.GetCartTotal)             //
-		userRoutes.DELETE("/cart/delete/", controllers.ClearCart)         //specify restaurant_id for clearing individual carts
-		userRoutes.DELETE("/cart/remove", controllers.RemoveItemFromCart) //
-		userRoutes.PUT("/cart/update/", controllers.UpdateQuantity)       //
-		userRoutes.GET("/coupon/cart/", controllers.ApplyCouponOnCart)    //
-
-		// Order Management
-		userRoutes.POST("/order/step1/placeorder", controllers.PlaceOrder)
-		userRoutes.GET("/order/deliverycode", controllers.SendOrderDeliveryVerificationCode)
-		userRoutes.POST("/order/step2/initiatepayment", controllers.InitiatePayment)
-		userRoutes.PUT("/order/update/paymentmode", controllers.ChangeOrderPaymentMode) //orderid in the query param //CHANGE COD , ONLINE MODE
-		userRoutes.POST("/order/step3/razorpaycallback/:orderid", controllers.RazorPayGatewayCallback)
-		userRoutes.GET("/order/step3/razorpaycallback/failed/:orderid", controllers.RazorPayFailed)
-		userRoutes.GET("/order/step3/stripecallback", controllers.StripeCallback)
-		userRoutes.POST("/order/cancel/online", controllers.CancelOrderedProductOnline)
-		userRoutes.POST("/order/cancel/cod", controllers.CancelOrderedProductCOD)
-		userRoutes.GET("/order/items", controllers.UserOrderItems)
-		userRoutes.GET("/order/info", controllers.GetOrderInfoByOrderIDasJSON)
-		userRoutes.GET("/order/invoice/", controllers.GetOrderInfoByOrderIDAndGeneratePDF)
-		userRoutes.GET("/order/paymenthistory", controllers.PaymentDetailsByOrderID)
-		userRoutes.GET("/order/verifypayment", controllers.VerifyOnlinePayment)
-		userRoutes.POST("/order/review", controllers.UserReviewonOrderItem)
-		userRoutes.POST("/order/rating", controllers.UserRatingOrderItem)
+		userRoutes.POST("/cart/add", h.AddToCart) //
+		userRoutes.POST("/cart/cookingrequest", h.AddCookingRequest)
+		userRoutes.GET("/cart/all", h.GetCartTotal)             //
+		userRoutes.DELETE("/cart/delete/", h.ClearCart)         //specify restaurant_id for clearing individual carts
+		userRoutes.DELETE("/cart/remove", h.RemoveItemFromCart) //
+		userRoutes.PUT("/cart/update/", h.UpdateQuantity)       //
+		userRoutes.GET("/coupon/cart/", h.ApplyCouponOnCart)    //
+
+		// Order Management, the mutating endpoints take an Idempotency-Key header to retry safely
+		userRoutes.POST("/order/step1/placeorder", h.Idempotent(), h.PlaceOrder)
+		userRoutes.GET("/order/deliverycode", h.SendOrderDeliveryVerificationCode)
+		userRoutes.POST("/order/step2/initiatepayment", h.Idempotent(), h.InitiatePayment)
+		userRoutes.PUT("/order/update/paymentmode", h.Idempotent(), h.ChangeOrderPaymentMode) //orderid in the query param //CHANGE COD , ONLINE MODE
+		userRoutes.POST("/order/step3/razorpaycallback/:orderid", h.Idempotent(), h.RazorPayGatewayCallback)
+		userRoutes.GET("/order/step3/razorpaycallback/failed/:orderid", h.RazorPayFailed)
+		userRoutes.GET("/order/step3/stripecallback", h.StripeCallback)
+		userRoutes.GET("/order/step3/fakecallback", h.FakePaymentCallback)                    //only answers when FAKEPAYMENTS=true
+		userRoutes.POST("/order/cancel/online", h.Idempotent(), h.CancelOrderedProductOnline) //refunds to the wallet
+		userRoutes.POST("/order/cancel/cod", h.Idempotent(), h.CancelOrderedProductCOD)
+		userRoutes.GET("/order/items", h.UserOrderItems)
+		userRoutes.GET("/order/info", h.GetOrderInfoByOrderIDasJSON)
+		userRoutes.GET("/order/invoice/", h.GetOrderInfoByOrderIDAndGeneratePDF)
+		userRoutes.GET("/order/paymenthistory", h.PaymentDetailsByOrderID)
+		userRoutes.GET("/order/refunds", h.OrderRefunds)   //?order_id=
+		userRoutes.GET("/order/timeline", h.OrderTimeline) //?order_id=
+		userRoutes.GET("/order/track", h.TrackOrder)       //?order_id=, server-sent events
+		userRoutes.POST("/order/cookingrequest", h.Idempotent(), h.UpdateOrderCookingRequest)
+		userRoutes.GET("/order/verifypayment", h.VerifyOnlinePayment)
+		userRoutes.POST("/order/review", h.UserReviewonOrderItem)
+		userRoutes.POST("/order/rating", h.UserRatingOrderItem)
 
 		// Referral System
-		userRoutes.GET("/referral/code", controllers.GetRefferalCode)
-		userRoutes.PATCH("/referral/activate", controllers.ActivateReferral)
-		userRoutes.GET("/referral/claim", controllers.ClaimReferralRewards)
-		userRoutes.GET("/referral/stats", controllers.GetReferralStats)
+		userRoutes.GET("/referral/code", h.GetRefferalCode)
+		userRoutes.PATCH("/referral/activate", h.ActivateReferral)
+		userRoutes.POST("/referral/claim", h.Idempotent(), h.ClaimReferralRewards) //credits the wallet
+		userRoutes.GET("/referral/stats", h.GetReferralStats)
 	}
 }
 
-func RestaurantRoutes(router *gin.Engine) {
-	restaurantRoutes := router.Group("/api/v1/restaurants")
+func RestaurantRoutes(router *gin.Engine, h *controllers.Handler) {
+	restaurantRoutes := router.Group("/api/v1/restaurants", h.Authenticate(model.RestaurantRole))
 	{
 		// Restaurant Management
-		restaurantRoutes.POST("/edit", controllers.EditRestaurant)       //update restaurant profile
-		restaurantRoutes.POST("/products/add", controllers.AddProduct)   //
-		restaurantRoutes.POST("/products/edit", controllers.EditProduct) //
-		restaurantRoutes.DELETE("/products", controllers.DeleteProduct)  //
+		restaurantRoutes.POST("/edit", h.EditRestaurant)       //update restaurant profile
+		restaurantRoutes.POST("/products/add", h.AddProduct)   //
+		restaurantRoutes.POST("/products/edit", h.EditProduct) //
+		restaurantRoutes.DELETE("/products", h.DeleteProduct)  //
 
 		// Order History and Status Updates
-		restaurantRoutes.GET("/order/history", controllers.OrderHistoryRestaurants)            //without order_status and with
-		restaurantRoutes.POST("/order/confirmcod", controllers.ConfirmCODPayment)              //authentication for rest add rest id in the order
-		restaurantRoutes.POST("/order/confirmdelivery", controllers.DeliveryComplete)          //query param order_id,authentication
-		restaurantRoutes.POST("/order/nextstatus", controllers.UpdateOrderStatusForRestaurant) //authentication rest
+		restaurantRoutes.GET("/order/history", h.OrderHistoryRestaurants)                            //without order_status and with
+		restaurantRoutes.POST("/order/confirmcod", h.Idempotent(), h.ConfirmCODPayment)              //authentication for rest add rest id in the order
+		restaurantRoutes.POST("/order/confirmdelivery", h.Idempotent(), h.DeliveryComplete)          //query param order_id,authentication
+		restaurantRoutes.POST("/order/accept", h.Idempotent(), h.AcceptOrder)                        //product_id is optional
+		restaurantRoutes.POST("/order/reject", h.Idempotent(), h.RejectOrder)                        //reason is required, refunds the user
+		restaurantRoutes.POST("/order/nextstatus", h.Idempotent(), h.UpdateOrderStatusForRestaurant) //authentication rest
+		restaurantRoutes.GET("/order/timeline", h.OrderTimeline)                                     //?order_id=
+		restaurantRoutes.GET("/order/feed", h.KitchenFeed)                                           //server-sent events, resumes from Last-Event-ID or ?cursor=
 
 		// Product Offers
-		restaurantRoutes.POST("/product/offer/add", controllers.AddProductOffer)      //
-		restaurantRoutes.PUT("/product/offer/remove", controllers.RemoveProductOffer) //
+		restaurantRoutes.POST("/product/offer/add", h.AddProductOffer)      //
+		restaurantRoutes.PUT("/product/offer/remove", h.RemoveProductOffer) //
 
 		//orderitem information in excel
-		restaurantRoutes.GET("/orderitems/excel/all", controllers.OrderItemsCSVFileForRestaurant) //
-		restaurantRoutes.GET("/orderitems/json/all", controllers.ListOrderItemsForRestaurants)    //
+		restaurantRoutes.GET("/orderitems/excel/all", h.OrderItemsCSVFileForRestaurant) //
+		restaurantRoutes.GET("/orderitems/json/all", h.ListOrderItemsForRestaurants)    //
 
 		//report
-		restaurantRoutes.GET("/report/all", controllers.RestaurantOverallSalesReport)
+		restaurantRoutes.GET("/report/all", h.RestaurantOverallSalesReport)
 		//new customers this week
 
 		//restaurant wallet balance and history
-		restaurantRoutes.GET("/wallet/all", controllers.GetRestaurantWalletData) //
+		restaurantRoutes.GET("/wallet/all", h.GetRestaurantWalletData) //
+
+		// Sessions
+		restaurantRoutes.GET("/sessions", h.ListSessions)
+		restaurantRoutes.DELETE("/sessions/:id", h.RevokeSession)
+		restaurantRoutes.DELETE("/sessions", h.RevokeAllSessions) //logout everywhere
 	}
 }
 
-func AdminRoutes(router *gin.Engine) {
-	adminRoutes := router.Group("/api/v1/admin")
+func AdminRoutes(router *gin.Engine, h *controllers.Handler) {
+	adminRoutes := router.Group("/api/v1/admin", h.Authenticate(model.AdminRole))
 	{
 		// User Management
 		//get profile info , update online stats
-		adminRoutes.GET("/users", controllers.GetUserList)                //
-		adminRoutes.GET("/users/blocked", controllers.GetBlockedUserList) //
-		adminRoutes.PUT("/users/block/", controllers.BlockUser)           //
-		adminRoutes.PUT("/users/unblock", controllers.UnblockUser)        //
+		adminRoutes.GET("/users", h.GetUserList)                //
+		adminRoutes.GET("/users/blocked", h.GetBlockedUserList) //
+		adminRoutes.PUT("/users/block/", h.BlockUser)           //
+		adminRoutes.PUT("/users/unblock", h.UnblockUser)        //
 
 		// Category Management
-		adminRoutes.POST("/categories/add", controllers.AddCategory)         //
-		adminRoutes.PATCH("/categories/edit", controllers.EditCategory)      //
-		adminRoutes.DELETE("/categories/delete", controllers.DeleteCategory) //
+		adminRoutes.POST("/categories/add", h.AddCategory)         //
+		adminRoutes.PATCH("/categories/edit", h.EditCategory)      //
+		adminRoutes.DELETE("/categories/delete", h.DeleteCategory) //
 
 		// Restaurant Management
-		adminRoutes.GET("/restaurants", controllers.GetRestaurants)
-		adminRoutes.PUT("/restaurants/block", controllers.BlockRestaurant)     //
-		adminRoutes.PUT("/restaurants/unblock", controllers.UnblockRestaurant) //
-		adminRoutes.PUT("/restaurants/verify/success", controllers.VerifyRestaurant)
-		adminRoutes.PUT("/restaurants/verify/failed", controllers.RemoveVerifyStatusRestaurant)
+		adminRoutes.GET("/restaurants", h.GetRestaurants)
+		adminRoutes.PUT("/restaurants/block", h.BlockRestaurant)     //
+		adminRoutes.PUT("/restaurants/unblock", h.UnblockRestaurant) //
+		adminRoutes.PUT("/restaurants/verify/success", h.VerifyRestaurant)
+		adminRoutes.PUT("/restaurants/verify/failed", h.RemoveVerifyStatusRestaurant)
 
 		// Coupon Management
-		adminRoutes.POST("/coupon/create", controllers.CreateCoupon)  //
-		adminRoutes.PATCH("/coupon/update", controllers.UpdateCoupon) //
+		adminRoutes.POST("/coupon/create", h.CreateCoupon)  //
+		adminRoutes.PATCH("/coupon/update", h.UpdateCoupon) //
+
+		// Ledger
+		adminRoutes.GET("/ledger/check", h.CheckLedger)
+
+		// Orders
+		adminRoutes.GET("/orders/timeline", h.OrderTimeline) //?order_id=
+
+		// Payment Reconciliation
+		adminRoutes.POST("/payments/reconcile", h.ReconcilePayments)
+		adminRoutes.GET("/payments/reconciliations", h.ListReconciliations)   //?limit=20
+		adminRoutes.GET("/payments/reconciliations/:id", h.GetReconciliation) //?format=csv
+
+		// Email Templates
+		adminRoutes.GET("/emails/templates", h.ListEmailTemplates)
+		adminRoutes.GET("/emails/templates/:name/preview", h.PreviewEmailTemplate) //?locale=hi&format=html
+		adminRoutes.GET("/emails/outbox", h.ListOutboxMessages)                    //?status=DEAD&limit=50
+		adminRoutes.POST("/emails/outbox/:id/requeue", h.RequeueOutboxMessage)
+
+		// Admin Management
+		adminRoutes.GET("/admins", h.ListAdmins)
+		adminRoutes.POST("/admins/invite", h.InviteAdmin) //super admins only
+		adminRoutes.POST("/recoverycodes", h.RegenerateRecoveryCodes)
+
+		// Sessions
+		adminRoutes.GET("/sessions", h.ListSessions)
+		adminRoutes.DELETE("/sessions/:id", h.RevokeSession)
+		adminRoutes.DELETE("/sessions", h.RevokeAllSessions) //logout everywhere
 	}
 }
 
-func PublicRoutes(router *gin.Engine) {
+func PublicRoutes(router *gin.Engine, h *controllers.Handler) {
 	// Public API Endpoints
 	publicRoute := router.Group("/api/v1/public")
 	{
 		//get restaurant profile info
-		publicRoute.GET("/restaurant/profile", controllers.GetRestaurantProfile)
-		publicRoute.GET("/coupon/all", controllers.GetAllCoupons)                   //
-		publicRoute.GET("/categories", controllers.GetCategoryList)                 //
-		publicRoute.GET("/categories/products", controllers.GetCategoryProductList) //
-		publicRoute.GET("/products", controllers.GetProductList)                    //
-		publicRoute.GET("/product/reviewandrating", controllers.ListAllReviewsandRating)
-		publicRoute.GET("/restaurants", controllers.GetRestaurants)                          //
-		publicRoute.GET("/restaurants/products/", controllers.GetProductsByRestaurantID)     //
-		publicRoute.GET("/products/onlyveg", controllers.OnlyVegProducts)                    //
-		publicRoute.GET("/products/newarrivals", controllers.NewArrivals)                    //
-		publicRoute.GET("/products/lowtohigh", controllers.PriceLowToHigh)                   //
-		publicRoute.GET("/products/hightolow", controllers.PriceHighToLow)                   //
-		publicRoute.GET("/products/offerproducts", controllers.GetProductOffers)             //
-		publicRoute.GET("/report/products", controllers.ProductReport)                       //
-		publicRoute.GET("/report/products/best", controllers.BestSellingProducts)            //
-		publicRoute.GET("/report/overallreport/all", controllers.PlatformOverallSalesReport) //
+		publicRoute.GET("/restaurant/profile", h.GetRestaurantProfile)
+		publicRoute.GET("/coupon/all", h.GetAllCoupons)                   //
+		publicRoute.GET("/categories", h.GetCategoryList)                 //
+		publicRoute.GET("/categories/products", h.GetCategoryProductList) //
+		publicRoute.GET("/products", h.GetProductList)                    //
+		publicRoute.GET("/product/reviewandrating", h.ListAllReviewsandRating)
+		publicRoute.GET("/restaurants", h.GetRestaurants)                          //
+		publicRoute.GET("/restaurants/products/", h.GetProductsByRestaurantID)     //
+		publicRoute.GET("/products/onlyveg", h.OnlyVegProducts)                    //
+		publicRoute.GET("/products/newarrivals", h.NewArrivals)                    //
+		publicRoute.GET("/products/lowtohigh", h.PriceLowToHigh)                   //
+		publicRoute.GET("/products/hightolow", h.PriceHighToLow)                   //
+		publicRoute.GET("/products/offerproducts", h.GetProductOffers)             //
+		publicRoute.GET("/report/products", h.ProductReport)                       //
+		publicRoute.GET("/report/products/best", h.BestSellingProducts)            //
+		publicRoute.GET("/report/overallreport/all", h.PlatformOverallSalesReport) //
 
 	}
 }
 
-func AdditionalRoutes(router *gin.Engine) {
+// WebhookRoutes are called by the payment providers, the signature of the request authenticates it
+func WebhookRoutes(router *gin.Engine, h *controllers.Handler) {
+	router.POST("/webhooks/stripe", h.StripeWebhook)
+	router.POST("/webhooks/razorpay", h.RazorpayWebhook)
+}
+
+func AdditionalRoutes(router *gin.Engine, h *controllers.Handler) {
 	// Additional Endpoints
 	router.GET("/api/v1/documentation", APIDocumentation)
-	router.GET("/api/v1/user/profileimage", view.LoadUpload)                                 //
-	router.POST("/api/v1/user/profileimage", controllers.UserProfileImageUpload)             //
-	router.GET("/api/v1/restaurant/profileimage", view.LoadUpload)                           //
-	router.POST("/api/v1/restaurant/profileimage", controllers.RestaurantProfileImageUpload) //
-	router.GET("/api/v1/logout", controllers.Logout)                                         //
+	router.GET("/api/v1/user/profileimage", view.LoadUpload)                                                             //
+	router.POST("/api/v1/user/profileimage", h.Authenticate(model.UserRole), h.UserProfileImageUpload)                   //
+	router.GET("/api/v1/restaurant/profileimage", view.LoadUpload)                                                       //
+	router.POST("/api/v1/restaurant/profileimage", h.Authenticate(model.RestaurantRole), h.RestaurantProfileImageUpload) //
+	router.GET("/api/v1/logout", h.Logout)                                                                               //
 }
 
 func APIDocumentation(c *gin.Context) {
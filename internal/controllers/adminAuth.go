@@ -1,97 +1,195 @@
 package controllers
 
 import (
-	"errors"
-	"foodbuddy/internal/database"
 	"foodbuddy/internal/model"
 	"foodbuddy/internal/utils"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
-func CheckAdmin(c *gin.Context) (string, error) {
-	email, _, err := utils.GetJWTClaim(c)
+// bindRequest reads and validates the json body, responding 400 when it is invalid
+func bindRequest(c *gin.Context, request interface{}) bool {
+	if err := c.ShouldBindJSON(request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "failed to process the incoming request",
+		})
+		return false
+	}
+	if err := utils.Validate(request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+// AdminLogin signs an admin in with the password and a totp code or a recovery code
+func (h *Handler) AdminLogin(c *gin.Context) {
+	var request model.AdminLoginRequest
+	if !bindRequest(c, &request) {
+		return
+	}
+
+	admin, err := h.svc.Admins.Login(request)
 	if err != nil {
-		return email, errors.New("request unauthorized")
+		respondError(c, err)
+		return
 	}
 
-	if email == "" {
-		return email, errors.New("request unauthorized")
+	tokens, err := h.startSession(c, admin.Email, model.AdminRole)
+	if err != nil {
+		respondError(c, err)
+		return
 	}
 
-	if err := VerifyJWT(c, model.AdminRole, email); err != nil {
-		return email, errors.New("request unauthorized")
+	response := gin.H{
+		"admin":         admin,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+	}
+	if request.Code == "" {
+		left, err := h.svc.Admins.RecoveryCodesLeft(admin.ID)
+		if err == nil {
+			response["recovery_codes_left"] = left
+		}
 	}
-	return email, nil
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Login is successful",
+		"data":    response,
+	})
 }
 
-func AdminLogin(c *gin.Context) {
-	adminEmail, exist := c.GetQuery("email")
-	if !exist {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "missing field email",
-		})
+// AcceptAdminInvite creates the admin account of an invite and returns the totp secret to enroll
+func (h *Handler) AcceptAdminInvite(c *gin.Context) {
+	var request model.AcceptAdminInviteRequest
+	if !bindRequest(c, &request) {
 		return
 	}
-	// Check if email exists in the admin table
-	var admin model.Admin
-	if tx := database.DB.Where("email = ?", adminEmail).First(&admin); tx.Error != nil {
-		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"status":  false,
-				"message": "Email not present in the admin table",
-			})
-			return
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"status":  false,
-				"message": "Database error",
-			})
-			return
-		}
+
+	enrollment, err := h.svc.Admins.AcceptInvite(request)
+	if err != nil {
+		respondError(c, err)
+		return
 	}
 
-	// Check if email exists in the verification table with admin role
-	var verification model.VerificationTable
-	if tx := database.DB.Where("email = ? AND role = ?", adminEmail, model.AdminRole).First(&verification); tx.Error != nil {
-		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
-			// Create a new entry in the verification table
-			verification = model.VerificationTable{
-				Email:              adminEmail,
-				Role:               model.AdminRole,
-				VerificationStatus: model.VerificationStatusPending,
-			}
-			if err := database.DB.Create(&verification).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"status":  false,
-					"message": "Failed to create admin verification entry",
-				})
-				return
-			}
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"status":  false,
-				"message": "Database error",
-			})
-			return
-		}
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  true,
+		"message": "invite accepted, add the account to an authenticator app and confirm a code to finish",
+		"data":    enrollment,
+	})
+}
+
+// ConfirmAdminTOTP finishes the totp enrollment, returns the recovery codes and signs the admin in
+func (h *Handler) ConfirmAdminTOTP(c *gin.Context) {
+	var request model.ConfirmAdminTOTPRequest
+	if !bindRequest(c, &request) {
+		return
 	}
 
-	// Send OTP
-	err := SendOTP(c, adminEmail, verification.OTPExpiry, verification.Role)
+	admin, codes, err := h.svc.Admins.ConfirmTOTP(request)
 	if err != nil {
-		c.JSON(http.StatusAlreadyReported, gin.H{
-			"status":  false,
-			"message": err.Error(),
-		})
+		respondError(c, err)
+		return
+	}
+
+	tokens, err := h.startSession(c, admin.Email, model.AdminRole)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "totp enabled, store the recovery codes somewhere safe, they are not shown again",
+		"data": gin.H{
+			"admin":          admin,
+			"recovery_codes": codes,
+			"token":          tokens.AccessToken,
+			"refresh_token":  tokens.RefreshToken,
+		},
+	})
+}
+
+// InviteAdmin mails an invite to a new admin, only super admins can invite
+func (h *Handler) InviteAdmin(c *gin.Context) {
+	adminID, ok := principalID(c, model.AdminRole)
+	if !ok {
+		return
+	}
+
+	var request model.AdminInviteRequest
+	if !bindRequest(c, &request) {
+		return
+	}
+
+	invite, err := h.svc.Admins.Invite(adminID, request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  true,
+		"message": "invite sent",
+		"data":    invite,
+	})
+}
+
+// ListAdmins returns the admin accounts and the invites sent
+func (h *Handler) ListAdmins(c *gin.Context) {
+	if !h.isAdmin(c) {
+		return
+	}
+
+	admins, err := h.svc.Admins.List()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	invites, err := h.svc.Admins.Invites()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "successfully retrieved admins",
+		"data": gin.H{
+			"admins":  admins,
+			"invites": invites,
+		},
+	})
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the admin making the request
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	adminID, ok := principalID(c, model.AdminRole)
+	if !ok {
+		return
+	}
+
+	var request model.RegenerateRecoveryCodesRequest
+	if !bindRequest(c, &request) {
+		return
+	}
+
+	codes, err := h.svc.Admins.RegenerateRecoveryCodes(adminID, request.Code)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"status":  true,
-		"message": "Verification link sent successfully. Please verify via that. Link expires soon .",
+		"message": "recovery codes replaced, the old codes no longer work",
+		"data": gin.H{
+			"recovery_codes": codes,
+		},
 	})
 }
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListEmailTemplates lists the email templates and the locales they are available in
+func (h *Handler) ListEmailTemplates(c *gin.Context) {
+	if !h.isAdmin(c) {
+		return
+	}
+
+	templates, err := h.svc.Emails.Templates()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "successfully retrieved email templates",
+		"data":    templates,
+	})
+}
+
+// PreviewEmailTemplate renders a template with its sample data. ?locale= picks the variant,
+// ?format=html or ?format=text returns that body as is to look at in the browser
+func (h *Handler) PreviewEmailTemplate(c *gin.Context) {
+	if !h.isAdmin(c) {
+		return
+	}
+
+	msg, err := h.svc.Emails.Preview(c.Param("name"), c.Query("locale"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	switch c.Query("format") {
+	case "html":
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(msg.HTML))
+		return
+	case "text":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(msg.Text))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "email template rendered with its sample data",
+		"data": gin.H{
+			"subject": msg.Subject,
+			"html":    msg.HTML,
+			"text":    msg.Text,
+		},
+	})
+}
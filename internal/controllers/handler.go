@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"errors"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/service"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves the http api on top of the services
+type Handler struct {
+	svc *service.Services
+}
+
+func NewHandler(svc *service.Services) *Handler {
+	return &Handler{svc: svc}
+}
+
+// respondError writes the error response, service errors carry their own status code
+func respondError(c *gin.Context, err error) {
+	var serviceErr *service.Error
+	if errors.As(err, &serviceErr) {
+		if serviceErr.Applied {
+			c.Set(appliedKey, true)
+		}
+		c.JSON(serviceErr.Code, gin.H{
+			"status":     false,
+			"message":    serviceErr.Message,
+			"error_code": serviceErr.Code,
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"status":     false,
+		"message":    err.Error(),
+		"error_code": http.StatusInternalServerError,
+	})
+}
+
+func unauthorized(c *gin.Context) {
+	c.JSON(http.StatusUnauthorized, gin.H{
+		"status":  false,
+		"message": "unauthorized request",
+	})
+}
+
+// locale is the preferred language of the request from the Accept-Language header,
+// it picks the variant of the emails sent while handling it
+func locale(c *gin.Context) string {
+	header := c.GetHeader("Accept-Language")
+	first := strings.TrimSpace(strings.Split(header, ",")[0])
+	return strings.TrimSpace(strings.Split(first, ";")[0])
+}
+
+// userID returns the id of the user making the request, responding 401 otherwise.
+// the principal is put on the context by Authenticate on the route group
+func (h *Handler) userID(c *gin.Context) (uint, bool) {
+	return principalID(c, model.UserRole)
+}
+
+// restaurantID returns the id of the restaurant making the request, responding 401 otherwise
+func (h *Handler) restaurantID(c *gin.Context) (uint, bool) {
+	return principalID(c, model.RestaurantRole)
+}
+
+// isAdmin reports whether the request is made by an admin, responding 401 otherwise
+func (h *Handler) isAdmin(c *gin.Context) bool {
+	_, ok := principalID(c, model.AdminRole)
+	return ok
+}
+
+func principalID(c *gin.Context, role string) (uint, bool) {
+	p, ok := principal(c)
+	if !ok || p.Role != role {
+		unauthorized(c)
+		return 0, false
+	}
+	return p.ID, true
+}
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"foodbuddy/internal/model"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// idempotentBodyLimit is the largest request body fingerprinted for an Idempotency-Key
+const idempotentBodyLimit = 1 << 20
+
+// appliedKey is set on the context when the error responded with came after the request
+// changed something
+const appliedKey = "applied"
+
+// Idempotent makes a mutating endpoint safe to retry. a request sent with an Idempotency-Key
+// header is handled once per account and key: repeating it replays the stored response,
+// repeating it while the first is still running is rejected with 409, and reusing the key
+// for a different request is rejected with 422. a server error frees the key for a retry unless
+// the request was applied before it failed. requests without the header, and requests with a
+// safe method that can be repeated anyway, go through as is
+func (h *Handler) Idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(model.IdempotencyKeyHeader)
+		if key == "" || safeMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+		p, ok := principal(c)
+		if !ok {
+			unauthorized(c)
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, idempotentBodyLimit))
+		if err != nil {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"status":  false,
+				"message": "failed to read the request",
+			})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		fmt.Fprintf(hash, "%s %s\n", c.Request.Method, c.Request.URL.RequestURI())
+		hash.Write(body)
+		owner := fmt.Sprintf("%s:%d", p.Role, p.ID)
+
+		record, replay, err := h.svc.Idempotency.Begin(owner, key, hex.EncodeToString(hash.Sum(nil)))
+		if err != nil {
+			respondError(c, err)
+			c.Abort()
+			return
+		}
+		if replay {
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(record.ResponseCode, record.ContentType, record.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		defer func() {
+			// a handler that panicked did not answer, the key is released for a retry
+			if r := recover(); r != nil {
+				h.svc.Idempotency.Complete(record, http.StatusInternalServerError, "", nil, false)
+				panic(r)
+			}
+		}()
+		c.Next()
+		h.svc.Idempotency.Complete(record, recorder.Status(), recorder.Header().Get("Content-Type"), recorder.body.Bytes(), c.GetBool(appliedKey))
+	}
+}
+
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// responseRecorder keeps a copy of the response body written through it
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -4,90 +4,69 @@ import (
 	"log"
 	"net/http"
 
-	"foodbuddy/internal/database"
 	"foodbuddy/internal/utils"
-	"foodbuddy/internal/model"
 
 	"github.com/gin-gonic/gin"
 )
 
-func UserProfileImageUpload(c *gin.Context) {
-	form, err := c.MultipartForm()
-	if err != nil {
-		log.Printf("Failed to get multipart form: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to get multipart form"})
+func (h *Handler) UserProfileImageUpload(c *gin.Context) {
+	UserID, ok := h.userID(c)
+	if !ok {
 		return
 	}
 
-	// Assuming there's only one file uploaded
-	fileHeaders := form.File["file"] // Replace "yourFieldName" with the actual field name used in the form
-	if len(fileHeaders) > 0 {
-		fileHeader := fileHeaders[0]
-		imageURL, err := utils.ImageUpload(fileHeader)
-		if err != nil {
-			log.Printf("Error uploading image: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image"})
-			return
-		}
+	imageURL, ok := uploadFormImage(c)
+	if !ok {
+		return
+	}
 
-		// fmt.Println(imageURL)
-		// c.Redirect(http.StatusMovedPermanently, imageURL)
-		email, role, err := utils.GetJWTClaim(c)
-		if role != model.UserRole || err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"status":  false,
-				"message": "unauthorized request",
-			})
-			return
-		}
-		UserID, _ := UserIDfromEmail(email)
-		if err:=database.DB.Model(&model.User{}).Where("id = ?",UserID).Update("picture",imageURL).Error;err!=nil{
-			c.JSON(http.StatusInternalServerError,gin.H{"status":false,"message":"failed to add image, please try again"+err.Error()})
-		}
+	if err := h.svc.Users.UpdatePicture(UserID, imageURL); err != nil {
+		respondError(c, err)
+		return
+	}
 
-		c.JSON(http.StatusOK,gin.H{"status":"success","message":"profile image uploaded"})
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "profile image uploaded"})
+}
 
-	} else {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+func (h *Handler) RestaurantProfileImageUpload(c *gin.Context) {
+	RestID, ok := h.restaurantID(c)
+	if !ok {
+		return
 	}
+
+	imageURL, ok := uploadFormImage(c)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Restaurants.UpdateImage(RestID, imageURL); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "profile image uploaded"})
 }
 
-func RestaurantProfileImageUpload(c *gin.Context) {
+// uploadFormImage uploads the "file" field of the multipart form and returns its url
+func uploadFormImage(c *gin.Context) (string, bool) {
 	form, err := c.MultipartForm()
 	if err != nil {
 		log.Printf("Failed to get multipart form: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to get multipart form"})
-		return
+		return "", false
 	}
 
 	fileHeaders := form.File["file"]
-	if len(fileHeaders) > 0 {
-		fileHeader := fileHeaders[0]
-		imageURL, err := utils.ImageUpload(fileHeader)
-		if err != nil {
-			log.Printf("Error uploading image: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image"})
-			return
-		}
-
-		// fmt.Println(imageURL)
-		// c.Redirect(http.StatusMovedPermanently, imageURL)
-		email, role, err := utils.GetJWTClaim(c)
-		if role != model.RestaurantRole || err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"status":  false,
-				"message": "unauthorized request",
-			})
-			return
-		}
-		RestID, _ := RestIDfromEmail(email)
-		if err:=database.DB.Model(&model.Restaurant{}).Where("id = ?",RestID).Update("image_url",imageURL).Error;err!=nil{
-			c.JSON(http.StatusInternalServerError,gin.H{"status":false,"message":"failed to add image, please try again"+err.Error()})
-		}
-
-		c.JSON(http.StatusOK,gin.H{"status":"success","message":"profile image uploaded"})
-
-	} else {
+	if len(fileHeaders) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return "", false
+	}
+
+	imageURL, err := utils.ImageUpload(fileHeaders[0])
+	if err != nil {
+		log.Printf("Error uploading image: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image"})
+		return "", false
 	}
+	return imageURL, true
 }
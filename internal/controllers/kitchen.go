@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"foodbuddy/internal/model"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KitchenFeed streams the restaurant's kitchen events as server-sent events: the items paid for
+// or placed as cash on delivery, their cancellations and changes to their cooking requests.
+// every event carries its seq, a kitchen reconnecting with it in the Last-Event-ID header, or
+// in ?cursor=, gets the events it missed first. without a cursor the feed starts from now.
+// the feed ends with the access token it was opened with, when it expires or is revoked
+func (h *Handler) KitchenFeed(c *gin.Context) {
+	RestaurantID, ok := h.restaurantID(c)
+	if !ok {
+		return
+	}
+	p, _ := principal(c)
+
+	cursorParam := c.GetHeader("Last-Event-ID")
+	if cursorParam == "" {
+		cursorParam = c.DefaultQuery("cursor", "0")
+	}
+	cursor, err := strconv.ParseUint(cursorParam, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":     false,
+			"message":    "cursor should be the id of the last event received",
+			"error_code": http.StatusBadRequest,
+		})
+		return
+	}
+
+	subscription, reset, err := h.svc.Kitchen.Follow(RestaurantID, uint(cursor))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	defer subscription.Close()
+
+	// the first batch of missed events is loaded before the stream starts, so failing to load it
+	// still gets an error status. a kitchen without a cursor starts from now
+	var missed []model.KitchenEvent
+	if cursor != 0 {
+		missed, err = h.svc.Kitchen.Missed(RestaurantID, uint(cursor))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	if reset {
+		writeServerEvent(c.Writer, 0, model.KitchenEventReset, gin.H{"message": "events after the cursor are no longer kept, reload the orders"})
+	}
+
+	// the events commit in their seq order, so the live ones up to the last replayed were
+	// replayed already
+	replayed := uint(cursor)
+	for {
+		for _, event := range missed {
+			writeServerEvent(c.Writer, event.Seq, event.EventType, event)
+			replayed = event.Seq
+		}
+		if len(missed) < model.KitchenReplayBatch {
+			break
+		}
+		missed, err = h.svc.Kitchen.Missed(RestaurantID, replayed)
+		if err != nil {
+			// the kitchen reconnects from the last event it got
+			writeServerEvent(c.Writer, 0, model.StreamEventError, gin.H{"message": err.Error()})
+			c.Writer.Flush()
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	expiry := time.NewTimer(time.Until(p.TokenExpiresAt))
+	defer expiry.Stop()
+	heartbeat := time.NewTicker(trackingHeartbeat)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case published, ok := <-subscription.Events():
+			if !ok {
+				return false
+			}
+			event, ok := published.Data.(model.KitchenEvent)
+			if ok && event.Seq > replayed {
+				writeServerEvent(w, event.Seq, event.EventType, event)
+			}
+			return true
+		case <-expiry.C:
+			return endStream(c)
+		case <-heartbeat.C:
+			if !h.sessionActive(p) {
+				return endStream(c)
+			}
+			fmt.Fprint(w, ": ping\n\n")
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// writeServerEvent writes a server-sent event, events without an id leave the client's cursor alone
+func writeServerEvent(w io.Writer, id uint, event string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	if id != 0 {
+		fmt.Fprintf(w, "id:%d\n", id)
+	}
+	fmt.Fprintf(w, "event:%s\ndata:%s\n\n", event, payload)
+}
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CheckLedger audits the wallet ledger for unbalanced entries and wallets that drifted from it
+func (h *Handler) CheckLedger(c *gin.Context) {
+	if !h.isAdmin(c) {
+		return
+	}
+
+	check, err := h.svc.Ledger.Check()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	message := "ledger is balanced"
+	if !check.Balanced {
+		message = "ledger has imbalances"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": message,
+		"data":    check,
+	})
+}
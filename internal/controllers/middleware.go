@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"foodbuddy/internal/service"
+	"foodbuddy/internal/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const principalKey = "principal"
+
+// Authenticate only lets requests through that carry a valid, unrevoked token of the role, the
+// account behind the token is loaded once and put on the context for the handlers
+func (h *Handler) Authenticate(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := utils.GetJWTClaims(c)
+		if err != nil || claims.Role != role {
+			unauthorized(c)
+			c.Abort()
+			return
+		}
+		if err := h.svc.Sessions.Verify(claims.TokenID); err != nil {
+			respondError(c, err)
+			c.Abort()
+			return
+		}
+
+		principal, err := h.svc.Auth.Principal(claims.Email, role)
+		if err != nil {
+			unauthorized(c)
+			c.Abort()
+			return
+		}
+		if principal.Blocked {
+			c.JSON(http.StatusForbidden, gin.H{
+				"status":  false,
+				"message": "your account is blocked",
+			})
+			c.Abort()
+			return
+		}
+
+		principal.SessionID = claims.SessionID
+		principal.TokenID, principal.TokenExpiresAt = claims.TokenID, claims.ExpiresAt
+		c.Set(principalKey, principal)
+		c.Next()
+	}
+}
+
+// sessionActive reports whether the access token the request came with is still valid, a
+// stream checks it while it stays open
+func (h *Handler) sessionActive(p service.Principal) bool {
+	return time.Now().Before(p.TokenExpiresAt) && h.svc.Sessions.Verify(p.TokenID) == nil
+}
+
+// principal returns who the request acts for, set by Authenticate
+func principal(c *gin.Context) (service.Principal, bool) {
+	value, exists := c.Get(principalKey)
+	if !exists {
+		return service.Principal{}, false
+	}
+	p, ok := value.(service.Principal)
+	return p, ok
+}
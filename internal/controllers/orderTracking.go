@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"fmt"
+	"foodbuddy/internal/model"
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// trackingHeartbeat keeps an idle tracking stream open through proxies
+const trackingHeartbeat = 30 * time.Second
+
+// TrackOrder streams the user's order as server-sent events: a snapshot of the order first,
+// then every item status change, the payment confirmation and the delivery code being sent.
+// a client that falls behind is disconnected, EventSource reconnects and gets a new snapshot.
+// the stream ends with the access token it was opened with, when it expires or is revoked
+func (h *Handler) TrackOrder(c *gin.Context) {
+	UserID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+	p, _ := principal(c)
+
+	timeline, subscription, err := h.svc.Orders.Track(UserID, c.Query("order_id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	defer subscription.Close()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent(model.OrderEventSnapshot, timeline)
+	c.Writer.Flush()
+
+	expiry := time.NewTimer(time.Until(p.TokenExpiresAt))
+	defer expiry.Stop()
+	heartbeat := time.NewTicker(trackingHeartbeat)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		case <-expiry.C:
+			return endStream(c)
+		case <-heartbeat.C:
+			// the revocations are checked with the heartbeat
+			if !h.sessionActive(p) {
+				return endStream(c)
+			}
+			// a comment line, EventSource ignores it
+			fmt.Fprint(w, ": ping\n\n")
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// endStream tells the client its session ended before closing the stream, EventSource
+// reconnects with the renewed token or gets a 401
+func endStream(c *gin.Context) bool {
+	c.SSEvent(model.StreamEventSessionEnded, gin.H{"message": "session has ended, reconnect with a renewed token"})
+	return false
+}
@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListOutboxMessages lists the queued emails, ?status= filters them, DEAD shows the dead letters
+func (h *Handler) ListOutboxMessages(c *gin.Context) {
+	if !h.isAdmin(c) {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	messages, err := h.svc.Outbox.List(c.Query("status"), limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "successfully retrieved outbox messages",
+		"data":    messages,
+	})
+}
+
+// RequeueOutboxMessage gives a dead lettered email another round of delivery attempts
+func (h *Handler) RequeueOutboxMessage(c *gin.Context) {
+	if !h.isAdmin(c) {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "invalid outbox message id",
+		})
+		return
+	}
+
+	if err := h.svc.Outbox.Requeue(uint(id)); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "message requeued",
+	})
+}
@@ -1,7 +1,6 @@
 package controllers
 
 import (
-	"foodbuddy/internal/database"
 	"foodbuddy/internal/model"
 	"foodbuddy/internal/utils"
 	"net/http"
@@ -11,15 +10,10 @@ import (
 )
 
 // public
-func GetProductList(c *gin.Context) {
-	var Products []model.Product
-
-	tx := database.DB.Select("*").Find(&Products)
-	if tx.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"status":  false,
-			"message": "failed to retrieve data from the database, or the product doesn't exist",
-		})
+func (h *Handler) GetProductList(c *gin.Context) {
+	Products, err := h.svc.Products.List()
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -33,9 +27,8 @@ func GetProductList(c *gin.Context) {
 }
 
 // public
-func GetProductsByRestaurantID(c *gin.Context) {
-	restaurantIDStr := c.Query("restaurantid")
-	restaurantID, err := strconv.Atoi(restaurantIDStr)
+func (h *Handler) GetProductsByRestaurantID(c *gin.Context) {
+	restaurantID, err := strconv.Atoi(c.Query("restaurantid"))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"status":  false,
@@ -44,12 +37,9 @@ func GetProductsByRestaurantID(c *gin.Context) {
 		return
 	}
 
-	var products []model.Product
-	if err := database.DB.Where("restaurant_id =?", restaurantID).Find(&products).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  false,
-			"message": "failed to retrieve products",
-		})
+	products, err := h.svc.Products.ListByRestaurant(uint(restaurantID))
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -63,28 +53,15 @@ func GetProductsByRestaurantID(c *gin.Context) {
 }
 
 // restuarant id
-func AddProduct(c *gin.Context) {
-
+func (h *Handler) AddProduct(c *gin.Context) {
 	//check restaurant api authentication
-	email, role, err := utils.GetJWTClaim(c)
-	if role != model.RestaurantRole || err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "unauthorized request",
-		})
-		return
-	}
-	JWTRestaurantID, ok := RestIDfromEmail(email)
+	JWTRestaurantID, ok := h.restaurantID(c)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  false,
-			"message": "failed to get restaurant information",
-		})
 		return
 	}
+
 	// Bind JSON
 	var Request model.AddProductRequest
-
 	if err := c.BindJSON(&Request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"status":  false,
@@ -101,68 +78,9 @@ func AddProduct(c *gin.Context) {
 		return
 	}
 
-	if Request.Veg != model.YES && Request.Veg != model.NO {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  false,
-			"message": "please specify if the product is vegetarian by 'YES' or 'NO' ",
-		})
-		return
-	}
-
-	if Request.Price < Request.OfferAmount {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  false,
-			"message": "offer amount should not be more than the product price",
-		})
-		return
-	}
-
-	// Check if the restaurant ID is correct and present in the database
-	var restaurant model.Restaurant
-	if err := database.DB.First(&restaurant, JWTRestaurantID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"status":  false,
-			"message": "restaurant not found",
-		})
-		return
-	}
-
-	// Check if the category is present
-	var category model.Category
-	if err := database.DB.First(&category, Request.CategoryID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"status":  false,
-			"message": "category doesn't exist",
-		})
-		return
-	}
-
-	// Check if the product name already exists within the same restaurant
-	var existingProduct model.Product
-	if err := database.DB.Where("name =? AND restaurant_id =? AND deleted_at IS NULL", Request.Name, JWTRestaurantID).First(&existingProduct).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  false,
-			"message": "product with the same name already exists in this restaurant",
-		})
-		return
-	}
-	existingProduct = model.Product{
-		RestaurantID: JWTRestaurantID,
-		CategoryID:   Request.CategoryID,
-		Name:         Request.Name,
-		Description:  Request.Description,
-		ImageURL:     Request.ImageURL,
-		Price:        Request.Price,
-		MaxStock:     Request.MaxStock,
-		StockLeft:    Request.StockLeft,
-		Veg:          Request.Veg,
-	}
-
-	if err := database.DB.Create(&existingProduct).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  false,
-			"message": "failed to create product",
-		})
+	product, err := h.svc.Products.Create(JWTRestaurantID, Request)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -171,29 +89,17 @@ func AddProduct(c *gin.Context) {
 		"status":  true,
 		"message": "successfully added new product",
 		"data": gin.H{
-			"id":      existingProduct.ID,
+			"id":      product.ID,
 			"product": Request,
 		},
 	})
 }
 
 // restaurant id
-func EditProduct(c *gin.Context) {
+func (h *Handler) EditProduct(c *gin.Context) {
 	//check restaurant api authentication
-	email, role, err := utils.GetJWTClaim(c)
-	if role != model.RestaurantRole || err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "unauthorized request",
-		})
-		return
-	}
-	JWTRestaurantID, ok := RestIDfromEmail(email)
+	JWTRestaurantID, ok := h.restaurantID(c)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  false,
-			"message": "failed to get restaurant information",
-		})
 		return
 	}
 
@@ -215,56 +121,8 @@ func EditProduct(c *gin.Context) {
 		return
 	}
 
-	//check jwt rest id and product rest id
-	pRestID := RestaurantIDByProductID(Request.ProductID)
-	if JWTRestaurantID != pRestID {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "unauthorized request, product is not yours",
-		})
-		return
-	}
-
-	// Check if the product exists by id
-	var existingProduct model.Product
-	if err := database.DB.Where("id = ?", Request.ProductID).First(&existingProduct).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  false,
-			"message": "failed to fetch product from the database",
-		})
-		return
-	}
-
-	if Request.Veg != model.YES && Request.Veg != model.NO {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  false,
-			"message": "please specify if the product is vegetarian by 'YES' or 'NO' ",
-		})
-		return
-	}
-
-	if Request.Price < Request.OfferAmount {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  false,
-			"message": "offer amount should not be more than the product price",
-		})
-		return
-	}
-
-	existingProduct.Name = Request.Name
-	existingProduct.Description = Request.Description
-	existingProduct.ImageURL = Request.ImageURL
-	existingProduct.Price = Request.Price
-	existingProduct.MaxStock = Request.MaxStock
-	existingProduct.StockLeft = Request.StockLeft
-	existingProduct.Veg = Request.Veg
-
-	// Update product details
-	if err := database.DB.Where("id = ?", Request.ProductID).Updates(&existingProduct).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  false,
-			"message": "failed to update product",
-		})
+	if err := h.svc.Products.Update(JWTRestaurantID, Request); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -278,28 +136,15 @@ func EditProduct(c *gin.Context) {
 }
 
 // restaurant id
-func DeleteProduct(c *gin.Context) {
+func (h *Handler) DeleteProduct(c *gin.Context) {
 	//check restaurant api authentication
-	email, role, err := utils.GetJWTClaim(c)
-	if role != model.RestaurantRole || err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "unauthorized request",
-		})
-		return
-	}
-	JWTRestaurantID, ok := RestIDfromEmail(email)
+	JWTRestaurantID, ok := h.restaurantID(c)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  false,
-			"message": "failed to get restaurant information",
-		})
 		return
 	}
 
 	// Get product id from parameters
-	productIDStr := c.Query("productid")
-	productID, err := strconv.Atoi(productIDStr)
+	productID, err := strconv.Atoi(c.Query("productid"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"status":  false,
@@ -307,34 +152,12 @@ func DeleteProduct(c *gin.Context) {
 		})
 		return
 	}
-	ProductRestaurantID := RestaurantIDByProductID(uint(productID))
 
-	if JWTRestaurantID != ProductRestaurantID {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "unauthorized request",
-		})
+	if err := h.svc.Products.Delete(JWTRestaurantID, uint(productID)); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	// Check if the product exists by id
-	var product model.Product
-	if err := database.DB.First(&product, productID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"status":  false,
-			"message": "product is not present in the database",
-		})
-		return
-	}
-
-	//delete the product
-	if err := database.DB.Delete(&product, productID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  false,
-			"message": "unable to delete the product from the database",
-		})
-		return
-	}
 	c.JSON(http.StatusOK, gin.H{
 		"status":  true,
 		"message": "successfully deleted the product",
@@ -342,36 +165,16 @@ func DeleteProduct(c *gin.Context) {
 }
 
 // user id
-func GetUsersFavouriteProduct(c *gin.Context) {
+func (h *Handler) GetUsersFavouriteProduct(c *gin.Context) {
 	//check user api authentication
-	email, role, err := utils.GetJWTClaim(c)
-	if role != model.UserRole || err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "unauthorized request",
-		})
+	UserID, ok := h.userID(c)
+	if !ok {
 		return
 	}
-	UserID, _ := UserIDfromEmail(email)
-
-	// //checking the user sending is performing in his/her account..
-	// _, ok := EmailFromUserID(UserID)
-	// if !ok {
-	// 	c.JSON(http.StatusNotFound, gin.H{
-	// 		"status":     false,
-	// 		"message":    "failed to get user email from the database",
-	// 		"error_code": http.StatusNotFound,
-	// 	})
-	// 	return
-	// }
 
-	var FavouriteProducts []model.FavouriteProduct
-
-	if err := database.DB.Where("user_id =?", UserID).Find(&FavouriteProducts).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"status":  false,
-			"message": "the user ID doesn't exist in the database",
-		})
+	FavouriteProducts, err := h.svc.Users.Favourites(UserID)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -383,18 +186,12 @@ func GetUsersFavouriteProduct(c *gin.Context) {
 }
 
 // user id
-func AddFavouriteProduct(c *gin.Context) {
-
+func (h *Handler) AddFavouriteProduct(c *gin.Context) {
 	//check user api authentication
-	email, role, err := utils.GetJWTClaim(c)
-	if role != model.UserRole || err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "unauthorized request",
-		})
+	UserID, ok := h.userID(c)
+	if !ok {
 		return
 	}
-	UserID, _ := UserIDfromEmail(email)
 
 	var request struct {
 		ProductID uint `validate:"required,number" json:"product_id"`
@@ -408,53 +205,16 @@ func AddFavouriteProduct(c *gin.Context) {
 		return
 	}
 
-	// Extracted validation logic for clarity
 	if err := utils.Validate(request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"status":  false,
-			"message": err,
-		})
-		return
-	}
-
-	var existingFavouriteProduct model.FavouriteProduct
-	var userinfo model.User
-	var productinfo model.Product
-
-	// Check if the user exists
-	if err := database.DB.Where("id =?", UserID).First(&userinfo).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  false,
-			"message": "user not found",
-		})
-		return
-	}
-
-	// Check if the product exists
-	if err := database.DB.Where("id =?", request.ProductID).First(&productinfo).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  false,
-			"message": "product not found",
-		})
-		return
-	}
-
-	// Check if the favorite product combination already exists
-	if err := database.DB.Where("user_id =? AND product_id =?", UserID, request.ProductID).First(&existingFavouriteProduct).Error; err == nil {
-		// If there's no error, it means the favorite product already exists
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  false,
-			"message": "favorite product already exists",
+			"message": err.Error(),
 		})
 		return
 	}
 
-	// If everything checks out, add the favorite product
-	if err := database.DB.Create(&model.FavouriteProduct{UserID: UserID, ProductID: request.ProductID}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  false,
-			"message": "failed to add favorite product",
-		})
+	if err := h.svc.Users.AddFavourite(UserID, request.ProductID); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -465,17 +225,12 @@ func AddFavouriteProduct(c *gin.Context) {
 }
 
 // user id
-func RemoveFavouriteProduct(c *gin.Context) {
+func (h *Handler) RemoveFavouriteProduct(c *gin.Context) {
 	//check user api authentication
-	email, role, err := utils.GetJWTClaim(c)
-	if role != model.UserRole || err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "unauthorized request",
-		})
+	UserID, ok := h.userID(c)
+	if !ok {
 		return
 	}
-	UserID, _ := UserIDfromEmail(email)
 
 	var request struct {
 		ProductID uint `validate:"required,number" json:"product_id"`
@@ -489,96 +244,37 @@ func RemoveFavouriteProduct(c *gin.Context) {
 		return
 	}
 
-	// Extracted validation logic for clarity
 	if err := utils.Validate(request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"status":  false,
-			"message": "unauthorized user",
+			"message": err.Error(),
 		})
 		return
 	}
 
-	var existingFavouriteProduct model.FavouriteProduct
-
-	if err := database.DB.Where(&model.FavouriteProduct{UserID: UserID, ProductID: request.ProductID}).First(&existingFavouriteProduct).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  false,
-			"message": "favorite product doesn't exist",
-		})
-		return
-	}
-	if err := database.DB.Where("user_id =? AND product_id =?", UserID, request.ProductID).Delete(&model.FavouriteProduct{}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  false,
-			"message": "failed to delete favorite product",
-		})
+	if err := h.svc.Users.RemoveFavourite(UserID, request.ProductID); err != nil {
+		respondError(c, err)
 		return
 	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":  true,
 		"message": "favorite product deleted successfully",
 	})
 }
 
-func RestaurantIDByProductID(ProductID uint) uint {
-
-	var Product model.Product
-	if err := database.DB.Where("id = ?", ProductID).First(&Product).Error; err != nil {
-		return 0
-	}
-	return Product.RestaurantID
-}
-
-func OnlyVegProducts(c *gin.Context) {
-	var products []model.Product
-
-	tx := database.DB.Where("veg = ?", model.YES).
-		Order("price ASC").
-		Find(&products)
-
-	if tx.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"status":  false,
-			"message": "failed to get product information",
-		})
+func (h *Handler) OnlyVegProducts(c *gin.Context) {
+	response, err := h.svc.Products.Veg()
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	var response []model.ProductResponse
-	for _, product := range products {
-		var dbCategory model.Category
-		var dbRestaurant model.Restaurant
-
-		if err := database.DB.Where("id = ?", product.CategoryID).First(&dbCategory).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"status": false, "message": "failed to get category information"})
-			return
-		}
-
-		if err := database.DB.Where("id = ?", product.RestaurantID).First(&dbRestaurant).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"status": false, "message": "failed to get restaurant information"})
-			return
-		}
-
-		response = append(response, model.ProductResponse{
-			ID:             product.ID,
-			RestaurantName: dbRestaurant.Name,
-			CategoryName:   dbCategory.Name,
-			Name:           product.Name,
-			Description:    product.Description,
-			ImageURL:       product.ImageURL,
-			Price:          product.Price,
-			StockLeft:      product.StockLeft,
-			AverageRating:  product.AverageRating,
-			Veg:            product.Veg,
-		})
-	}
-
 	c.JSON(http.StatusOK, gin.H{"status": true, "data": response})
 }
 
-func AddProductOffer(c *gin.Context) {
+func (h *Handler) AddProductOffer(c *gin.Context) {
 	var request model.AddOfferRequest
-
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"status":  false,
@@ -587,40 +283,14 @@ func AddProductOffer(c *gin.Context) {
 		return
 	}
 
-	email, role, err := utils.GetJWTClaim(c)
-	if role != model.RestaurantRole || err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "unauthorized request",
-		})
-		return
-	}
-
-	pRestID := RestaurantIDByProductID(request.ProductID)
-	RestID, _ := RestIDfromEmail(email)
-	if pRestID != RestID {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "StatusUnauthorized",
-		})
-		return
-	}
-
-	exist, Product := CheckProduct(int(request.ProductID))
-	if !exist {
-		c.JSON(http.StatusNotFound, gin.H{
-			"status":  false,
-			"message": "failed to find the product",
-		})
+	RestID, ok := h.restaurantID(c)
+	if !ok {
 		return
 	}
 
-	Product.OfferAmount = request.OfferAmount
-	if err := database.DB.Model(&Product).Where("id = ?", request.ProductID).Update("offer_amount", request.OfferAmount).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  false,
-			"message": "failed to add the offer amount",
-		})
+	Product, err := h.svc.Products.SetOffer(RestID, request.ProductID, request.OfferAmount)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -631,8 +301,7 @@ func AddProductOffer(c *gin.Context) {
 	})
 }
 
-func RemoveProductOffer(c *gin.Context) {
-
+func (h *Handler) RemoveProductOffer(c *gin.Context) {
 	ProductID, err := strconv.Atoi(c.Query("productid"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -642,40 +311,14 @@ func RemoveProductOffer(c *gin.Context) {
 	}
 
 	//check restaurant api authentication
-	email, role, err := utils.GetJWTClaim(c)
-	if role != model.RestaurantRole || err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "unauthorized request",
-		})
-		return
-	}
-
-	pRestID := RestaurantIDByProductID(uint(ProductID))
-	RestID, _ := RestIDfromEmail(email)
-	if pRestID != RestID {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "StatusUnauthorized",
-		})
-		return
-	}
-
-	exist, Product := CheckProduct(ProductID)
-	if !exist {
-		c.JSON(http.StatusNotFound, gin.H{
-			"status":  false,
-			"message": "failed to find the product",
-		})
+	RestID, ok := h.restaurantID(c)
+	if !ok {
 		return
 	}
 
-	Product.OfferAmount = 0
-	if err := database.DB.Model(&Product).Where("id = ?", ProductID).Update("offer_amount", 0).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  false,
-			"message": "failed to remove the offer amount",
-		})
+	Product, err := h.svc.Products.SetOffer(RestID, uint(ProductID), 0)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -684,29 +327,13 @@ func RemoveProductOffer(c *gin.Context) {
 		"message": "successfully removed the offer amount",
 		"data":    Product,
 	})
-
 }
 
-func CheckProduct(ProductID int) (bool, model.Product) {
-	var Product model.Product
-
-	if err := database.DB.Where("id = ?", ProductID).First(&Product).Error; err != nil {
-		return false, model.Product{}
-	}
-
-	return true, Product
-
-}
-
-func GetProductOffers(c *gin.Context) {
+func (h *Handler) GetProductOffers(c *gin.Context) {
 	//get products with more than 0 in offer_amount
-	var Products []model.Product
-
-	if err := database.DB.Where("offer_amount > ?", 0).Find(&Products).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"status": false, "message": "failed to get product details",
-		})
-
+	Products, err := h.svc.Products.Offers()
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -715,31 +342,18 @@ func GetProductOffers(c *gin.Context) {
 	})
 }
 
-func ListAllReviewsandRating(c *gin.Context) {
-    ProductID := c.Query("product_id")
-
-    if ProductID == "" {
-        c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "provide product_id in the query params"})
-        return
-    }
-
-    var orderItems []model.OrderItem
-    if err := database.DB.Where("product_id = ?", ProductID).Find(&orderItems).Error; err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"status": false, "message": "failed to fetch reviews and ratings"})
-        return
-    }
-
-    var response []map[string]interface{}
-    for _, item := range orderItems {
-        // Check if either review or rating is non-null
-        if item.OrderReview != "" || item.OrderRating != 0 {
-            response = append(response, map[string]interface{}{
-                "userid":    item.UserID,
-                "rating":    item.OrderRating,
-                "review":    item.OrderReview,
-            })
-        }
-    }
-
-    c.JSON(http.StatusOK, gin.H{"status": true, "data": response})
-}
\ No newline at end of file
+func (h *Handler) ListAllReviewsandRating(c *gin.Context) {
+	ProductID, _ := strconv.Atoi(c.Query("product_id"))
+	if ProductID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "provide product_id in the query params"})
+		return
+	}
+
+	response, err := h.svc.Products.Reviews(uint(ProductID))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "data": response})
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"fmt"
+	"foodbuddy/internal/model"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gocarina/gocsv"
+)
+
+// ReconcilePayments runs the payment reconciliation now instead of waiting for the schedule
+func (h *Handler) ReconcilePayments(c *gin.Context) {
+	if !h.isAdmin(c) {
+		return
+	}
+
+	report, err := h.svc.Reconciliation.Run(c.Request.Context(), model.ReconcileTriggerAdmin)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": fmt.Sprintf("reconciliation checked %d payments and found %d discrepancies", report.Run.Checked, report.Run.Discrepancies),
+		"data":    report,
+	})
+}
+
+// ListReconciliations lists the latest reconciliation runs
+func (h *Handler) ListReconciliations(c *gin.Context) {
+	if !h.isAdmin(c) {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	runs, err := h.svc.Reconciliation.Runs(limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "successfully retrieved reconciliation runs",
+		"data":    runs,
+	})
+}
+
+// GetReconciliation returns the discrepancy report of a run, ?format=csv downloads it for finance
+func (h *Handler) GetReconciliation(c *gin.Context) {
+	if !h.isAdmin(c) {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "invalid reconciliation run id",
+		})
+		return
+	}
+
+	report, err := h.svc.Reconciliation.Report(uint(id))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  true,
+			"message": "successfully retrieved reconciliation report",
+			"data":    report,
+		})
+		return
+	}
+
+	tmpfile, err := os.CreateTemp("", "reconciliation_*.csv")
+	if err != nil {
+		log.Printf("Failed to create temp file: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create CSV file"})
+		return
+	}
+	defer os.Remove(tmpfile.Name())
+
+	if err := gocsv.MarshalFile(report.Discrepancies, tmpfile); err != nil {
+		log.Printf("Failed to marshal CSV data: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write CSV data"})
+		return
+	}
+	c.Writer.Header().Set("Content-Type", "text/csv")
+	c.Writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=reconciliation_%d.csv", report.Run.ID))
+	c.File(tmpfile.Name())
+}
@@ -1,8 +1,6 @@
 package controllers
 
 import (
-	"fmt"
-	"foodbuddy/internal/database"
 	"foodbuddy/internal/model"
 	"foodbuddy/internal/utils"
 	"log"
@@ -12,13 +10,10 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gocarina/gocsv"
-	passwordvalidator "github.com/wagslane/go-password-validator"
-	"golang.org/x/crypto/bcrypt"
-	"gorm.io/gorm"
 )
 
 // RestaurantSignup
-func RestaurantSignup(c *gin.Context) {
+func (h *Handler) RestaurantSignup(c *gin.Context) {
 	// bind json to struct
 	var restaurantSignup model.RestaurantSignupRequest
 	if err := c.BindJSON(&restaurantSignup); err != nil {
@@ -38,90 +33,9 @@ func RestaurantSignup(c *gin.Context) {
 		return
 	}
 
-	//check if the password and the confirm password is correct
-	if restaurantSignup.Password != restaurantSignup.ConfirmPassword {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  false,
-			"message": "passwords doesn't match",
-		})
-		return
-	}
-
-	err := passwordvalidator.Validate(restaurantSignup.Password, model.PasswordEntropy)
+	restaurant, err := h.svc.Auth.RestaurantSignup(restaurantSignup)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  false,
-			"message": err.Error(),
-		})
-		return
-	}
-
-	// check if email exists
-	var verification model.VerificationTable
-	tx := database.DB.Where("email = ? AND role = ?", restaurantSignup.Email, model.RestaurantRole).First(&verification)
-	if tx.Error != nil && tx.Error != gorm.ErrRecordNotFound {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  false,
-			"message": "database error",
-		})
-		return
-	} else if tx.Error == gorm.ErrRecordNotFound {
-		// create new entry in verification table
-		verification = model.VerificationTable{
-			Email:              restaurantSignup.Email,
-			Role:               model.RestaurantRole,
-			VerificationStatus: model.VerificationStatusPending,
-		}
-		tx = database.DB.Create(&verification)
-		if tx.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"status":  false,
-				"message": "failed to create restaurant verification entry",
-			})
-			return
-		}
-	} else {
-		// email already exists
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  false,
-			"message": "restaurant email already exists",
-		})
-		return
-	}
-
-	// generate salt and hash password
-	salt := utils.GenerateRandomString(7)
-	saltedPassword := salt + restaurantSignup.Password
-	hash, err := bcrypt.GenerateFromPassword([]byte(saltedPassword), bcrypt.DefaultCost)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  false,
-			"message": "failed to process the request",
-		})
-		return
-	}
-
-	// create restaurant record
-	restaurant := model.Restaurant{
-		Name:               restaurantSignup.Name,
-		Description:        restaurantSignup.Description,
-		Address:            restaurantSignup.Address,
-		Email:              restaurantSignup.Email,
-		PhoneNumber:        restaurantSignup.PhoneNumber,
-		ImageURL:           restaurantSignup.ImageURL,
-		CertificateURL:     restaurantSignup.CertificateURL,
-		VerificationStatus: model.VerificationStatusPending,
-		Blocked:            false,
-		Salt:               salt,
-		HashedPassword:     string(hash),
-	}
-
-	// save to database
-	if err := database.DB.Create(&restaurant).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  false,
-			"message": "failed to save restaurant data",
-		})
+		respondError(c, err)
 		return
 	}
 
@@ -144,11 +58,9 @@ func RestaurantSignup(c *gin.Context) {
 }
 
 // RestaurantLogin
-func RestaurantLogin(c *gin.Context) {
+func (h *Handler) RestaurantLogin(c *gin.Context) {
 	// Get struct
 	var restaurantLogin model.RestaurantLoginRequest
-	var existingRestaurant model.Restaurant
-
 	if err := c.BindJSON(&restaurantLogin); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"status":  false,
@@ -166,65 +78,15 @@ func RestaurantLogin(c *gin.Context) {
 		return
 	}
 
-	// Check email on restaurant DB
-	if err := database.DB.Where("email = ?", restaurantLogin.Email).First(&existingRestaurant).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  false,
-			"message": "Error fetching restaurant details",
-		})
-		return
-	}
-
-	// Check block and admin verification status
-	if existingRestaurant.Blocked {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "Restaurant not authorized to access the route",
-		})
-		return
-	}
-
-	// Check password by salt and password
-	password := []byte(existingRestaurant.Salt + restaurantLogin.Password)
-	if err := bcrypt.CompareHashAndPassword([]byte(existingRestaurant.HashedPassword), password); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "Invalid credentials",
-		})
-		return
-	}
-
-	// Check email verification status using verification table
-	var verificationTable model.VerificationTable
-	if err := database.DB.Where("email = ?", restaurantLogin.Email).First(&verificationTable).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  false,
-			"message": "Failed to fetch email verification status",
-		})
-		return
-	}
-
-	if verificationTable.VerificationStatus != model.VerificationStatusVerified {
-		if err := SendOTP(c, restaurantLogin.Email, verificationTable.OTPExpiry, model.RestaurantRole); err != nil {
-			c.JSON(http.StatusAlreadyReported, gin.H{
-				"status":  false,
-				"message": err.Error(),
-			})
-			return
-		}
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "Please verify your email to continue",
-		})
+	existingRestaurant, err := h.svc.Auth.RestaurantLogin(restaurantLogin, locale(c))
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	token, err := GenerateJWT(c, existingRestaurant.Email, model.RestaurantRole)
+	tokens, err := h.startSession(c, existingRestaurant.Email, model.RestaurantRole)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  false,
-			"message": "Failed to generate token",
-		})
+		respondError(c, err)
 		return
 	}
 
@@ -242,23 +104,20 @@ func RestaurantLogin(c *gin.Context) {
 			"certificate_url":     existingRestaurant.CertificateURL,
 			"verification_status": existingRestaurant.VerificationStatus,
 		},
-		"token": token,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
 	})
 }
 
 // public
-func GetRestaurants(c *gin.Context) {
-	var restaurants []model.Restaurant
-	// Search db and get all
-	if err := database.DB.Select("*").Find(&restaurants).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  false,
-			"message": "failed to retrieve data from the database",
-		})
+func (h *Handler) GetRestaurants(c *gin.Context) {
+	restaurants, err := h.svc.Restaurants.List()
+	if err != nil {
+		respondError(c, err)
 		return
 	}
-	var simplifiedRestaurants []gin.H
 
+	var simplifiedRestaurants []gin.H
 	for _, r := range restaurants {
 		simplifiedRestaurants = append(simplifiedRestaurants, gin.H{
 			"restaurant_id":       r.ID,
@@ -281,15 +140,10 @@ func GetRestaurants(c *gin.Context) {
 }
 
 // restaurant
-func EditRestaurant(c *gin.Context) {
-
+func (h *Handler) EditRestaurant(c *gin.Context) {
 	//check restaurant api authentication
-	email, role, err := utils.GetJWTClaim(c)
-	if role != model.RestaurantRole || err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "unauthorized request",
-		})
+	RestaurantID, ok := h.restaurantID(c)
+	if !ok {
 		return
 	}
 
@@ -311,35 +165,11 @@ func EditRestaurant(c *gin.Context) {
 		return
 	}
 
-	RestaurantID, ok := RestIDfromEmail(email)
-	if !ok {
-		c.JSON(http.StatusNotFound, gin.H{
-			"status":  false,
-			"message": "failed to retrieve restaurant information",
-		})
-		return
-	}
-
-	// Check if present and update it with the new data
-	var existingRestaurant model.Restaurant
-	if err := database.DB.First(&existingRestaurant, RestaurantID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"status":  false,
-			"message": "restaurant doesn't exist",
-		})
-		return
-	}
-
-	// Edit the restaurant
-	if err := database.DB.Model(&existingRestaurant).Updates(Request).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  false,
-			"message": "failed to edit the restaurant",
-		})
+	if err := h.svc.Restaurants.UpdateProfile(RestaurantID, Request); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	fmt.Println("done")
 	c.JSON(http.StatusOK, gin.H{
 		"status":  true,
 		"message": "successfully edited the restaurant",
@@ -348,21 +178,14 @@ func EditRestaurant(c *gin.Context) {
 }
 
 // admin
-func DeleteRestaurant(c *gin.Context) {
-
+func (h *Handler) DeleteRestaurant(c *gin.Context) {
 	//check admin api authentication
-	_, role, err := utils.GetJWTClaim(c)
-	if role != model.AdminRole || err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "unauthorized request",
-		})
+	if !h.isAdmin(c) {
 		return
 	}
 
 	// Get the restaurant id
-	restaurantIDStr := c.Param("restaurantid")
-	restaurantID, err := strconv.Atoi(restaurantIDStr)
+	restaurantID, err := strconv.Atoi(c.Param("restaurantid"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"status":  false,
@@ -371,22 +194,8 @@ func DeleteRestaurant(c *gin.Context) {
 		return
 	}
 
-	// Check if it's already present
-	var existingRestaurant model.Restaurant
-	if err := database.DB.First(&existingRestaurant, restaurantID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"status":  false,
-			"message": "restaurant doesn't exist",
-		})
-		return
-	}
-
-	// Delete it
-	if err := database.DB.Delete(&existingRestaurant).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  false,
-			"message": "failed to delete the restaurant",
-		})
+	if err := h.svc.Restaurants.Delete(uint(restaurantID)); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -397,20 +206,14 @@ func DeleteRestaurant(c *gin.Context) {
 }
 
 // admin
-func BlockRestaurant(c *gin.Context) {
+func (h *Handler) BlockRestaurant(c *gin.Context) {
 	//check admin api authentication
-	_, role, err := utils.GetJWTClaim(c)
-	if role != model.AdminRole || err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "unauthorized request",
-		})
+	if !h.isAdmin(c) {
 		return
 	}
 
 	// Get the restaurant id
-	restaurantIDStr := c.Query("restaurantid")
-	restaurantID, err := strconv.Atoi(restaurantIDStr)
+	restaurantID, err := strconv.Atoi(c.Query("restaurantid"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"status":     false,
@@ -420,41 +223,9 @@ func BlockRestaurant(c *gin.Context) {
 		return
 	}
 
-	// Check restaurant by id
-	var restaurant model.Restaurant
-	if err := database.DB.First(&restaurant, restaurantID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"status":     false,
-			"message":    "restaurant not found",
-			"error_code": http.StatusNotFound,
-		})
-		return
-	}
-
-	if restaurant.Blocked {
-		c.JSON(http.StatusAlreadyReported, gin.H{
-			"status":     false,
-			"message":    "restaurant is already blocked",
-			"error_code": http.StatusConflict,
-			"data": gin.H{
-				"name":        restaurant.Name,
-				"email":       restaurant.Email,
-				"address":     restaurant.Address,
-				"blockstatus": restaurant.Blocked,
-			},
-		})
-		return
-	}
-
-	// Set blocked as true
-	restaurant.Blocked = true
-
-	if err := database.DB.Save(&restaurant).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":     false,
-			"message":    "failed to change the block status",
-			"error_code": http.StatusInternalServerError,
-		})
+	restaurant, err := h.svc.Restaurants.Block(uint(restaurantID))
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -471,20 +242,14 @@ func BlockRestaurant(c *gin.Context) {
 }
 
 // admin
-func UnblockRestaurant(c *gin.Context) {
+func (h *Handler) UnblockRestaurant(c *gin.Context) {
 	//check admin api authentication
-	_, role, err := utils.GetJWTClaim(c)
-	if role != model.AdminRole || err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "unauthorized request",
-		})
+	if !h.isAdmin(c) {
 		return
 	}
 
 	// Get the restaurant id
-	restaurantIDStr := c.Query("restaurantid")
-	restaurantID, err := strconv.Atoi(restaurantIDStr)
+	restaurantID, err := strconv.Atoi(c.Query("restaurantid"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"status":  false,
@@ -493,38 +258,9 @@ func UnblockRestaurant(c *gin.Context) {
 		return
 	}
 
-	// Check restaurant by id
-	var restaurant model.Restaurant
-	if err := database.DB.First(&restaurant, restaurantID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"status":  false,
-			"message": "restaurant not found",
-		})
-		return
-	}
-
-	if !restaurant.Blocked {
-		c.JSON(http.StatusConflict, gin.H{
-			"status":  false,
-			"message": "restaurant is already unblocked",
-			"data": gin.H{
-				"name":         restaurant.Name,
-				"email":        restaurant.Email,
-				"address":      restaurant.Address,
-				"block_status": restaurant.Blocked,
-			},
-		})
-		return
-	}
-
-	// Set blocked as false
-	restaurant.Blocked = false
-
-	if err := database.DB.Save(&restaurant).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  false,
-			"message": "failed to change the block status",
-		})
+	restaurant, err := h.svc.Restaurants.Unblock(uint(restaurantID))
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -540,20 +276,14 @@ func UnblockRestaurant(c *gin.Context) {
 	})
 }
 
-func VerifyRestaurant(c *gin.Context) {
+func (h *Handler) VerifyRestaurant(c *gin.Context) {
 	//check admin api authentication
-	_, role, err := utils.GetJWTClaim(c)
-	if role != model.AdminRole || err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "unauthorized request",
-		})
+	if !h.isAdmin(c) {
 		return
 	}
 
 	// Get the restaurant id
-	restaurantIDStr := c.Query("restaurantid")
-	restaurantID, err := strconv.Atoi(restaurantIDStr)
+	restaurantID, err := strconv.Atoi(c.Query("restaurantid"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"status":  false,
@@ -561,39 +291,27 @@ func VerifyRestaurant(c *gin.Context) {
 		})
 		return
 	}
-	var Restaurant model.Restaurant
-	if err := database.DB.Where("id = ?", restaurantID).First(&Restaurant).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"status": false, "message": err.Error()})
-		return
-	}
 
-	if Restaurant.VerificationStatus == model.VerificationStatusVerified {
-		c.JSON(http.StatusAlreadyReported, gin.H{"status": true, "message": "restaurant is already verified"})
+	changed, err := h.svc.Restaurants.SetVerification(uint(restaurantID), model.VerificationStatusVerified)
+	if err != nil {
+		respondError(c, err)
 		return
-	} else {
-		Restaurant.VerificationStatus = model.VerificationStatusVerified
 	}
-
-	if err := database.DB.Updates(&Restaurant).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"status": false, "message": err.Error()})
+	if !changed {
+		c.JSON(http.StatusAlreadyReported, gin.H{"status": true, "message": "restaurant is already verified"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"status": true, "message": "restaurant status changed to status - verified"})
 }
-func RemoveVerifyStatusRestaurant(c *gin.Context) {
+
+func (h *Handler) RemoveVerifyStatusRestaurant(c *gin.Context) {
 	//check admin api authentication
-	_, role, err := utils.GetJWTClaim(c)
-	if role != model.AdminRole || err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "unauthorized request",
-		})
+	if !h.isAdmin(c) {
 		return
 	}
 
 	// Get the restaurant id
-	restaurantIDStr := c.Query("restaurantid")
-	restaurantID, err := strconv.Atoi(restaurantIDStr)
+	restaurantID, err := strconv.Atoi(c.Query("restaurantid"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"status":  false,
@@ -601,59 +319,29 @@ func RemoveVerifyStatusRestaurant(c *gin.Context) {
 		})
 		return
 	}
-	var Restaurant model.Restaurant
-	if err := database.DB.Where("id = ?", restaurantID).First(&Restaurant).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"status": false, "message": err.Error()})
+
+	changed, err := h.svc.Restaurants.SetVerification(uint(restaurantID), model.VerificationStatusPending)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
-
-	if Restaurant.VerificationStatus == model.VerificationStatusPending {
+	if !changed {
 		c.JSON(http.StatusAlreadyReported, gin.H{"status": true, "message": "restaurant is already unverified"})
-	} else {
-		Restaurant.VerificationStatus = model.VerificationStatusPending
-	}
-
-	if err := database.DB.Updates(&Restaurant).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"status": false, "message": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"status": true, "message": "restaurant status changed to status - pending"})
 }
 
-// get restaurantid from rest email
-func RestIDfromEmail(email string) (uint, bool) {
-	var Restaurant model.Restaurant
-	if err := database.DB.Where("email = ?", email).First(&Restaurant).Error; err != nil {
-		return 0, false
-	}
-	return Restaurant.ID, true
-}
-
-func RestaurantWalletBalance(email string) (float64, bool) {
-	var Restaurant model.Restaurant
-	if err := database.DB.Where("email = ?", email).First(&Restaurant).Error; err != nil {
-		return 0, false
-	}
-	return Restaurant.WalletAmount, true
-}
-
-func GetRestaurantWalletData(c *gin.Context) {
+func (h *Handler) GetRestaurantWalletData(c *gin.Context) {
 	//check restaurant api authentication
-	email, role, err := utils.GetJWTClaim(c)
-	if role != model.RestaurantRole || err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "unauthorized request",
-		})
+	RestID, ok := h.restaurantID(c)
+	if !ok {
 		return
 	}
 
-	RestID, _ := RestIDfromEmail(email)
-	WalletBalance, _ := RestaurantWalletBalance(email)
-
-	var Result []model.RestaurantWalletHistory
-	if err := database.DB.Where("restaurant_id = ?", RestID).Find(&Result).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"status": false, "message": "failed to get restaurant wallet history"})
+	WalletBalance, Result, err := h.svc.Wallets.RestaurantWallet(RestID)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -666,49 +354,23 @@ func GetRestaurantWalletData(c *gin.Context) {
 	})
 }
 
-func OrderItemsCSVFileForRestaurant(c *gin.Context) {
-	email, role, err := utils.GetJWTClaim(c)
-	if role != model.RestaurantRole || err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "unauthorized request",
-		})
+func (h *Handler) OrderItemsCSVFileForRestaurant(c *gin.Context) {
+	RestID, ok := h.restaurantID(c)
+	if !ok {
 		return
 	}
 
-	RestID, _ := RestIDfromEmail(email)
-
-	var OrderInformation []model.OrderItem
-	err = database.DB.Where("restaurant_id =?", RestID).Find(&OrderInformation).Error
+	data, err := h.svc.Restaurants.OrderItems(RestID)
 	if err != nil {
-		log.Printf("Failed to retrieve order information: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve order information"})
+		respondError(c, err)
 		return
 	}
 
-	if len(OrderInformation) == 0 {
+	if len(data) == 0 {
 		c.JSON(http.StatusOK, gin.H{"status": true, "message": "No orders found for this restaurant."})
 		return
 	}
 
-	data := make([]model.OrderItem, len(OrderInformation))
-	for i, item := range OrderInformation {
-		data[i] = model.OrderItem{
-			OrderID:            item.OrderID,
-			UserID:             uint(item.UserID),
-			RestaurantID:       uint(item.RestaurantID),
-			ProductID:          uint(item.ProductID),
-			Quantity:           item.Quantity,
-			Amount:             item.Amount,
-			ProductOfferAmount: item.ProductOfferAmount,
-			AfterDeduction:     RoundDecimalValue(item.AfterDeduction),
-			CookingRequest:     item.CookingRequest,
-			OrderStatus:        item.OrderStatus,
-			OrderReview:        item.OrderReview,
-			OrderRating:        item.OrderRating,
-		}
-	}
-
 	tmpfile, err := os.CreateTemp("", "orders_*.csv")
 	if err != nil {
 		log.Printf("Failed to create temp file: %v", err)
@@ -727,81 +389,53 @@ func OrderItemsCSVFileForRestaurant(c *gin.Context) {
 	c.File(tmpfile.Name())
 }
 
-func OrderPaymentInformationsExcelFile(c *gin.Context) {
-}
-
-func ListOrderItemsForRestaurants(c *gin.Context) {
-	email, role, err := utils.GetJWTClaim(c)
-	if role != model.RestaurantRole || err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  false,
-			"message": "unauthorized request",
-		})
+func (h *Handler) ListOrderItemsForRestaurants(c *gin.Context) {
+	RestID, ok := h.restaurantID(c)
+	if !ok {
 		return
 	}
 
-	RestID, _ := RestIDfromEmail(email)
-
-	var OrderInformation []model.OrderItem
-	err = database.DB.Where("restaurant_id =?", RestID).Find(&OrderInformation).Error
+	data, err := h.svc.Restaurants.OrderItems(RestID)
 	if err != nil {
-		log.Printf("Failed to retrieve order information: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve order information"})
+		respondError(c, err)
 		return
 	}
 
-	if len(OrderInformation) == 0 {
+	if len(data) == 0 {
 		c.JSON(http.StatusOK, gin.H{"status": true, "message": "No orders found for this restaurant."})
 		return
 	}
 
-	data := make([]model.OrderItem, len(OrderInformation))
-	for i, item := range OrderInformation {
-		data[i] = model.OrderItem{
-			OrderID:            item.OrderID,
-			UserID:             uint(item.UserID),
-			RestaurantID:       uint(item.RestaurantID),
-			ProductID:          uint(item.ProductID),
-			Quantity:           item.Quantity,
-			Amount:             item.Amount,
-			ProductOfferAmount: item.ProductOfferAmount,
-			AfterDeduction:     RoundDecimalValue(item.AfterDeduction),
-			CookingRequest:     item.CookingRequest,
-			OrderStatus:        item.OrderStatus,
-			OrderReview:        item.OrderReview,
-			OrderRating:        item.OrderRating,
-		}
-	}
-
 	c.JSON(http.StatusOK, gin.H{
 		"status": true,
 		"data":   data,
 	})
 }
 
-func GetRestaurantProfile(c *gin.Context){
-	RestaurantID := c.Query("id")
-    if RestaurantID == "0" || RestaurantID == ""{
-		c.JSON(http.StatusBadRequest,gin.H{"status":false,"message":"id is not provided in the query params"});return
-	}
-
-    var Restaurant model.Restaurant
-	if err:=database.DB.Where("id = ?",RestaurantID).First(&Restaurant).Error;err!=nil{
-		c.JSON(http.StatusBadRequest,gin.H{"status":false,"message":"restaurant profile not found,confirm id of the restaurant"});return
-	}	
-
-	c.JSON(http.StatusOK,
-		gin.H{
-			"status":true,
-			"data":gin.H{
-				"restaurant_id":RestaurantID,
-				"name":Restaurant.Name,
-				"description":Restaurant.Description,
-				"address":Restaurant.Address,
-				"phone_number":Restaurant.PhoneNumber,
-				"image_url":Restaurant.ImageURL,
-				"certificate_url":Restaurant.CertificateURL,
-				"blocked":Restaurant.Blocked,
-			},
-		})
+func (h *Handler) GetRestaurantProfile(c *gin.Context) {
+	RestaurantID, _ := strconv.Atoi(c.Query("id"))
+	if RestaurantID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "message": "id is not provided in the query params"})
+		return
+	}
+
+	Restaurant, err := h.svc.Restaurants.Profile(uint(RestaurantID))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": true,
+		"data": gin.H{
+			"restaurant_id":   RestaurantID,
+			"name":            Restaurant.Name,
+			"description":     Restaurant.Description,
+			"address":         Restaurant.Address,
+			"phone_number":    Restaurant.PhoneNumber,
+			"image_url":       Restaurant.ImageURL,
+			"certificate_url": Restaurant.CertificateURL,
+			"blocked":         Restaurant.Blocked,
+		},
+	})
 }
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/service"
+	"foodbuddy/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// startSession opens a session for the account and sets the access and refresh token cookies
+func (h *Handler) startSession(c *gin.Context, email string, role string) (service.Tokens, error) {
+	tokens, err := h.svc.Sessions.Start(email, role, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		return tokens, err
+	}
+	setTokenCookies(c, tokens)
+	return tokens, nil
+}
+
+func setTokenCookies(c *gin.Context, tokens service.Tokens) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(model.AccessTokenCookie, tokens.AccessToken, model.AccessTokenLifetime, "/", "", false, true)
+	c.SetCookie(model.RefreshTokenCookie, tokens.RefreshToken, model.RefreshTokenLifetime, "/", "", false, true)
+}
+
+// endSession revokes the session of the request if there is one, by the access token or
+// else by the refresh token when the access token already expired
+func (h *Handler) endSession(c *gin.Context) {
+	if claims, err := utils.GetJWTClaims(c); err == nil {
+		h.svc.Sessions.Revoke(claims.Email, claims.Role, claims.SessionID)
+		return
+	}
+	if refreshToken, err := c.Cookie(model.RefreshTokenCookie); err == nil && refreshToken != "" {
+		h.svc.Sessions.End(refreshToken)
+	}
+}
+
+// RefreshSession exchanges a refresh token for a new access token and refresh token,
+// the refresh token is read from the cookie or the json body
+func (h *Handler) RefreshSession(c *gin.Context) {
+	var request model.RefreshSessionRequest
+	if refreshToken, err := c.Cookie(model.RefreshTokenCookie); err == nil && refreshToken != "" {
+		request.RefreshToken = refreshToken
+	} else if err := c.ShouldBindJSON(&request); err != nil || request.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  false,
+			"message": "refresh token is required",
+		})
+		return
+	}
+
+	tokens, err := h.svc.Sessions.Refresh(request.RefreshToken)
+	if err != nil {
+		utils.RemoveCookies(c)
+		respondError(c, err)
+		return
+	}
+	setTokenCookies(c, tokens)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "session refreshed",
+		"data":    tokens,
+	})
+}
+
+// ListSessions returns the signed in sessions of the account making the request
+func (h *Handler) ListSessions(c *gin.Context) {
+	p, ok := principal(c)
+	if !ok {
+		unauthorized(c)
+		return
+	}
+
+	sessions, err := h.svc.Sessions.List(p.Email, p.Role)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "successfully retrieved sessions",
+		"data": gin.H{
+			"current_session_id": p.SessionID,
+			"sessions":           sessions,
+		},
+	})
+}
+
+// RevokeSession signs the account out of one of its sessions
+func (h *Handler) RevokeSession(c *gin.Context) {
+	p, ok := principal(c)
+	if !ok {
+		unauthorized(c)
+		return
+	}
+
+	sessionID := c.Param("id")
+	if err := h.svc.Sessions.Revoke(p.Email, p.Role, sessionID); err != nil {
+		respondError(c, err)
+		return
+	}
+	if sessionID == p.SessionID {
+		utils.RemoveCookies(c)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "session revoked",
+	})
+}
+
+// RevokeAllSessions signs the account out everywhere, including this session
+func (h *Handler) RevokeAllSessions(c *gin.Context) {
+	p, ok := principal(c)
+	if !ok {
+		unauthorized(c)
+		return
+	}
+
+	if err := h.svc.Sessions.RevokeAll(p.Email, p.Role); err != nil {
+		respondError(c, err)
+		return
+	}
+	utils.RemoveCookies(c)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "logged out of every session",
+	})
+}
@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"foodbuddy/internal/model"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// webhookBodyLimit is the largest webhook accepted, payment events are a few kilobytes
+const webhookBodyLimit = 64 << 10
+
+// StripeWebhook receives the payment events stripe sends
+func (h *Handler) StripeWebhook(c *gin.Context) {
+	h.paymentWebhook(c, model.Stripe)
+}
+
+// RazorpayWebhook receives the payment events razorpay sends
+func (h *Handler) RazorpayWebhook(c *gin.Context) {
+	h.paymentWebhook(c, model.Razorpay)
+}
+
+// paymentWebhook hands the raw body to the gateway, the signature is computed over the exact
+// bytes sent. anything but a 2xx makes the provider deliver the event again later
+func (h *Handler) paymentWebhook(c *gin.Context, gateway string) {
+	payload, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, webhookBodyLimit))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"status":  false,
+			"message": "failed to read the webhook",
+		})
+		return
+	}
+
+	if err := h.svc.Payments.HandleWebhook(c.Request.Context(), gateway, payload, c.Request.Header); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "event received",
+	})
+}
@@ -0,0 +1,180 @@
+package database
+
+import (
+	"fmt"
+	"foodbuddy/internal/model"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// supported database drivers
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Config describes how to reach the database. for sqlite Name is the file path,
+// ":memory:" keeps the database in memory
+type Config struct {
+	Driver   string
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+	// TLS is passed as the tls parameter for mysql (true, false, skip-verify, preferred)
+	// and as sslmode for postgres (disable, require, verify-ca, verify-full)
+	TLS string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// NewConfig reads the database configuration out of the environment variables,
+// unset values fall back to a local mysql server
+func NewConfig(env model.EnvVariables) Config {
+	config := Config{
+		Driver:   strings.ToLower(env.DBDriver),
+		Host:     env.DBHost,
+		Port:     env.DBPort,
+		User:     env.DBUser,
+		Password: env.DBPassword,
+		Name:     env.DBName,
+		TLS:      env.DBTLS,
+	}
+	if config.Driver == "" {
+		config.Driver = DriverMySQL
+	}
+	if config.Host == "" {
+		config.Host = "localhost"
+	}
+	if config.Port == "" {
+		switch config.Driver {
+		case DriverPostgres:
+			config.Port = "5432"
+		default:
+			config.Port = "3306"
+		}
+	}
+
+	config.MaxOpenConns = atoi("DBMAXOPENCONNS", env.DBMaxOpenConns)
+	config.MaxIdleConns = atoi("DBMAXIDLECONNS", env.DBMaxIdleConns)
+	if env.DBConnMaxLifetime != "" {
+		lifetime, err := time.ParseDuration(env.DBConnMaxLifetime)
+		if err != nil {
+			log.Printf("Warning: invalid DBCONNMAXLIFETIME %q, using no limit", env.DBConnMaxLifetime)
+		}
+		config.ConnMaxLifetime = lifetime
+	}
+	return config
+}
+
+func atoi(key string, value string) int {
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Warning: invalid %s %q, using the driver default", key, value)
+		return 0
+	}
+	return n
+}
+
+// DSN is the connection string of the configured database. with withDatabase unset
+// it points at the server only, which is used to create a missing database
+func (c Config) DSN(withDatabase bool) string {
+	switch c.Driver {
+	case DriverSQLite:
+		return c.Name
+	case DriverPostgres:
+		name := "postgres"
+		if withDatabase {
+			name = c.Name
+		}
+		sslmode := c.TLS
+		if sslmode == "" {
+			sslmode = "disable"
+		}
+		dsn := url.URL{
+			Scheme:   "postgres",
+			Host:     net.JoinHostPort(c.Host, c.Port),
+			Path:     "/" + name,
+			RawQuery: url.Values{"sslmode": {sslmode}}.Encode(),
+		}
+		switch {
+		case c.Password != "":
+			dsn.User = url.UserPassword(c.User, c.Password)
+		case c.User != "":
+			dsn.User = url.User(c.User)
+		}
+		return dsn.String()
+	default:
+		dsn := mysqldriver.NewConfig()
+		dsn.User = c.User
+		dsn.Passwd = c.Password
+		dsn.Net = "tcp"
+		dsn.Addr = net.JoinHostPort(c.Host, c.Port)
+		if withDatabase {
+			dsn.DBName = c.Name
+		}
+		dsn.ParseTime = true
+		dsn.TLSConfig = c.TLS
+		return dsn.FormatDSN()
+	}
+}
+
+func (c Config) dialector(withDatabase bool) (gorm.Dialector, error) {
+	dsn := c.DSN(withDatabase)
+	switch c.Driver {
+	case DriverMySQL:
+		return mysql.Open(dsn), nil
+	case DriverPostgres:
+		return postgres.Open(dsn), nil
+	case DriverSQLite:
+		return sqlite.Open(dsn), nil
+	}
+	return nil, fmt.Errorf("unsupported database driver %q, use mysql, postgres or sqlite", c.Driver)
+}
+
+// Open connects to the configured database and applies the pool settings
+func Open(config Config) (*gorm.DB, error) {
+	dialector, err := config.dialector(true)
+	if err != nil {
+		return nil, err
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	if config.Driver == DriverSQLite && config.Name == ":memory:" {
+		// every new connection would open its own empty in-memory database
+		sqlDB.SetMaxOpenConns(1)
+	} else if config.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(config.MaxOpenConns)
+	}
+	if config.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(config.MaxIdleConns)
+	}
+	if config.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
+	}
+	return db, nil
+}
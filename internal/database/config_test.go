@@ -0,0 +1,58 @@
+package database
+
+import (
+	"net/url"
+	"testing"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+)
+
+// the credentials come back out of the connection string as they went in, whatever they hold
+func TestDSNKeepsCredentials(t *testing.T) {
+	passwords := []string{"secret", "", "p@ss:w/rd?x=1&y#z", `it's \ a space`, "%41"}
+	for _, password := range passwords {
+		t.Run("mysql "+password, func(t *testing.T) {
+			config := Config{Driver: DriverMySQL, Host: "db.local", Port: "3306", User: "foodbuddy", Password: password, Name: "foodbuddy", TLS: "true"}
+			parsed, err := mysqldriver.ParseDSN(config.DSN(true))
+			if err != nil {
+				t.Fatalf("parse %s: %v", config.DSN(true), err)
+			}
+			if parsed.User != config.User || parsed.Passwd != password || parsed.Addr != "db.local:3306" || parsed.DBName != config.Name {
+				t.Errorf("parsed %q:%q@%s/%s, want %q:%q@db.local:3306/%s", parsed.User, parsed.Passwd, parsed.Addr, parsed.DBName, config.User, password, config.Name)
+			}
+			if !parsed.ParseTime || parsed.TLSConfig != "true" {
+				t.Errorf("parsed parseTime %v and tls %q, want true and %q", parsed.ParseTime, parsed.TLSConfig, "true")
+			}
+		})
+		t.Run("postgres "+password, func(t *testing.T) {
+			config := Config{Driver: DriverPostgres, Host: "db.local", Port: "5432", User: "food@buddy", Password: password, Name: "foodbuddy"}
+			parsed, err := url.Parse(config.DSN(true))
+			if err != nil {
+				t.Fatalf("parse %s: %v", config.DSN(true), err)
+			}
+			got, _ := parsed.User.Password()
+			if parsed.User.Username() != config.User || got != password || parsed.Host != "db.local:5432" || parsed.Path != "/"+config.Name {
+				t.Errorf("parsed %q:%q@%s%s, want %q:%q@db.local:5432/%s", parsed.User.Username(), got, parsed.Host, parsed.Path, config.User, password, config.Name)
+			}
+			if sslmode := parsed.Query().Get("sslmode"); sslmode != "disable" {
+				t.Errorf("sslmode is %q, want disable", sslmode)
+			}
+		})
+	}
+}
+
+func TestDSNWithoutDatabase(t *testing.T) {
+	mysql := Config{Driver: DriverMySQL, Host: "localhost", Port: "3306", User: "root", Password: "secret", Name: "foodbuddy"}
+	parsed, err := mysqldriver.ParseDSN(mysql.DSN(false))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if parsed.DBName != "" {
+		t.Errorf("mysql server dsn names database %q", parsed.DBName)
+	}
+
+	postgres := Config{Driver: DriverPostgres, Host: "localhost", Port: "5432", User: "postgres", Name: "foodbuddy"}
+	if dsn := postgres.DSN(false); dsn != "postgres://postgres@localhost:5432/postgres?sslmode=disable" {
+		t.Errorf("postgres server dsn is %s", dsn)
+	}
+}
@@ -2,12 +2,10 @@ package database
 
 import (
 	"fmt"
-	"foodbuddy/internal/model"
 	"foodbuddy/internal/utils"
 	"log"
-	"os"
+	"strings"
 
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
@@ -15,86 +13,70 @@ var DB *gorm.DB
 
 func ConnectToDB() {
 	var err error
-	databaseCredentials := utils.GetEnvVariables()
+	config := NewConfig(utils.GetEnvVariables())
 
-	dsn := fmt.Sprintf("%s:%s@tcp(localhost:3306)/?parseTime=true", databaseCredentials.DBUser, databaseCredentials.DBPassword)
-	log.Println("Connecting to MySQL server with DSN:", dsn)
-
-	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
-	if err != nil {
-		log.Fatalf("unable to connect to MySQL server: %v", err)
-	}
-
-	if !databaseExists(databaseCredentials.DBName) {
-		if err := createDatabase(databaseCredentials.DBName); err != nil {
+	if config.Driver != DriverSQLite {
+		if err := ensureDatabase(config); err != nil {
 			log.Printf("Warning: %v", err)
 		}
 	}
 
-	dsn = fmt.Sprintf("%s:%s@tcp(localhost:3306)/%s?parseTime=true", databaseCredentials.DBUser, databaseCredentials.DBPassword, databaseCredentials.DBName)
-	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	if config.Driver == DriverSQLite {
+		log.Printf("Opening sqlite database %s", config.Name)
+	} else {
+		log.Printf("Connecting to %s database %s on %s:%s", config.Driver, config.Name, config.Host, config.Port)
+	}
+	DB, err = Open(config)
 	if err != nil {
 		log.Fatalf("unable to connect to database: %v", err)
 	} else {
 		log.Println("Connection to database: OK")
 	}
+}
 
-	AutoMigrate()
+// ensureDatabase creates the configured database on the server when it is missing
+func ensureDatabase(config Config) error {
+	dialector, err := config.dialector(false)
+	if err != nil {
+		return err
+	}
+	server, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		return fmt.Errorf("unable to connect to %s server: %w", config.Driver, err)
+	}
+	if sqlDB, err := server.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	if databaseExists(server, config.Driver, config.Name) {
+		return nil
+	}
+	return createDatabase(server, config.Driver, config.Name)
 }
 
-func databaseExists(dbName string) bool {
+func databaseExists(db *gorm.DB, driver string, dbName string) bool {
 	var exists int
-	dsn := fmt.Sprintf("%s:%s@tcp(localhost:3306)/?parseTime=true", os.Getenv("DBUSER"), os.Getenv("DBPASSWORD"))
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
-	if err != nil {
-		log.Fatalf("unable to connect to MySQL server: %v", err)
+	query := "SELECT COUNT(*) FROM information_schema.schemata WHERE schema_name = ?"
+	if driver == DriverPostgres {
+		query = "SELECT COUNT(*) FROM pg_database WHERE datname = ?"
 	}
 
-	if err := db.Raw("SELECT COUNT(*) FROM information_schema.schemata WHERE schema_name = ?", dbName).Scan(&exists).Error; err != nil {
+	if err := db.Raw(query, dbName).Scan(&exists).Error; err != nil {
 		log.Printf("Failed to check database existence: %v", err)
 		return false
 	}
 	return exists > 0
 }
 
-func createDatabase(dbName string) error {
-	dsn := fmt.Sprintf("%s:%s@tcp(localhost:3306)/?parseTime=true", os.Getenv("DBUSER"), os.Getenv("DBPASSWORD"))
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
-	if err != nil {
-		return fmt.Errorf("unable to connect to MySQL server: %w", err)
+func createDatabase(db *gorm.DB, driver string, dbName string) error {
+	name := "`" + strings.ReplaceAll(dbName, "`", "``") + "`"
+	if driver == DriverPostgres {
+		name = `"` + strings.ReplaceAll(dbName, `"`, `""`) + `"`
 	}
 
-	if err := db.Exec("CREATE DATABASE " + dbName).Error; err != nil {
+	if err := db.Exec("CREATE DATABASE " + name).Error; err != nil {
 		return fmt.Errorf("failed to create database: %w", err)
 	}
 	log.Printf("Database %s created successfully!", dbName)
 	return nil
 }
-
-func AutoMigrate() {
-	err := DB.AutoMigrate(
-		&model.User{},
-		&model.Restaurant{},
-		&model.Category{},
-		&model.Product{},
-		&model.FavouriteProduct{},
-		&model.Address{},
-		&model.Admin{},
-		&model.VerificationTable{},
-		&model.CartItems{},
-		&model.Order{},
-		&model.OrderItem{},
-		&model.Payment{},
-		&model.PasswordReset{},
-		&model.CouponInventory{},
-		&model.CouponUsage{},
-		&model.UserWalletHistory{},
-		&model.RestaurantWalletHistory{},
-		&model.UserReferralHistory{},
-		&model.DeliveryVerification{},
-	)
-
-	if err != nil {
-		log.Fatal("failed to automigrate models:", err)
-	}
-}
@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// restaurants now accept the items of paid orders before cooking them, and items left unaccepted
+// are rejected and refunded after the deadline. the items already PROCESSING, including the ones
+// the migration to the order state machine started, were taken on before there was anything to
+// accept, so they are accepted with the step in their history
+func init() {
+	reason := "accepted by the migration to order acceptance"
+	accepted := "order_status = 'ACCEPTED' AND EXISTS (SELECT 1 FROM order_status_histories WHERE " +
+		"order_status_histories.order_id = order_items.order_id AND order_status_histories.product_id = order_items.product_id AND " +
+		"order_status_histories.reason = ?)"
+
+	register(Migration{
+		Version: 19,
+		Name:    "accept_started_order_items",
+		Up: func(tx *gorm.DB) error {
+			err := tx.Exec("INSERT INTO order_status_histories (order_id, product_id, from_status, to_status, actor, actor_id, reason, created_at) "+
+				"SELECT order_id, product_id, 'PROCESSING', 'ACCEPTED', 'system', 0, ?, ? FROM order_items WHERE order_status = 'PROCESSING'",
+				reason, time.Now()).Error
+			if err != nil {
+				return err
+			}
+			return tx.Exec("UPDATE order_items SET order_status = 'ACCEPTED' WHERE order_status = 'PROCESSING'").Error
+		},
+		// the items still ACCEPTED go back to PROCESSING, the ones that moved on are left as they are
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Exec("UPDATE order_items SET order_status = 'PROCESSING' WHERE "+accepted, reason).Error; err != nil {
+				return err
+			}
+			return tx.Exec("DELETE FROM order_status_histories WHERE reason = ? AND EXISTS (SELECT 1 FROM order_items WHERE "+
+				"order_items.order_id = order_status_histories.order_id AND order_items.product_id = order_status_histories.product_id AND "+
+				"order_items.order_status = 'PROCESSING')", reason).Error
+		},
+	})
+}
@@ -0,0 +1,64 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// admins sign in with a password and a totp code instead of a mailed link. admins that
+// already exist keep full privileges and set their password through an invite
+func init() {
+	type Admin struct {
+		gorm.Model
+		Email          string
+		Name           string
+		Privilege      string `gorm:"size:32"`
+		HashedPassword string
+		Salt           string
+		TOTPSecret     string `gorm:"column:totp_secret"`
+		TOTPEnabled    bool   `gorm:"column:totp_enabled"`
+		TOTPLastStep   int64  `gorm:"column:totp_last_step"`
+		InvitedBy      uint
+	}
+	type AdminInvite struct {
+		ID         uint   `gorm:"primaryKey"`
+		Email      string `gorm:"size:191"`
+		Privilege  string `gorm:"size:32"`
+		TokenHash  string `gorm:"size:64;uniqueIndex:idx_admin_invites_token_hash"`
+		InvitedBy  uint
+		CreatedAt  time.Time
+		ExpiresAt  time.Time
+		AcceptedAt *time.Time
+	}
+	type AdminRecoveryCode struct {
+		ID        uint   `gorm:"primaryKey"`
+		AdminID   uint   `gorm:"index:idx_admin_recovery_codes_admin_id"`
+		CodeHash  string `gorm:"size:64"`
+		CreatedAt time.Time
+		UsedAt    *time.Time
+	}
+
+	register(Migration{
+		Version: 6,
+		Name:    "admin_accounts",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&Admin{}, &AdminInvite{}, &AdminRecoveryCode{}); err != nil {
+				return err
+			}
+			return tx.Table("admins").Where("privilege IS NULL OR privilege = ''").Update("privilege", "SUPER").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&AdminRecoveryCode{}, &AdminInvite{}); err != nil {
+				return err
+			}
+			for _, column := range []string{"name", "privilege", "hashed_password", "salt", "totp_secret", "totp_enabled", "totp_last_step", "invited_by"} {
+				if err := tx.Exec("ALTER TABLE ? DROP COLUMN ?", clause.Table{Name: "admins"}, clause.Column{Name: column}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,69 @@
+package migrations
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+const usage = "usage: migrate up | down [steps] | status"
+
+// Run executes the migrate command line, args are the arguments after "migrate"
+func Run(db *gorm.DB, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf(usage)
+	}
+
+	switch args[0] {
+	case "up":
+		ran, err := Up(db)
+		for _, m := range ran {
+			fmt.Fprintf(out, "applied  %04d %s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(ran) == 0 {
+			fmt.Fprintln(out, "schema is up to date")
+		}
+		return nil
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("steps should be a positive number, %s", usage)
+			}
+			steps = n
+		}
+		reverted, err := Down(db, steps)
+		for _, m := range reverted {
+			fmt.Fprintf(out, "reverted %04d %s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Fprintln(out, "no applied migrations to revert")
+		}
+		return nil
+
+	case "status":
+		statuses, err := Statuses(db)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			if s.Applied {
+				fmt.Fprintf(out, "applied  %04d %s (%s)\n", s.Version, s.Name, s.AppliedAt.Format("2006-01-02 15:04:05"))
+			} else {
+				fmt.Fprintf(out, "pending  %04d %s\n", s.Version, s.Name)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown migrate command %q, %s", args[0], usage)
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// the responses stored for the Idempotency-Key header of the order and payment endpoints
+func init() {
+	type IdempotencyKey struct {
+		ID           uint      `gorm:"primaryKey"`
+		Owner        string    `gorm:"column:owner;size:64;uniqueIndex:idx_idempotency_keys_owner_key"`
+		Key          string    `gorm:"column:idempotency_key;size:128;uniqueIndex:idx_idempotency_keys_owner_key"`
+		Fingerprint  string    `gorm:"column:fingerprint;size:64"`
+		Status       string    `gorm:"column:status;size:16"`
+		ResponseCode int       `gorm:"column:response_code"`
+		ContentType  string    `gorm:"column:content_type"`
+		ResponseBody []byte    `gorm:"column:response_body"`
+		CreatedAt    time.Time `gorm:"column:created_at;index:idx_idempotency_keys_created_at"`
+		UpdatedAt    time.Time
+	}
+
+	register(Migration{
+		Version: 11,
+		Name:    "idempotency_keys",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&IdempotencyKey{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&IdempotencyKey{})
+		},
+	})
+}
@@ -0,0 +1,245 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// the schema as AutoMigrate used to create it at boot. AutoMigrate only creates what is
+// missing, so databases that were set up before migrations existed adopt this version as is
+func init() {
+	register(Migration{
+		Version: 1,
+		Name:    "initial_schema",
+		Up: func(tx *gorm.DB) error {
+			type Admin struct {
+				gorm.Model
+				Email string
+			}
+			type User struct {
+				gorm.Model
+				ID             uint
+				Name           string  `gorm:"column:name;type:varchar(255)"`
+				Email          string  `gorm:"column:email;type:varchar(255);unique_index"`
+				PhoneNumber    string  `gorm:"column:phone_number;type:varchar(255);unique_index"`
+				Picture        string  `gorm:"column:picture;type:text"`
+				ReferralCode   string  `gorm:"column:referral_code"`
+				WalletAmount   float64 `gorm:"column:wallet_amount;type:double precision"`
+				LoginMethod    string  `gorm:"column:login_method;type:varchar(255)"`
+				Blocked        bool    `gorm:"column:blocked;type:bool"`
+				Salt           string  `gorm:"column:salt;type:varchar(255)"`
+				HashedPassword string  `gorm:"column:hashed_password;type:varchar(255)"`
+			}
+			type UserReferralHistory struct {
+				UserID       uint   `gorm:"column:user_id"`
+				ReferralCode string `gorm:"column:referral_code"`
+				ReferredBy   string `gorm:"column:referred_by"`
+				ReferClaimed bool   `gorm:"column:refer_claimed"`
+			}
+			type VerificationTable struct {
+				Email              string `gorm:"type:varchar(255);unique_index"`
+				Role               string
+				OTP                uint64
+				OTPExpiry          uint64
+				VerificationStatus string `gorm:"type:varchar(255)"`
+			}
+			type Product struct {
+				gorm.Model
+				ID              uint
+				RestaurantID    uint
+				CategoryID      uint
+				Name            string
+				Description     string `gorm:"column:description"`
+				ImageURL        string `gorm:"column:image_url"`
+				Price           float64
+				PreparationTime float64 `gorm:"column:preparation_time"`
+				MaxStock        uint
+				OfferAmount     float64 `gorm:"column:offer_amount"`
+				StockLeft       uint
+				RatingSum       float64 `gorm:"column:rating_sum"`
+				RatingCount     uint    `gorm:"column:rating_count"`
+				AverageRating   float64 `gorm:"column:average_rating"`
+				Veg             string  `gorm:"column:veg"`
+			}
+			type Category struct {
+				gorm.Model
+				ID              uint `gorm:"column:id"`
+				Name            string
+				Description     string    `gorm:"column:description"`
+				ImageURL        string    `gorm:"column:image_url"`
+				OfferPercentage uint      `gorm:"column:offer_percentage"`
+				Products        []Product `gorm:"foreignKey:CategoryID"`
+			}
+			type Restaurant struct {
+				gorm.Model
+				ID                 uint
+				Name               string
+				Description        string `gorm:"column:description"`
+				Address            string
+				Email              string
+				PhoneNumber        string  `gorm:"column:phone_number"`
+				WalletAmount       float64 `gorm:"column:wallet_amount;type:double precision"`
+				ImageURL           string  `gorm:"column:image_url"`
+				CertificateURL     string  `gorm:"column:certificate_url"`
+				VerificationStatus string  `gorm:"column:verification_status"`
+				Blocked            bool
+				Salt               string
+				HashedPassword     string `gorm:"column:hashed_password"`
+			}
+			type FavouriteProduct struct {
+				UserID    uint
+				ProductID uint
+			}
+			type Address struct {
+				UserID       uint `gorm:"column:user_id"`
+				AddressID    uint `gorm:"primaryKey;autoIncrement;column:address_id"`
+				PhoneNumber  string
+				AddressType  string `gorm:"column:address_type"`
+				StreetName   string `gorm:"column:street_name"`
+				StreetNumber string `gorm:"column:street_number"`
+				City         string `gorm:"column:city"`
+				State        string `gorm:"column:state"`
+				PostalCode   string `gorm:"column:postal_code"`
+			}
+			type CartItems struct {
+				UserID         uint `gorm:"column:user_id"`
+				ProductID      uint
+				RestaurantID   uint `gorm:"column:restaurant_id"`
+				Quantity       uint
+				CookingRequest string
+			}
+			type Order struct {
+				OrderID              string
+				UserID               uint
+				RestaurantID         uint
+				AddressID            uint
+				ItemCount            uint
+				CouponCode           string
+				CouponDiscountAmount float64
+				ProductOfferAmount   float64
+				TotalAmount          float64
+				FinalAmount          float64
+				PaymentMethod        string    `gorm:"column:payment_method"`
+				PaymentStatus        string    `gorm:"column:payment_status"`
+				OrderedAt            time.Time `gorm:"autoCreateTime"`
+			}
+			type OrderItem struct {
+				OrderID            string
+				UserID             uint
+				RestaurantID       uint
+				ProductID          uint
+				Quantity           uint
+				Amount             float64
+				ProductOfferAmount float64
+				AfterDeduction     float64 `gorm:"column:after_deduction"`
+				CookingRequest     string
+				OrderStatus        string `gorm:"column:order_status"`
+				OrderReview        string
+				OrderRating        float64
+			}
+			type Payment struct {
+				OrderID           string
+				WalletPaymentID   string `gorm:"column:wallet_payment_id"`
+				StripeSessionID   string
+				StripePaymentID   string
+				RazorpayOrderID   string `gorm:"column:razorpay_order_id"`
+				RazorpayPaymentID string `gorm:"column:razorpay_payment_id"`
+				RazorpaySignature string `gorm:"column:razorpay_signature"`
+				PaymentGateway    string
+				PaymentStatus     string `gorm:"column:payment_status"`
+			}
+			type PasswordReset struct {
+				gorm.Model
+				Email      string
+				Role       string
+				ResetToken string `gorm:"column:reset_token"`
+				Active     string
+				ExpiryTime uint
+			}
+			type CouponInventory struct {
+				CouponCode    string `gorm:"primary_key"`
+				Expiry        uint
+				Percentage    uint
+				MaximumUsage  uint
+				MinimumAmount float64
+			}
+			type CouponUsage struct {
+				gorm.Model
+				UserID     uint
+				CouponCode string
+				UsageCount uint
+			}
+			type UserWalletHistory struct {
+				TransactionTime time.Time `gorm:"autoCreateTime"`
+				WalletPaymentID string    `gorm:"column:wallet_payment_id"`
+				UserID          uint      `gorm:"column:user_id"`
+				Type            string    `gorm:"column:type"`
+				OrderID         string    `gorm:"column:order_id"`
+				Amount          float64   `gorm:"column:amount"`
+				CurrentBalance  float64   `gorm:"column:current_balance"`
+				Reason          string    `gorm:"column:reason"`
+			}
+			type RestaurantWalletHistory struct {
+				TransactionTime time.Time `gorm:"autoCreateTime"`
+				Type            string    `gorm:"column:type"`
+				OrderID         string    `gorm:"column:order_id"`
+				RestaurantID    uint      `gorm:"column:restaurant_id"`
+				Amount          float64   `gorm:"column:amount"`
+				CurrentBalance  float64   `gorm:"column:current_balance"`
+				Reason          string    `gorm:"column:reason"`
+			}
+			type DeliveryVerification struct {
+				OrderID    string `gorm:"column:order_id"`
+				UserID     uint   `gorm:"column:user_id"`
+				OTP        uint   `gorm:"column:otp"`
+				LastSentAT uint   `gorm:"column:last_sent_at"`
+			}
+
+			return tx.AutoMigrate(
+				&User{},
+				&Restaurant{},
+				&Category{},
+				&Product{},
+				&FavouriteProduct{},
+				&Address{},
+				&Admin{},
+				&VerificationTable{},
+				&CartItems{},
+				&Order{},
+				&OrderItem{},
+				&Payment{},
+				&PasswordReset{},
+				&CouponInventory{},
+				&CouponUsage{},
+				&UserWalletHistory{},
+				&RestaurantWalletHistory{},
+				&UserReferralHistory{},
+				&DeliveryVerification{},
+			)
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(
+				"delivery_verifications",
+				"user_referral_histories",
+				"restaurant_wallet_histories",
+				"user_wallet_histories",
+				"coupon_usages",
+				"coupon_inventories",
+				"password_resets",
+				"payments",
+				"order_items",
+				"orders",
+				"cart_items",
+				"verification_tables",
+				"admins",
+				"addresses",
+				"favourite_products",
+				"products",
+				"categories",
+				"restaurants",
+				"users",
+			)
+		},
+	})
+}
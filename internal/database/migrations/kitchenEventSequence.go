@@ -0,0 +1,52 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// kitchen events are numbered per restaurant in the order they commit, the ids of concurrent
+// transactions can commit out of order. the events kept are numbered with their id, so the
+// cursors the kitchens hold stay valid
+func init() {
+	type KitchenEvent struct {
+		RestaurantID uint `gorm:"column:restaurant_id;uniqueIndex:idx_kitchen_events_restaurant_seq,priority:1"`
+		Seq          uint `gorm:"column:seq;not null;default:0;uniqueIndex:idx_kitchen_events_restaurant_seq,priority:2"`
+	}
+	type KitchenSequence struct {
+		RestaurantID uint `gorm:"primaryKey;autoIncrement:false"`
+		LastSeq      uint `gorm:"column:last_seq;not null;default:0"`
+	}
+
+	register(Migration{
+		Version: 18,
+		Name:    "kitchen_event_sequence",
+		Up: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&KitchenEvent{}, "Seq") {
+				if err := tx.Migrator().AddColumn(&KitchenEvent{}, "Seq"); err != nil {
+					return err
+				}
+			}
+			if err := tx.Exec("UPDATE kitchen_events SET seq = id WHERE seq = 0").Error; err != nil {
+				return err
+			}
+			if !tx.Migrator().HasIndex(&KitchenEvent{}, "idx_kitchen_events_restaurant_seq") {
+				if err := tx.Migrator().CreateIndex(&KitchenEvent{}, "idx_kitchen_events_restaurant_seq"); err != nil {
+					return err
+				}
+			}
+			if err := tx.AutoMigrate(&KitchenSequence{}); err != nil {
+				return err
+			}
+			return tx.Exec("INSERT INTO kitchen_sequences (restaurant_id, last_seq) " +
+				"SELECT restaurant_id, MAX(seq) FROM kitchen_events " +
+				"WHERE restaurant_id NOT IN (SELECT restaurant_id FROM kitchen_sequences) GROUP BY restaurant_id").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&KitchenSequence{}); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropIndex(&KitchenEvent{}, "idx_kitchen_events_restaurant_seq"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&KitchenEvent{}, "Seq")
+		},
+	})
+}
@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// the events of the restaurants' kitchen feeds, kept for kitchens reconnecting with a cursor
+func init() {
+	type KitchenEvent struct {
+		ID             uint      `gorm:"primaryKey;index:idx_kitchen_events_restaurant,priority:2"`
+		RestaurantID   uint      `gorm:"column:restaurant_id;index:idx_kitchen_events_restaurant,priority:1"`
+		OrderID        string    `gorm:"column:order_id;size:191"`
+		ProductID      uint      `gorm:"column:product_id"`
+		EventType      string    `gorm:"column:event_type;size:32"`
+		Quantity       uint      `gorm:"column:quantity"`
+		CookingRequest string    `gorm:"column:cooking_request"`
+		Reason         string    `gorm:"column:reason"`
+		CreatedAt      time.Time `gorm:"index:idx_kitchen_events_created_at"`
+	}
+
+	register(Migration{
+		Version: 14,
+		Name:    "kitchen_events",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&KitchenEvent{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&KitchenEvent{})
+		},
+	})
+}
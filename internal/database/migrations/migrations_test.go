@@ -0,0 +1,106 @@
+package migrations
+
+import (
+	"foodbuddy/internal/database"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB opens a fresh sqlite file, nothing is migrated yet
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.Open(database.Config{Driver: database.DriverSQLite, Name: filepath.Join(t.TempDir(), "foodbuddy.db")})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	db.Logger = logger.Discard
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// every migration goes up and comes back down on sqlite, so each Down runs against the schema
+// the later Downs leave behind
+func TestMigrationsUpAndDown(t *testing.T) {
+	db := openTestDB(t)
+
+	all := All()
+	for round := 1; round <= 2; round++ {
+		ran, err := Up(db)
+		if err != nil {
+			t.Fatalf("round %d: %v", round, err)
+		}
+		if len(ran) != len(all) {
+			t.Fatalf("round %d: %d migrations ran, want %d", round, len(ran), len(all))
+		}
+		reverted, err := Down(db, len(all))
+		if err != nil {
+			t.Fatalf("round %d: %v", round, err)
+		}
+		if len(reverted) != len(all) {
+			t.Fatalf("round %d: %d migrations reverted, want %d", round, len(reverted), len(all))
+		}
+		pending, err := Pending(db)
+		if err != nil {
+			t.Fatalf("round %d: %v", round, err)
+		}
+		if len(pending) != len(all) {
+			t.Errorf("round %d: %d migrations pending after reverting them all, want %d", round, len(pending), len(all))
+		}
+	}
+}
+
+// the items of orders in flight are started with their stock taken, the stock they never took
+// is what cancelling them gives back
+func TestStartPaidOrderItemsTakesStock(t *testing.T) {
+	db := openTestDB(t)
+	var start Migration
+	for _, m := range All() {
+		if m.Version == 15 {
+			start = m
+			break
+		}
+		if err := m.Up(db); err != nil {
+			t.Fatalf("migration %d %s: %v", m.Version, m.Name, err)
+		}
+	}
+
+	seed := []string{
+		"INSERT INTO products (id, name, stock_left) VALUES (1, 'biryani', 10), (2, 'haleem', 1), (3, 'kebab', 5)",
+		"INSERT INTO orders (order_id, payment_status) VALUES ('cod', 'COD_PENDING'), ('paid', 'ONLINE_CONFIRMED'), ('unpaid', 'ONLINE_PENDING')",
+		"INSERT INTO order_items (order_id, product_id, quantity, order_status) VALUES " +
+			"('cod', 1, 2, 'INITIATED'), ('cod', 2, 3, 'INITIATED'), ('paid', 1, 3, 'INITIATED'), ('paid', 3, 1, 'CANCELLED'), ('unpaid', 3, 2, 'INITIATED')",
+	}
+	for _, statement := range seed {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	if err := start.Up(db); err != nil {
+		t.Fatalf("migration 15: %v", err)
+	}
+
+	want := map[uint]uint{1: 5, 2: 0, 3: 5}
+	for id, stock := range want {
+		var left uint
+		if err := db.Raw("SELECT stock_left FROM products WHERE id = ?", id).Scan(&left).Error; err != nil {
+			t.Fatalf("read stock: %v", err)
+		}
+		if left != stock {
+			t.Errorf("product %d has %d in stock, want %d", id, left, stock)
+		}
+	}
+	var processing int64
+	if err := db.Raw("SELECT COUNT(*) FROM order_items WHERE order_status = 'PROCESSING'").Scan(&processing).Error; err != nil {
+		t.Fatalf("count items: %v", err)
+	}
+	if processing != 3 {
+		t.Errorf("%d items started, want 3", processing)
+	}
+}
@@ -0,0 +1,69 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// money used to be stored as float rupees, which drifts when it is summed and split.
+// every money column becomes a bigint holding paise. a column is converted by adding
+// the new one next to it, copying the rounded value over, dropping the old column and
+// renaming the new one
+func init() {
+	columns := map[string][]string{
+		"users":                       {"wallet_amount"},
+		"restaurants":                 {"wallet_amount"},
+		"products":                    {"price", "offer_amount"},
+		"orders":                      {"coupon_discount_amount", "product_offer_amount", "total_amount", "final_amount"},
+		"order_items":                 {"amount", "product_offer_amount", "after_deduction"},
+		"coupon_inventories":          {"minimum_amount"},
+		"user_wallet_histories":       {"amount", "current_balance"},
+		"restaurant_wallet_histories": {"amount", "current_balance"},
+	}
+	tables := []string{
+		"users", "restaurants", "products", "orders", "order_items",
+		"coupon_inventories", "user_wallet_histories", "restaurant_wallet_histories",
+	}
+
+	register(Migration{
+		Version: 3,
+		Name:    "money_in_paise",
+		Up: func(tx *gorm.DB) error {
+			for _, table := range tables {
+				for _, column := range columns[table] {
+					if err := convertColumn(tx, table, column, "BIGINT NOT NULL DEFAULT 0", "ROUND(? * 100)"); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, table := range tables {
+				for _, column := range columns[table] {
+					if err := convertColumn(tx, table, column, "DOUBLE PRECISION", "? / 100.0"); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+	})
+}
+
+// convertColumn replaces column with a column of columnType, filled with expr where ? is the old value
+func convertColumn(tx *gorm.DB, table, column, columnType, expr string) error {
+	temp := column + "_converted"
+	if err := tx.Exec("ALTER TABLE ? ADD COLUMN ? "+columnType, clause.Table{Name: table}, clause.Column{Name: temp}).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("UPDATE ? SET ? = "+expr, clause.Table{Name: table}, clause.Column{Name: temp}, clause.Column{Name: column}).Error; err != nil {
+		return err
+	}
+	// plain statements, the sqlite migrator can only drop columns of a model and mysql 8,
+	// postgres and sqlite 3.35 all support these
+	if err := tx.Exec("ALTER TABLE ? DROP COLUMN ?", clause.Table{Name: table}, clause.Column{Name: column}).Error; err != nil {
+		return err
+	}
+	return tx.Exec("ALTER TABLE ? RENAME COLUMN ? TO ?", clause.Table{Name: table}, clause.Column{Name: temp}, clause.Column{Name: column}).Error
+}
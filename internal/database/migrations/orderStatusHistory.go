@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// the steps every order item took through the order state machine
+func init() {
+	type OrderStatusHistory struct {
+		ID         uint   `gorm:"primaryKey"`
+		OrderID    string `gorm:"column:order_id;size:191;index:idx_order_status_histories_order_id"`
+		ProductID  uint   `gorm:"column:product_id"`
+		FromStatus string `gorm:"column:from_status;size:32"`
+		ToStatus   string `gorm:"column:to_status;size:32"`
+		Actor      string `gorm:"column:actor;size:16"`
+		ActorID    uint   `gorm:"column:actor_id"`
+		Reason     string `gorm:"column:reason"`
+		CreatedAt  time.Time
+	}
+
+	register(Migration{
+		Version: 13,
+		Name:    "order_status_history",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&OrderStatusHistory{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&OrderStatusHistory{})
+		},
+	})
+}
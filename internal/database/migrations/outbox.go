@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// emails waiting in the outbox to be delivered by the workers
+func init() {
+	type OutboxMessage struct {
+		ID            uint       `gorm:"primaryKey"`
+		Recipient     string     `gorm:"column:recipient"`
+		Template      string     `gorm:"column:template;size:64"`
+		Subject       string     `gorm:"column:subject"`
+		HTML          string     `gorm:"column:html;type:text"`
+		Text          string     `gorm:"column:text;type:text"`
+		Status        string     `gorm:"column:status;size:16;index:idx_outbox_messages_due,priority:1"`
+		NextAttemptAt time.Time  `gorm:"column:next_attempt_at;index:idx_outbox_messages_due,priority:2"`
+		Attempts      int        `gorm:"column:attempts"`
+		LastError     string     `gorm:"column:last_error;type:text"`
+		LockedUntil   *time.Time `gorm:"column:locked_until"`
+		CreatedAt     time.Time
+		UpdatedAt     time.Time
+		SentAt        *time.Time `gorm:"column:sent_at"`
+	}
+
+	register(Migration{
+		Version: 7,
+		Name:    "outbox",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&OutboxMessage{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&OutboxMessage{})
+		},
+	})
+}
@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// payments are looked up by the gateway's reference whatever the gateway, the references
+// stored in the per gateway columns so far are copied over
+func init() {
+	type Payment struct {
+		GatewayReference string `gorm:"column:gateway_reference;size:191;index:idx_payments_gateway_reference"`
+		GatewayPaymentID string `gorm:"column:gateway_payment_id"`
+		Amount           int64  `gorm:"column:amount"`
+	}
+	columns := []string{"GatewayReference", "GatewayPaymentID", "Amount"}
+	backfill := []struct{ gateway, reference, paymentID string }{
+		{"RAZORPAY", "razorpay_order_id", "razorpay_payment_id"},
+		{"STRIPE", "stripe_session_id", "stripe_payment_id"},
+		{"WALLET", "wallet_payment_id", "wallet_payment_id"},
+	}
+
+	register(Migration{
+		Version: 8,
+		Name:    "payment_gateway_reference",
+		Up: func(tx *gorm.DB) error {
+			for _, field := range columns {
+				if tx.Migrator().HasColumn(&Payment{}, field) {
+					continue
+				}
+				if err := tx.Migrator().AddColumn(&Payment{}, field); err != nil {
+					return err
+				}
+			}
+			if err := tx.Migrator().CreateIndex(&Payment{}, "idx_payments_gateway_reference"); err != nil {
+				return err
+			}
+			for _, b := range backfill {
+				err := tx.Exec("UPDATE payments SET gateway_reference = ?, gateway_payment_id = ? WHERE payment_gateway = ?",
+					clause.Column{Name: b.reference}, clause.Column{Name: b.paymentID}, b.gateway).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		// sqlite rebuilds the table when a later Down drops a column and the rebuild loses the index
+		Down: func(tx *gorm.DB) error {
+			if tx.Migrator().HasIndex(&Payment{}, "idx_payments_gateway_reference") {
+				if err := tx.Migrator().DropIndex(&Payment{}, "idx_payments_gateway_reference"); err != nil {
+					return err
+				}
+			}
+			for _, field := range columns {
+				if err := tx.Migrator().DropColumn(&Payment{}, field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
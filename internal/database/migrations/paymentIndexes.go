@@ -0,0 +1,52 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// the payment callbacks look payments up by order, stripe session and razorpay order,
+// none of which were indexed. the columns were longtext on mysql, which can't be indexed
+// without a prefix length, so they are narrowed to varchar(191) first
+func init() {
+	type Payment struct {
+		OrderID         string `gorm:"size:191;index:idx_payments_order_id"`
+		StripeSessionID string `gorm:"size:191;index:idx_payments_stripe_session_id"`
+		RazorpayOrderID string `gorm:"column:razorpay_order_id;size:191;index:idx_payments_razorpay_order_id"`
+	}
+	columns := []string{"OrderID", "StripeSessionID", "RazorpayOrderID"}
+	indexes := []string{"idx_payments_order_id", "idx_payments_stripe_session_id", "idx_payments_razorpay_order_id"}
+
+	register(Migration{
+		Version: 2,
+		Name:    "payment_lookup_indexes",
+		Up: func(tx *gorm.DB) error {
+			// sqlite alters a column by rebuilding the table, which drops its indexes,
+			// so every column is altered before any index is created
+			for _, field := range columns {
+				if err := tx.Migrator().AlterColumn(&Payment{}, field); err != nil {
+					return err
+				}
+			}
+			for _, index := range indexes {
+				if tx.Migrator().HasIndex(&Payment{}, index) {
+					continue
+				}
+				if err := tx.Migrator().CreateIndex(&Payment{}, index); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		// the columns are left as varchar(191), every stored id fits in them. on sqlite the indexes
+		// are already gone when a later Down rebuilt the table to drop a column
+		Down: func(tx *gorm.DB) error {
+			for _, index := range indexes {
+				if !tx.Migrator().HasIndex(&Payment{}, index) {
+					continue
+				}
+				if err := tx.Migrator().DropIndex(&Payment{}, index); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// the runs of the payment reconciliation and the discrepancies each of them found
+func init() {
+	type ReconciliationRun struct {
+		ID            uint       `gorm:"primaryKey"`
+		Trigger       string     `gorm:"column:triggered_by;size:16"`
+		Checked       int        `gorm:"column:checked"`
+		Fixed         int        `gorm:"column:fixed"`
+		Expired       int        `gorm:"column:expired"`
+		Discrepancies int        `gorm:"column:discrepancies"`
+		StartedAt     time.Time  `gorm:"column:started_at;index:idx_reconciliation_runs_started_at"`
+		FinishedAt    *time.Time `gorm:"column:finished_at"`
+	}
+	type PaymentDiscrepancy struct {
+		ID            uint   `gorm:"primaryKey"`
+		RunID         uint   `gorm:"column:run_id;index:idx_payment_discrepancies_run_id"`
+		OrderID       string `gorm:"column:order_id;size:191"`
+		Gateway       string `gorm:"column:gateway;size:32"`
+		Reference     string `gorm:"column:reference"`
+		PaymentID     string `gorm:"column:payment_id"`
+		Amount        int64  `gorm:"column:amount"`
+		OurStatus     string `gorm:"column:our_status"`
+		GatewayStatus string `gorm:"column:gateway_status"`
+		Action        string `gorm:"column:action;size:16"`
+		Note          string `gorm:"column:note"`
+		CreatedAt     time.Time
+	}
+
+	register(Migration{
+		Version: 10,
+		Name:    "payment_reconciliation",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&ReconciliationRun{}, &PaymentDiscrepancy{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&PaymentDiscrepancy{}, &ReconciliationRun{})
+		},
+	})
+}
@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// the refunds of cancelled order items, to the wallet or through the gateway
+func init() {
+	type Refund struct {
+		ID               uint   `gorm:"primaryKey"`
+		OrderID          string `gorm:"column:order_id;size:191;index:idx_refunds_order_id"`
+		ProductID        uint   `gorm:"column:product_id"`
+		UserID           uint   `gorm:"column:user_id"`
+		Amount           int64  `gorm:"column:amount"`
+		Destination      string `gorm:"column:destination;size:16"`
+		PaymentGateway   string `gorm:"column:payment_gateway;size:32;index:idx_refunds_gateway_refund_id,priority:1"`
+		GatewayReference string `gorm:"column:gateway_reference"`
+		GatewayRefundID  string `gorm:"column:gateway_refund_id;size:191;index:idx_refunds_gateway_refund_id,priority:2"`
+		Status           string `gorm:"column:status;size:16"`
+		FailureReason    string `gorm:"column:failure_reason"`
+		CreatedAt        time.Time
+		UpdatedAt        time.Time
+		ProcessedAt      *time.Time `gorm:"column:processed_at"`
+	}
+
+	register(Migration{
+		Version: 12,
+		Name:    "refunds",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&Refund{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&Refund{})
+		},
+	})
+}
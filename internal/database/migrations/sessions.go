@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// server side sessions behind the refresh tokens, and the access tokens revoked before they expired
+func init() {
+	type Session struct {
+		ID                  string `gorm:"primaryKey;size:36"`
+		Email               string `gorm:"size:191;index:idx_sessions_account"`
+		Role                string `gorm:"size:32;index:idx_sessions_account"`
+		RefreshTokenHash    string `gorm:"size:64;uniqueIndex:idx_sessions_refresh_token_hash"`
+		PreviousRefreshHash string `gorm:"size:64;index:idx_sessions_previous_refresh_hash"`
+		AccessTokenID       string `gorm:"size:36"`
+		AccessExpiresAt     time.Time
+		UserAgent           string
+		IPAddress           string
+		CreatedAt           time.Time
+		LastUsedAt          time.Time
+		ExpiresAt           time.Time
+		RevokedAt           *time.Time
+	}
+	type RevokedToken struct {
+		JTI       string    `gorm:"column:jti;primaryKey;size:36"`
+		ExpiresAt time.Time `gorm:"index:idx_revoked_tokens_expires_at"`
+	}
+
+	register(Migration{
+		Version: 5,
+		Name:    "sessions",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&Session{}, &RevokedToken{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&RevokedToken{}, &Session{})
+		},
+	})
+}
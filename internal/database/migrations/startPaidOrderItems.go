@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// paid and cash on delivery orders used to leave their items INITIATED, which the order state
+// machine keeps for items waiting for their payment. the items of orders in flight are moved
+// to PROCESSING so the restaurants can go on with them, with the step in their history. the
+// stock was only ever taken for items in processing, so it is taken for them here: cancelling
+// them gives it back. a product short of it is left at zero
+func init() {
+	started := []string{"ONLINE_CONFIRMED", "COD_PENDING", "COD_CONFIRMED"}
+	waiting := "order_status = 'INITIATED' AND order_id IN (SELECT order_id FROM orders WHERE payment_status IN ?)"
+	taken := "(SELECT COALESCE(SUM(quantity), 0) FROM order_items WHERE product_id = products.id AND " + waiting + ")"
+
+	register(Migration{
+		Version: 15,
+		Name:    "start_paid_order_items",
+		Up: func(tx *gorm.DB) error {
+			err := tx.Exec("UPDATE products SET stock_left = CASE WHEN stock_left > "+taken+" THEN stock_left - "+taken+" ELSE 0 END "+
+				"WHERE id IN (SELECT product_id FROM order_items WHERE "+waiting+")",
+				started, started, started).Error
+			if err != nil {
+				return err
+			}
+			err = tx.Exec("INSERT INTO order_status_histories (order_id, product_id, from_status, to_status, actor, actor_id, reason, created_at) "+
+				"SELECT order_id, product_id, 'INITIATED', 'PROCESSING', 'system', 0, 'started by the migration to the order state machine', ? FROM order_items WHERE "+waiting,
+				time.Now(), started).Error
+			if err != nil {
+				return err
+			}
+			return tx.Exec("UPDATE order_items SET order_status = 'PROCESSING' WHERE "+waiting, started).Error
+		},
+		// the items can't be told apart from the ones started since, they and their stock are
+		// left as they are
+		Down: func(tx *gorm.DB) error {
+			return nil
+		},
+	})
+}
@@ -0,0 +1,42 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// online orders hold the stock of their items until they are paid, the products keep the sum
+// of their holds next to their stock
+func init() {
+	type Product struct {
+		Reserved uint `gorm:"column:reserved;not null;default:0"`
+	}
+	type StockReservation struct {
+		ID        uint      `gorm:"primaryKey"`
+		OrderID   string    `gorm:"column:order_id;size:191;index:idx_stock_reservations_order_id"`
+		ProductID uint      `gorm:"column:product_id"`
+		Quantity  uint      `gorm:"column:quantity"`
+		ExpiresAt time.Time `gorm:"column:expires_at;index:idx_stock_reservations_expires_at"`
+		CreatedAt time.Time
+	}
+
+	register(Migration{
+		Version: 16,
+		Name:    "stock_reservations",
+		Up: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&Product{}, "Reserved") {
+				if err := tx.Migrator().AddColumn(&Product{}, "Reserved"); err != nil {
+					return err
+				}
+			}
+			return tx.AutoMigrate(&StockReservation{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&StockReservation{}); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&Product{}, "Reserved")
+		},
+	})
+}
@@ -0,0 +1,57 @@
+package migrations
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// the database turns down any change that takes a user or restaurant wallet below zero, the
+// migration stops when a wallet already is so it can be settled first
+func init() {
+	type User struct {
+		WalletAmount int64 `gorm:"column:wallet_amount;check:chk_users_wallet_amount,wallet_amount >= 0"`
+	}
+	type Restaurant struct {
+		WalletAmount int64 `gorm:"column:wallet_amount;check:chk_restaurants_wallet_amount,wallet_amount >= 0"`
+	}
+	wallets := []struct {
+		model      interface{}
+		table      string
+		constraint string
+	}{
+		{&User{}, "users", "chk_users_wallet_amount"},
+		{&Restaurant{}, "restaurants", "chk_restaurants_wallet_amount"},
+	}
+
+	register(Migration{
+		Version: 17,
+		Name:    "wallet_balance_check",
+		Up: func(tx *gorm.DB) error {
+			for _, w := range wallets {
+				var negative int64
+				if err := tx.Table(w.table).Where("wallet_amount < 0").Count(&negative).Error; err != nil {
+					return err
+				}
+				if negative > 0 {
+					return fmt.Errorf("%d %s have a negative wallet balance, settle them before migrating", negative, w.table)
+				}
+				if tx.Migrator().HasConstraint(w.model, w.constraint) {
+					continue
+				}
+				if err := tx.Migrator().CreateConstraint(w.model, w.constraint); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, w := range wallets {
+				if err := tx.Migrator().DropConstraint(w.model, w.constraint); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,89 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// the double entry ledger behind the wallets. wallets that already hold money get an
+// account and an opening balance entry against the platform's opening balance account,
+// so the ledger agrees with the stored balances from the start
+func init() {
+	type LedgerAccount struct {
+		ID        uint   `gorm:"primaryKey"`
+		Type      string `gorm:"size:64;uniqueIndex:idx_ledger_accounts_type_owner"`
+		OwnerID   uint   `gorm:"uniqueIndex:idx_ledger_accounts_type_owner"`
+		CreatedAt time.Time
+	}
+	type JournalEntry struct {
+		ID        uint `gorm:"primaryKey"`
+		CreatedAt time.Time
+		Reason    string
+		OrderID   string `gorm:"size:191;index:idx_journal_entries_order_id"`
+	}
+	type JournalLine struct {
+		ID        uint `gorm:"primaryKey"`
+		EntryID   uint `gorm:"index:idx_journal_lines_entry_id"`
+		AccountID uint `gorm:"index:idx_journal_lines_account_id"`
+		Debit     int64
+		Credit    int64
+	}
+
+	account := func(tx *gorm.DB, accountType string, ownerID uint) (uint, error) {
+		row := LedgerAccount{Type: accountType, OwnerID: ownerID}
+		err := tx.Where("type = ? AND owner_id = ?", accountType, ownerID).FirstOrCreate(&row).Error
+		return row.ID, err
+	}
+
+	register(Migration{
+		Version: 4,
+		Name:    "wallet_ledger",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&LedgerAccount{}, &JournalEntry{}, &JournalLine{}); err != nil {
+				return err
+			}
+			opening, err := account(tx, "OPENING_BALANCE", 0)
+			if err != nil {
+				return err
+			}
+
+			wallets := map[string]string{"users": "USER_WALLET", "restaurants": "RESTAURANT_WALLET"}
+			for _, table := range []string{"users", "restaurants"} {
+				var rows []struct {
+					ID           uint
+					WalletAmount int64
+				}
+				if err := tx.Table(table).Select("id, wallet_amount").Where("wallet_amount <> 0").Scan(&rows).Error; err != nil {
+					return err
+				}
+				for _, row := range rows {
+					wallet, err := account(tx, wallets[table], row.ID)
+					if err != nil {
+						return err
+					}
+					entry := JournalEntry{CreatedAt: time.Now(), Reason: "OPENINGBALANCE"}
+					if err := tx.Create(&entry).Error; err != nil {
+						return err
+					}
+					// a positive balance moves from the opening account into the wallet
+					from, to, amount := opening, wallet, row.WalletAmount
+					if amount < 0 {
+						from, to, amount = wallet, opening, -amount
+					}
+					lines := []JournalLine{
+						{EntryID: entry.ID, AccountID: from, Debit: amount},
+						{EntryID: entry.ID, AccountID: to, Credit: amount},
+					}
+					if err := tx.Create(&lines).Error; err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("journal_lines", "journal_entries", "ledger_accounts")
+		},
+	})
+}
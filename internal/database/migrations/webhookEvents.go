@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// the payment webhook events already handled, and the amount refunded of each payment
+func init() {
+	type WebhookEvent struct {
+		ID        uint   `gorm:"primaryKey"`
+		Provider  string `gorm:"column:provider;size:32;uniqueIndex:idx_webhook_events_provider_event"`
+		EventID   string `gorm:"column:event_id;size:191;uniqueIndex:idx_webhook_events_provider_event"`
+		Type      string `gorm:"column:type"`
+		CreatedAt time.Time
+	}
+	type Payment struct {
+		RefundedAmount int64 `gorm:"column:refunded_amount;not null;default:0"`
+	}
+
+	register(Migration{
+		Version: 9,
+		Name:    "webhook_events",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&WebhookEvent{}); err != nil {
+				return err
+			}
+			if !tx.Migrator().HasColumn(&Payment{}, "RefundedAmount") {
+				if err := tx.Migrator().AddColumn(&Payment{}, "RefundedAmount"); err != nil {
+					return err
+				}
+			}
+			// refunds are capped at the amount paid, which payments made before
+			// payment_gateway_reference don't have, it is the final amount of their order
+			return tx.Exec("UPDATE payments SET amount = (SELECT final_amount FROM orders WHERE orders.order_id = payments.order_id) WHERE amount IS NULL OR amount = 0").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropColumn(&Payment{}, "RefundedAmount"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropTable(&WebhookEvent{})
+		},
+	})
+}
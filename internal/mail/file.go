@@ -0,0 +1,38 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// FileMailer writes every message as an .eml file into a directory instead of sending it,
+// the files open in any mail client. it lets the signup and delivery flows run offline
+type FileMailer struct {
+	dir   string
+	from  string
+	count atomic.Uint64
+}
+
+func NewFileMailer(dir string, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create the mail directory: %w", err)
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+func (m *FileMailer) Send(msg Message) error {
+	if len(msg.To) == 0 {
+		return errors.New("mail has no recipients")
+	}
+	now := time.Now()
+	raw, err := Build(m.from, msg, now)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%04d.eml", now.Format("20060102-150405.000"), m.count.Add(1)%10000)
+	return os.WriteFile(filepath.Join(m.dir, name), raw, 0o644)
+}
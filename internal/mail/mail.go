@@ -0,0 +1,166 @@
+// Package mail sends the emails of the platform through a Mailer. the mailer is picked by
+// configuration: smtp in production, .eml files on disk in development and an in-memory
+// recorder in tests
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"foodbuddy/internal/model"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// supported mailers
+const (
+	DriverSMTP   = "smtp"
+	DriverFile   = "file"
+	DriverMemory = "memory"
+)
+
+// Message is an email with an html body and a plain text alternative, either can be empty
+type Message struct {
+	To      []string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Mailer delivers messages
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// Config describes which mailer to use and how to reach the smtp server
+type Config struct {
+	Driver   string
+	From     string
+	Host     string
+	Port     string
+	Username string
+	Password string
+	// Dir is where the file mailer writes the .eml files
+	Dir string
+
+	// TemplateDir holds the email templates, DefaultLocale is used when a template
+	// has no variant in the locale asked for
+	TemplateDir   string
+	DefaultLocale string
+}
+
+// NewConfig reads the mail configuration out of the environment variables,
+// unset values fall back to the gmail relay the platform used so far
+func NewConfig(env model.EnvVariables) Config {
+	config := Config{
+		Driver:   strings.ToLower(env.Mailer),
+		From:     env.MailFrom,
+		Host:     env.SMTPHost,
+		Port:     env.SMTPPort,
+		Username: env.SMTPUsername,
+		Password: env.SMTPPassword,
+		Dir:      env.MailDir,
+
+		TemplateDir:   env.MailTemplateDir,
+		DefaultLocale: env.MailLocale,
+	}
+	if config.Driver == "" {
+		config.Driver = DriverSMTP
+	}
+	if config.From == "" {
+		config.From = "foodbuddycode@gmail.com"
+	}
+	if config.Host == "" {
+		config.Host = "smtp.gmail.com"
+	}
+	if config.Port == "" {
+		config.Port = "587"
+	}
+	if config.Username == "" {
+		config.Username = config.From
+	}
+	if config.Dir == "" {
+		config.Dir = "mail"
+	}
+	if config.TemplateDir == "" {
+		config.TemplateDir = "templates/email"
+	}
+	if config.DefaultLocale == "" {
+		config.DefaultLocale = "en"
+	}
+	return config
+}
+
+// New returns the mailer of the configured driver
+func New(config Config) (Mailer, error) {
+	switch config.Driver {
+	case DriverSMTP:
+		if config.Password == "" {
+			log.Printf("Warning: SMTPAPP is not set, sending mail will fail")
+		}
+		return NewSMTPMailer(config), nil
+	case DriverFile:
+		return NewFileMailer(config.Dir, config.From)
+	case DriverMemory:
+		return NewMemoryMailer(), nil
+	}
+	return nil, fmt.Errorf("unsupported mailer %q, use %s, %s or %s", config.Driver, DriverSMTP, DriverFile, DriverMemory)
+}
+
+// Build encodes the message as a MIME email, with both bodies as multipart/alternative
+func Build(from string, msg Message, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: %s\r\n", messageID(from))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", writer.Boundary())
+
+	// the last part is the preferred one, so html goes after the text
+	parts := []struct{ contentType, body string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	}
+	for _, part := range parts {
+		if part.body == "" {
+			continue
+		}
+		w, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=\"UTF-8\""},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func messageID(from string) string {
+	random := make([]byte, 12)
+	rand.Read(random)
+	domain := "foodbuddy"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+	return "<" + hex.EncodeToString(random) + "@" + domain + ">"
+}
@@ -0,0 +1,53 @@
+package mail
+
+import "sync"
+
+// MemoryMailer keeps the messages it is given instead of sending them, for tests
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+	// Err is returned by Send when set, to test how failed deliveries are handled
+	Err error
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return m.Err
+	}
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// Messages returns the messages sent so far, oldest first
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
+
+// SentTo returns the messages sent to the address
+func (m *MemoryMailer) SentTo(address string) []Message {
+	var sent []Message
+	for _, msg := range m.Messages() {
+		for _, to := range msg.To {
+			if to == address {
+				sent = append(sent, msg)
+				break
+			}
+		}
+	}
+	return sent
+}
+
+// Reset forgets the messages sent so far
+func (m *MemoryMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = nil
+}
@@ -0,0 +1,34 @@
+package mail
+
+import (
+	"errors"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// SMTPMailer sends mail through an smtp server with plain auth
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func NewSMTPMailer(config Config) *SMTPMailer {
+	return &SMTPMailer{
+		addr: net.JoinHostPort(config.Host, config.Port),
+		from: config.From,
+		auth: smtp.PlainAuth("", config.Username, config.Password, config.Host),
+	}
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+	if len(msg.To) == 0 {
+		return errors.New("mail has no recipients")
+	}
+	raw, err := Build(m.from, msg, time.Now())
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(m.addr, m.auth, m.from, msg.To, raw)
+}
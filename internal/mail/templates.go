@@ -0,0 +1,203 @@
+package mail
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+)
+
+// ErrTemplateNotFound is returned when no locale of a template exists
+var ErrTemplateNotFound = errors.New("email template not found")
+
+// Templates renders the email templates in a directory. each template is a file named
+// <name>.<locale>.tmpl defining the blocks "subject", "html" and "text", for example
+// verification.en.tmpl. the html block is rendered with html/template so the data is
+// escaped, subject and text are plain text. a file is parsed again when it changes on
+// disk, so the copy can be edited without restarting the server.
+// <name>.sample.json holds the data the admin preview renders the template with
+type Templates struct {
+	dir           string
+	defaultLocale string
+
+	mu    sync.Mutex
+	cache map[string]*parsedTemplate
+}
+
+type parsedTemplate struct {
+	modTime time.Time
+	html    *htmltemplate.Template
+	text    *texttemplate.Template
+}
+
+// TemplateInfo describes a template and the locales it is available in
+type TemplateInfo struct {
+	Name    string   `json:"name"`
+	Locales []string `json:"locales"`
+}
+
+// LoadTemplates parses every template in dir so broken templates are found at startup
+func LoadTemplates(dir string, defaultLocale string) (*Templates, error) {
+	t := &Templates{dir: dir, defaultLocale: strings.ToLower(defaultLocale), cache: map[string]*parsedTemplate{}}
+	infos, err := t.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		for _, locale := range info.Locales {
+			if _, err := t.parse(t.path(info.Name, locale)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return t, nil
+}
+
+// List returns the templates found in the directory
+func (t *Templates) List() ([]TemplateInfo, error) {
+	files, err := filepath.Glob(filepath.Join(t.dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	locales := map[string][]string{}
+	for _, file := range files {
+		base := strings.TrimSuffix(filepath.Base(file), ".tmpl")
+		dot := strings.LastIndex(base, ".")
+		if dot <= 0 {
+			return nil, fmt.Errorf("email template %s should be named <name>.<locale>.tmpl", file)
+		}
+		locales[base[:dot]] = append(locales[base[:dot]], base[dot+1:])
+	}
+	infos := make([]TemplateInfo, 0, len(locales))
+	for name, l := range locales {
+		sort.Strings(l)
+		infos = append(infos, TemplateInfo{Name: name, Locales: l})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+// Render renders the template in the closest locale available: the exact locale ("en-in"),
+// then its language ("en"), then the default locale. the returned message has no recipients
+func (t *Templates) Render(name string, locale string, data interface{}) (Message, error) {
+	tmpl, err := t.find(name, locale)
+	if err != nil {
+		return Message{}, err
+	}
+
+	var msg Message
+	var buf bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&buf, "subject", data); err != nil {
+		return msg, fmt.Errorf("email template %s: %w", name, err)
+	}
+	msg.Subject = strings.TrimSpace(buf.String())
+
+	if tmpl.html.Lookup("html") != nil {
+		buf.Reset()
+		if err := tmpl.html.ExecuteTemplate(&buf, "html", data); err != nil {
+			return msg, fmt.Errorf("email template %s: %w", name, err)
+		}
+		msg.HTML = strings.TrimSpace(buf.String())
+	}
+	if tmpl.text.Lookup("text") != nil {
+		buf.Reset()
+		if err := tmpl.text.ExecuteTemplate(&buf, "text", data); err != nil {
+			return msg, fmt.Errorf("email template %s: %w", name, err)
+		}
+		msg.Text = strings.TrimSpace(buf.String())
+	}
+	return msg, nil
+}
+
+// SampleData returns the preview data of a template, an empty map when it has none
+func (t *Templates) SampleData(name string) (map[string]interface{}, error) {
+	data := map[string]interface{}{}
+	content, err := os.ReadFile(filepath.Join(t.dir, name+".sample.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &data); err != nil {
+		return nil, fmt.Errorf("sample data of email template %s: %w", name, err)
+	}
+	return data, nil
+}
+
+func (t *Templates) find(name string, locale string) (*parsedTemplate, error) {
+	if strings.ContainsAny(name, `/\.`) {
+		return nil, ErrTemplateNotFound
+	}
+	for _, candidate := range localeCandidates(locale, t.defaultLocale) {
+		path := t.path(name, candidate)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		return t.parse(path)
+	}
+	return nil, ErrTemplateNotFound
+}
+
+func (t *Templates) path(name string, locale string) string {
+	return filepath.Join(t.dir, name+"."+locale+".tmpl")
+}
+
+// parse returns the parsed file, from the cache unless the file changed since
+func (t *Templates) parse(path string) (*parsedTemplate, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if cached, ok := t.cache[path]; ok && cached.modTime.Equal(info.ModTime()) {
+		return cached, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	name := filepath.Base(path)
+	html, err := htmltemplate.New(name).Parse(string(content))
+	if err != nil {
+		return nil, err
+	}
+	text, err := texttemplate.New(name).Parse(string(content))
+	if err != nil {
+		return nil, err
+	}
+	if text.Lookup("subject") == nil {
+		return nil, fmt.Errorf("email template %s has no subject block", name)
+	}
+	if text.Lookup("html") == nil && text.Lookup("text") == nil {
+		return nil, fmt.Errorf("email template %s needs an html or a text block", name)
+	}
+
+	parsed := &parsedTemplate{modTime: info.ModTime(), html: html, text: text}
+	t.cache[path] = parsed
+	return parsed, nil
+}
+
+// localeCandidates lists the locales to try for a requested locale, most specific first
+func localeCandidates(locale string, defaultLocale string) []string {
+	locale = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+	var candidates []string
+	if locale != "" && !strings.ContainsAny(locale, `/\.`) {
+		candidates = append(candidates, locale)
+		if dash := strings.Index(locale, "-"); dash > 0 {
+			candidates = append(candidates, locale[:dash])
+		}
+	}
+	return append(candidates, defaultLocale)
+}
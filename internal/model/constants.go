@@ -1,5 +1,7 @@
 package model
 
+import "foodbuddy/internal/money"
+
 const (
 	LocalHost                  = "localhost"
 	ProjectRoot                = "PROJECTROOT"
@@ -15,15 +17,80 @@ const (
 	YES                        = "YES"
 	NO                         = "NO"
 
-	CODMaximumAmount                    = 1000
+	CODMaximumAmount                    = 1000 * money.Rupee
 	DeliveryVerificationOTPCooldownTime = 1 * 60
 
+	// lifetimes in seconds, access tokens are short lived and renewed with the refresh token
+	AccessTokenLifetime  = 15 * 60
+	RefreshTokenLifetime = 30 * 24 * 60 * 60
+	AccessTokenCookie    = "Authorization"
+	RefreshTokenCookie   = "RefreshToken"
+
+	// super admins can invite other admins, standard admins manage the platform only
+	AdminPrivilegeSuper    = "SUPER"
+	AdminPrivilegeStandard = "STANDARD"
+	AdminInviteLifetime    = 48 * 60 * 60
+	AdminRecoveryCodeCount = 10
+	TOTPIssuer             = "FoodBuddy"
+
+	OutboxPending    = "PENDING"
+	OutboxProcessing = "PROCESSING"
+	OutboxSent       = "SENT"
+	OutboxDead       = "DEAD"
+
+	// a message is dead lettered after OutboxMaxAttempts failed deliveries, the wait between
+	// attempts doubles from OutboxRetryBase up to OutboxRetryMax (seconds)
+	OutboxMaxAttempts = 8
+	OutboxRetryBase   = 30
+	OutboxRetryMax    = 60 * 60
+
+	// online orders still unpaid PaymentPendingTTL seconds after they were placed are expired by
+	// the payment reconciliation, which runs every ReconcileInterval seconds
+	PaymentPendingTTL = 30 * 60
+	ReconcileInterval = 10 * 60
+
+	// online orders hold the stock of their items StockHoldTTL seconds from when they are placed
+	// or their payment is started, a failed payment lets go of it right away
+	StockHoldTTL = 15 * 60
+
+	// paid order items the restaurant hasn't accepted AcceptanceDeadline seconds after they
+	// came in are rejected on its behalf and refunded
+	AcceptanceDeadline = 10 * 60
+
+	ReconcileTriggerScheduled = "SCHEDULED"
+	ReconcileTriggerAdmin     = "ADMIN"
+
+	// what the reconciliation did about a payment whose state differed from the gateway's
+	ReconcileSettled   = "SETTLED"    // paid at the gateway, confirmed here
+	ReconcileFailed    = "FAILED"     // failed or abandoned at the gateway, failed here
+	ReconcileExpired   = "EXPIRED"    // order left unpaid past the ttl, expired with its items
+	ReconcileRefundDue = "REFUND_DUE" // paid at the gateway for an order that no longer takes it
+	ReconcileReview    = "REVIEW"     // left alone, someone has to look at it
+
+	// a response is replayed for its Idempotency-Key for IdempotencyKeyLifetime seconds, a key
+	// still processing after IdempotencyLease seconds was abandoned and can be used again
+	IdempotencyKeyHeader   = "Idempotency-Key"
+	IdempotencyProcessing  = "PROCESSING"
+	IdempotencyCompleted   = "COMPLETED"
+	IdempotencyKeyLifetime = 24 * 60 * 60
+	IdempotencyLease       = 2 * 60
+	IdempotencyKeyMaxLen   = 128
+
 	CashOnDelivery = "COD"
 	OnlinePayment  = "ONLINE"
 
-	Razorpay = "RAZORPAY"
-	Stripe   = "STRIPE"
-	Wallet   = "WALLET"
+	Razorpay    = "RAZORPAY"
+	Stripe      = "STRIPE"
+	Wallet      = "WALLET"
+	FakeGateway = "FAKE" // offline gateway, only registered when FAKEPAYMENTS=true
+
+	// cancelled items of online orders are refunded to the user's wallet or back to the payment
+	RefundToWallet = "WALLET"
+	RefundToSource = "SOURCE"
+
+	RefundStatusPending   = "PENDING"
+	RefundStatusProcessed = "PROCESSED"
+	RefundStatusFailed    = "FAILED"
 
 	WalletIncoming = "INCOMING"
 	WalletOutgoing = "OUTGOING"
@@ -31,16 +98,28 @@ const (
 	WalletTxTypeOrderRefund    = "ORDERREFUND"
 	WalletTxTypeReferralReward = "REFERRALREWARD"
 	WalletTxTypeOrderPayment   = "ORDERPAYMENT"
+	WalletTxTypeOpeningBalance = "OPENINGBALANCE"
+	WalletTxTypeGatewayRefund  = "GATEWAYREFUND"
+
+	// ledger account types, the wallets are owned by a user or a restaurant
+	LedgerUserWallet       = "USER_WALLET"
+	LedgerRestaurantWallet = "RESTAURANT_WALLET"
+	LedgerPaymentGateway   = "PAYMENT_GATEWAY"
+	LedgerReferralRewards  = "REFERRAL_REWARDS"
+	LedgerOpeningBalance   = "OPENING_BALANCE"
 
 	OnlinePaymentPending   = "ONLINE_PENDING"
 	OnlinePaymentConfirmed = "ONLINE_CONFIRMED"
 	OnlinePaymentFailed    = "ONLINE_FAILED"
+	OnlinePaymentRefunded  = "ONLINE_REFUNDED"
+	OnlinePaymentExpired   = "ONLINE_EXPIRED" // left unpaid past PaymentPendingTTL, can't be paid anymore
 
 	CODStatusPending   = "COD_PENDING"
 	CODStatusConfirmed = "COD_CONFIRMED"
 	CODStatusFailed    = "COD_FAILED"
 
 	OrderStatusProcessing    = "PROCESSING"
+	OrderStatusAccepted      = "ACCEPTED"
 	OrderStatusInitiated     = "INITIATED"
 	OrderStatusInPreparation = "PREPARATION"
 	OrderStatusPrepared      = "PREPARED"
@@ -48,8 +127,32 @@ const (
 	OrderStatusDelivered     = "DELIVERED"
 	OrderStatusCancelled     = "CANCELLED"
 
+	// SystemActor moves order items on behalf of the payments and the background jobs, the
+	// other actors are the UserRole, RestaurantRole and AdminRole accounts
+	SystemActor = "system"
+
+	// the events pushed to the customers tracking an order, the snapshot of the order comes first
+	OrderEventSnapshot         = "snapshot"
+	OrderEventItemStatus       = "item_status"
+	OrderEventPaymentConfirmed = "payment_confirmed"
+	OrderEventDeliveryCodeSent = "delivery_code_sent"
+	// StreamEventSessionEnded closes a stream whose access token expired or was revoked
+	StreamEventSessionEnded = "session_ended"
+	// StreamEventError closes a stream that failed after it started
+	StreamEventError = "error"
+
+	// the events of a restaurant's kitchen feed, every event is about one order item
+	KitchenEventNewItem        = "new_item"       // paid, or placed as cash on delivery
+	KitchenEventItemCancelled  = "item_cancelled" // after the kitchen got it
+	KitchenEventItemWithdrawn  = "item_withdrawn" // switched to online payment, it comes back once paid
+	KitchenEventCookingRequest = "cooking_request"
+	KitchenEventReset          = "reset" // events after the cursor were purged, reload the orders
+
+	KitchenEventRetention = 24 * 60 * 60 // seconds the kitchen events are kept for replay
+	KitchenReplayBatch    = 200
+
 	CouponDiscountPercentageLimit = 50
 
-	ReferralClaimAmount = 30
+	ReferralClaimAmount = 30 * money.Rupee
 	ReferralClaimLimit  = 1
 )
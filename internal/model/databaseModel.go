@@ -1,43 +1,102 @@
 package model
 
 import (
+	"foodbuddy/internal/money"
 	"time"
 
 	"gorm.io/gorm"
 )
 
 type EnvVariables struct {
-	ServerURL           string
-	Port                string
-	ClientID            string
-	ClientSecret        string
-	DBUser              string
-	DBPassword          string
-	DBName              string
-	JWTSecret           string
-	CloudinaryCloudName string
-	CloudinaryAccessKey string
-	CloudinarySecretKey string
+	ServerURL             string
+	Port                  string
+	ClientID              string
+	ClientSecret          string
+	DBUser                string
+	DBPassword            string
+	DBName                string
+	DBDriver              string
+	DBHost                string
+	DBPort                string
+	DBTLS                 string
+	DBMaxOpenConns        string
+	DBMaxIdleConns        string
+	DBConnMaxLifetime     string
+	JWTSecret             string
+	Mailer                string
+	MailFrom              string
+	MailDir               string
+	MailTemplateDir       string
+	MailLocale            string
+	SMTPHost              string
+	SMTPPort              string
+	SMTPUsername          string
+	SMTPPassword          string
+	CloudinaryCloudName   string
+	CloudinaryAccessKey   string
+	CloudinarySecretKey   string
+	RazorpayKeyID         string
+	RazorpayKeySecret     string
+	RazorpayWebhookSecret string
+	StripeKey             string
+	StripeWebhookSecret   string
+	PaymentPendingTTL     string
+	ReconcileInterval     string
+	AcceptanceDeadline    string
+	StockHoldTTL          string
+	FakePayments          string
 }
 
 type Admin struct {
 	gorm.Model
-	Email string `validate:"required,email"`
+	Email          string `validate:"required,email" json:"email"`
+	Name           string `gorm:"column:name" json:"name"`
+	Privilege      string `gorm:"column:privilege;size:32" json:"privilege"`
+	HashedPassword string `gorm:"column:hashed_password" json:"-"`
+	Salt           string `gorm:"column:salt" json:"-"`
+	// TOTPSecret is set when the invite is accepted, the admin can sign in once TOTPEnabled
+	// is set by confirming a code from the authenticator app
+	TOTPSecret  string `gorm:"column:totp_secret" json:"-"`
+	TOTPEnabled bool   `gorm:"column:totp_enabled" json:"totp_enabled"`
+	// TOTPLastStep is the time step of the last code used, a code can't be used twice
+	TOTPLastStep int64 `gorm:"column:totp_last_step" json:"-"`
+	InvitedBy    uint  `gorm:"column:invited_by" json:"invited_by"`
+}
+
+// AdminInvite lets the invited email create an admin account, the token is only kept as a hash
+type AdminInvite struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Email      string     `gorm:"column:email;size:191" json:"email"`
+	Privilege  string     `gorm:"column:privilege;size:32" json:"privilege"`
+	TokenHash  string     `gorm:"column:token_hash;size:64;uniqueIndex:idx_admin_invites_token_hash" json:"-"`
+	InvitedBy  uint       `gorm:"column:invited_by" json:"invited_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `gorm:"column:expires_at" json:"expires_at"`
+	AcceptedAt *time.Time `gorm:"column:accepted_at" json:"accepted_at,omitempty"`
+}
+
+// AdminRecoveryCode is a one time code that signs an admin in without the authenticator app
+type AdminRecoveryCode struct {
+	ID        uint   `gorm:"primaryKey"`
+	AdminID   uint   `gorm:"column:admin_id;index:idx_admin_recovery_codes_admin_id"`
+	CodeHash  string `gorm:"column:code_hash;size:64"`
+	CreatedAt time.Time
+	UsedAt    *time.Time `gorm:"column:used_at"`
 }
 
 type User struct {
 	gorm.Model
-	ID             uint    `validate:"required"`
-	Name           string  `gorm:"column:name;type:varchar(255)" validate:"required" json:"name"`
-	Email          string  `gorm:"column:email;type:varchar(255);unique_index" validate:"email" json:"email"`
-	PhoneNumber    string  `gorm:"column:phone_number;type:varchar(255);unique_index" json:"phone_number"`
-	Picture        string  `gorm:"column:picture;type:text" json:"picture"`
-	ReferralCode   string  `gorm:"column:referral_code" json:"referral_code"`
-	WalletAmount   float64 `gorm:"column:wallet_amount;type:double" json:"wallet_amount"`
-	LoginMethod    string  `gorm:"column:login_method;type:varchar(255)" validate:"required" json:"login_method"`
-	Blocked        bool    `gorm:"column:blocked;type:bool" json:"blocked"`
-	Salt           string  `gorm:"column:salt;type:varchar(255)" validate:"required" json:"salt"`
-	HashedPassword string  `gorm:"column:hashed_password;type:varchar(255)" validate:"required,min=8" json:"hashed_password"`
+	ID             uint         `validate:"required"`
+	Name           string       `gorm:"column:name;type:varchar(255)" validate:"required" json:"name"`
+	Email          string       `gorm:"column:email;type:varchar(255);unique_index" validate:"email" json:"email"`
+	PhoneNumber    string       `gorm:"column:phone_number;type:varchar(255);unique_index" json:"phone_number"`
+	Picture        string       `gorm:"column:picture;type:text" json:"picture"`
+	ReferralCode   string       `gorm:"column:referral_code" json:"referral_code"`
+	WalletAmount   money.Amount `gorm:"column:wallet_amount;check:chk_users_wallet_amount,wallet_amount >= 0" json:"wallet_amount"`
+	LoginMethod    string       `gorm:"column:login_method;type:varchar(255)" validate:"required" json:"login_method"`
+	Blocked        bool         `gorm:"column:blocked;type:bool" json:"blocked"`
+	Salt           string       `gorm:"column:salt;type:varchar(255)" validate:"required" json:"salt"`
+	HashedPassword string       `gorm:"column:hashed_password;type:varchar(255)" validate:"required,min=8" json:"hashed_password"`
 }
 
 type UserReferralHistory struct {
@@ -68,20 +127,21 @@ type Category struct {
 type Product struct {
 	gorm.Model
 	ID              uint
-	RestaurantID    uint    `gorm:"foreignKey:RestaurantID" validate:"required" json:"restaurant_id"`
-	CategoryID      uint    `gorm:"foreignKey:CategoryID" validate:"required" json:"category_id"`
-	Name            string  `validate:"required" json:"name"`
-	Description     string  `gorm:"column:description" validate:"required" json:"description"`
-	ImageURL        string  `gorm:"column:image_url" validate:"required" json:"image_url"`
-	Price           float64 `validate:"required,number" json:"price"`
-	PreparationTime float64 `gorm:"column:preparation_time" validate:"required,number" json:"preparation_time"` //in mins
-	MaxStock        uint    `validate:"required,number" json:"max_stock"`
-	OfferAmount     float64 `gorm:"column:offer_amount" json:"offer_amount"`
-	StockLeft       uint    `validate:"required,number" json:"stock_left"`
-	RatingSum       float64 `gorm:"column:rating_sum" json:"rating_sum"`
-	RatingCount     uint    `gorm:"column:rating_count" json:"rating_count"`
-	AverageRating   float64 `gorm:"column:average_rating" json:"average_rating"`
-	Veg             string  `validate:"required" json:"veg" gorm:"column:veg"`
+	RestaurantID    uint         `gorm:"foreignKey:RestaurantID" validate:"required" json:"restaurant_id"`
+	CategoryID      uint         `gorm:"foreignKey:CategoryID" validate:"required" json:"category_id"`
+	Name            string       `validate:"required" json:"name"`
+	Description     string       `gorm:"column:description" validate:"required" json:"description"`
+	ImageURL        string       `gorm:"column:image_url" validate:"required" json:"image_url"`
+	Price           money.Amount `validate:"required,number" json:"price"`
+	PreparationTime float64      `gorm:"column:preparation_time" validate:"required,number" json:"preparation_time"` //in mins
+	MaxStock        uint         `validate:"required,number" json:"max_stock"`
+	OfferAmount     money.Amount `gorm:"column:offer_amount" json:"offer_amount"`
+	StockLeft       uint         `validate:"required,number" json:"stock_left"`
+	Reserved        uint         `gorm:"column:reserved;not null;default:0" json:"-"` //part of StockLeft held for online orders waiting for their payment
+	RatingSum       float64      `gorm:"column:rating_sum" json:"rating_sum"`
+	RatingCount     uint         `gorm:"column:rating_count" json:"rating_count"`
+	AverageRating   float64      `gorm:"column:average_rating" json:"average_rating"`
+	Veg             string       `validate:"required" json:"veg" gorm:"column:veg"`
 }
 
 type Restaurant struct {
@@ -91,11 +151,11 @@ type Restaurant struct {
 	Description        string `gorm:"column:description" validate:"required" json:"description"`
 	Address            string
 	Email              string
-	PhoneNumber        string  `gorm:"column:phone_number" validate:"required" json:"phone_number"`
-	WalletAmount       float64 `gorm:"column:wallet_amount;type:double" json:"wallet_amount"`
-	ImageURL           string  `gorm:"column:image_url" validate:"required" json:"image_url"`
-	CertificateURL     string  `gorm:"column:certificate_url" validate:"required" json:"certificate_url"`
-	VerificationStatus string  `gorm:"column:verification_status"`
+	PhoneNumber        string       `gorm:"column:phone_number" validate:"required" json:"phone_number"`
+	WalletAmount       money.Amount `gorm:"column:wallet_amount;check:chk_restaurants_wallet_amount,wallet_amount >= 0" json:"wallet_amount"`
+	ImageURL           string       `gorm:"column:image_url" validate:"required" json:"image_url"`
+	CertificateURL     string       `gorm:"column:certificate_url" validate:"required" json:"certificate_url"`
+	VerificationStatus string       `gorm:"column:verification_status"`
 	Blocked            bool
 	Salt               string
 	HashedPassword     string `gorm:"column:hashed_password"`
@@ -127,45 +187,132 @@ type CartItems struct {
 }
 
 type Order struct {
-	OrderID              string    `validate:"required" json:"order_id"`
-	UserID               uint      `validate:"required,number" json:"user_id"`
-	RestaurantID         uint      `validate:"required,number" json:"restaurant_id"`
-	AddressID            uint      `validate:"required,number" json:"address_id"`
-	ItemCount            uint      `json:"item_count"`
-	CouponCode           string    `json:"coupon_code"`
-	CouponDiscountAmount float64   `validate:"required,number" json:"coupon_discount_amount"`
-	ProductOfferAmount   float64   `json:"product_offer_amount"`
-	TotalAmount          float64   `validate:"required,number" json:"total_amount"`
-	FinalAmount          float64   `validate:"required,number" json:"final_amount"`
-	PaymentMethod        string    `validate:"required" json:"payment_method" gorm:"column:payment_method"`
-	PaymentStatus        string    `validate:"required" json:"payment_status" gorm:"column:payment_status"`
-	OrderedAt            time.Time `gorm:"autoCreateTime" json:"ordered_at"`
+	OrderID              string       `validate:"required" json:"order_id"`
+	UserID               uint         `validate:"required,number" json:"user_id"`
+	RestaurantID         uint         `validate:"required,number" json:"restaurant_id"`
+	AddressID            uint         `validate:"required,number" json:"address_id"`
+	ItemCount            uint         `json:"item_count"`
+	CouponCode           string       `json:"coupon_code"`
+	CouponDiscountAmount money.Amount `validate:"required,number" json:"coupon_discount_amount"`
+	ProductOfferAmount   money.Amount `json:"product_offer_amount"`
+	TotalAmount          money.Amount `validate:"required,number" json:"total_amount"`
+	FinalAmount          money.Amount `validate:"required,number" json:"final_amount"`
+	PaymentMethod        string       `validate:"required" json:"payment_method" gorm:"column:payment_method"`
+	PaymentStatus        string       `validate:"required" json:"payment_status" gorm:"column:payment_status"`
+	OrderedAt            time.Time    `gorm:"autoCreateTime" json:"ordered_at"`
 }
 type OrderItem struct {
-	OrderID            string  `validate:"required" csv:"OrderID" json:"order_id"`
-	UserID             uint    `validate:"required,number" json:"user_id" csv:"UserID"`
-	RestaurantID       uint    `validate:"required,number" json:"restaurant_id" csv:"RestaurantID"`
-	ProductID          uint    `validate:"required,number" json:"product_id" csv:"ProductID"`
-	Quantity           uint    `validate:"required,number" json:"quantity" csv:"Quantity"`
-	Amount             float64 `validate:"required,number" json:"amount" csv:"Amount"`
-	ProductOfferAmount float64 `json:"product_offer_amount" csv:"ProductOfferAmount"`
-	AfterDeduction     float64 `gorm:"column:after_deduction" json:"after_deduction" csv:"AfterDeduction"`
-	CookingRequest     string  `csv:"CookingRequest" json:"cooking_request"`
-	OrderStatus        string  `json:"order_status" gorm:"column:order_status" csv:"OrderStatus"`
-	OrderReview        string  `csv:"OrderReview" json:"order_review"`
-	OrderRating        float64 `csv:"OrderRating" json:"order_rating"`
+	OrderID            string       `validate:"required" csv:"OrderID" json:"order_id"`
+	UserID             uint         `validate:"required,number" json:"user_id" csv:"UserID"`
+	RestaurantID       uint         `validate:"required,number" json:"restaurant_id" csv:"RestaurantID"`
+	ProductID          uint         `validate:"required,number" json:"product_id" csv:"ProductID"`
+	Quantity           uint         `validate:"required,number" json:"quantity" csv:"Quantity"`
+	Amount             money.Amount `validate:"required,number" json:"amount" csv:"Amount"`
+	ProductOfferAmount money.Amount `json:"product_offer_amount" csv:"ProductOfferAmount"`
+	AfterDeduction     money.Amount `gorm:"column:after_deduction" json:"after_deduction" csv:"AfterDeduction"`
+	CookingRequest     string       `csv:"CookingRequest" json:"cooking_request"`
+	OrderStatus        string       `json:"order_status" gorm:"column:order_status" csv:"OrderStatus"`
+	OrderReview        string       `csv:"OrderReview" json:"order_review"`
+	OrderRating        float64      `csv:"OrderRating" json:"order_rating"`
 }
 
 type Payment struct {
-	OrderID           string `validate:"required" json:"order_id"`
+	OrderID           string `validate:"required" json:"order_id" gorm:"column:order_id;size:191;index:idx_payments_order_id"`
 	WalletPaymentID   string `json:"wallet_payment_id" gorm:"column:wallet_payment_id"`
-	StripeSessionID   string `json:"stripe_session_id" column:"stripe_session_id"`
-	StripePaymentID   string `json:"stripe_payment_id" column:"stripe_payment_id"`
-	RazorpayOrderID   string `validate:"required" json:"razorpay_order_id" gorm:"column:razorpay_order_id"`
+	StripeSessionID   string `json:"stripe_session_id" gorm:"column:stripe_session_id;size:191;index:idx_payments_stripe_session_id"`
+	StripePaymentID   string `json:"stripe_payment_id" gorm:"column:stripe_payment_id"`
+	RazorpayOrderID   string `validate:"required" json:"razorpay_order_id" gorm:"column:razorpay_order_id;size:191;index:idx_payments_razorpay_order_id"`
 	RazorpayPaymentID string `validate:"required" json:"razorpay_payment_id" gorm:"column:razorpay_payment_id"`
 	RazorpaySignature string `validate:"required" json:"razorpay_signature" gorm:"column:razorpay_signature"`
-	PaymentGateway    string `json:"payment_gateway" gorm:"payment_gateway"`
+	PaymentGateway    string `json:"payment_gateway" gorm:"column:payment_gateway"`
 	PaymentStatus     string `validate:"required" json:"payment_status" gorm:"column:payment_status"`
+	// GatewayReference is the checkout at the gateway (razorpay order, stripe session, wallet
+	// payment) and GatewayPaymentID the payment made in it, whatever the gateway
+	GatewayReference string       `json:"gateway_reference" gorm:"column:gateway_reference;size:191;index:idx_payments_gateway_reference"`
+	GatewayPaymentID string       `json:"gateway_payment_id" gorm:"column:gateway_payment_id"`
+	Amount           money.Amount `json:"amount" gorm:"column:amount"`
+	// RefundedAmount is how much of the payment the gateway refunded so far
+	RefundedAmount money.Amount `json:"refunded_amount" gorm:"column:refunded_amount"`
+}
+
+// WebhookEvent remembers the provider events already handled, providers deliver an event
+// again until they get a 2xx, so the same event can arrive more than once
+type WebhookEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Provider  string    `gorm:"column:provider;size:32;uniqueIndex:idx_webhook_events_provider_event" json:"provider"`
+	EventID   string    `gorm:"column:event_id;size:191;uniqueIndex:idx_webhook_events_provider_event" json:"event_id"`
+	Type      string    `gorm:"column:type" json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReconciliationRun is one pass of the payment reconciliation over the open payments and the
+// unpaid online orders, the discrepancies it found are kept for finance
+type ReconciliationRun struct {
+	ID      uint   `gorm:"primaryKey" json:"id"`
+	Trigger string `gorm:"column:triggered_by;size:16" json:"trigger"`
+	// Checked payments were looked up at their gateway, Fixed ones were brought in line with
+	// it and Expired orders were left unpaid past the ttl
+	Checked       int        `gorm:"column:checked" json:"checked"`
+	Fixed         int        `gorm:"column:fixed" json:"fixed"`
+	Expired       int        `gorm:"column:expired" json:"expired"`
+	Discrepancies int        `gorm:"column:discrepancies" json:"discrepancies"`
+	StartedAt     time.Time  `gorm:"column:started_at;index:idx_reconciliation_runs_started_at" json:"started_at"`
+	FinishedAt    *time.Time `gorm:"column:finished_at" json:"finished_at,omitempty"`
+}
+
+// PaymentDiscrepancy is a payment or order whose state differed from the gateway's, with what
+// the reconciliation did about it
+type PaymentDiscrepancy struct {
+	ID            uint         `gorm:"primaryKey" json:"id" csv:"ID"`
+	RunID         uint         `gorm:"column:run_id;index:idx_payment_discrepancies_run_id" json:"run_id" csv:"RunID"`
+	OrderID       string       `gorm:"column:order_id;size:191" json:"order_id" csv:"OrderID"`
+	Gateway       string       `gorm:"column:gateway;size:32" json:"gateway" csv:"Gateway"`
+	Reference     string       `gorm:"column:reference" json:"reference" csv:"Reference"`
+	PaymentID     string       `gorm:"column:payment_id" json:"payment_id" csv:"PaymentID"`
+	Amount        money.Amount `gorm:"column:amount" json:"amount" csv:"Amount"`
+	OurStatus     string       `gorm:"column:our_status" json:"our_status" csv:"OurStatus"`
+	GatewayStatus string       `gorm:"column:gateway_status" json:"gateway_status" csv:"GatewayStatus"`
+	Action        string       `gorm:"column:action;size:16" json:"action" csv:"Action"`
+	Note          string       `gorm:"column:note" json:"note" csv:"Note"`
+	CreatedAt     time.Time    `json:"created_at" csv:"CreatedAt"`
+}
+
+// Refund gives the amount of a cancelled order item back, to the user's wallet right away or
+// through the gateway the order was paid with. gateway refunds are pending until the gateway
+// processes them, a failed one is credited to the wallet instead
+type Refund struct {
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	OrderID     string       `gorm:"column:order_id;size:191;index:idx_refunds_order_id" json:"order_id"`
+	ProductID   uint         `gorm:"column:product_id" json:"product_id"`
+	UserID      uint         `gorm:"column:user_id" json:"user_id"`
+	Amount      money.Amount `gorm:"column:amount" json:"amount"`
+	Destination string       `gorm:"column:destination;size:16" json:"destination"`
+	// the payment refunded and the gateway's id of the refund, for refunds to the source
+	PaymentGateway   string     `gorm:"column:payment_gateway;size:32;index:idx_refunds_gateway_refund_id,priority:1" json:"payment_gateway,omitempty"`
+	GatewayReference string     `gorm:"column:gateway_reference" json:"gateway_reference,omitempty"`
+	GatewayRefundID  string     `gorm:"column:gateway_refund_id;size:191;index:idx_refunds_gateway_refund_id,priority:2" json:"gateway_refund_id,omitempty"`
+	Status           string     `gorm:"column:status;size:16" json:"status"`
+	FailureReason    string     `gorm:"column:failure_reason" json:"failure_reason,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	ProcessedAt      *time.Time `gorm:"column:processed_at" json:"processed_at,omitempty"`
+}
+
+// IdempotencyKey is the first response to a request sent with an Idempotency-Key, repeating
+// the request with the key replays the response instead of doing it again
+type IdempotencyKey struct {
+	ID uint `gorm:"primaryKey"`
+	// Owner is the account the key belongs to, keys of different accounts never collide
+	Owner string `gorm:"column:owner;size:64;uniqueIndex:idx_idempotency_keys_owner_key"`
+	Key   string `gorm:"column:idempotency_key;size:128;uniqueIndex:idx_idempotency_keys_owner_key"`
+	// Fingerprint is a hash of the method, url and body, a key can't be reused for another request
+	Fingerprint  string    `gorm:"column:fingerprint;size:64"`
+	Status       string    `gorm:"column:status;size:16"`
+	ResponseCode int       `gorm:"column:response_code"`
+	ContentType  string    `gorm:"column:content_type"`
+	ResponseBody []byte    `gorm:"column:response_body"`
+	CreatedAt    time.Time `gorm:"column:created_at;index:idx_idempotency_keys_created_at"`
+	UpdatedAt    time.Time
 }
 
 type PasswordReset struct {
@@ -174,15 +321,15 @@ type PasswordReset struct {
 	Role       string `validate:"required"`
 	ResetToken string `gorm:"column:reset_token" json:"reset_token"`
 	Active     string `json:"active"`
-	ExpiryTime uint   `gorm:"expiry_time" json:"expiry_time"`
+	ExpiryTime uint   `gorm:"column:expiry_time" json:"expiry_time"`
 }
 
 type CouponInventory struct {
-	CouponCode    string  `validate:"required" json:"coupon_code" gorm:"primary_key"`
-	Expiry        uint    `validate:"required" json:"expiry"`
-	Percentage    uint    `validate:"required" json:"percentage"`
-	MaximumUsage  uint    `validate:"required" json:"maximum_usage"`
-	MinimumAmount float64 `validate:"required" json:"minimum_amount"`
+	CouponCode    string       `validate:"required" json:"coupon_code" gorm:"primary_key"`
+	Expiry        uint         `validate:"required" json:"expiry"`
+	Percentage    uint         `validate:"required" json:"percentage"`
+	MaximumUsage  uint         `validate:"required" json:"maximum_usage"`
+	MinimumAmount money.Amount `validate:"required" json:"minimum_amount"`
 }
 
 type CouponUsage struct {
@@ -193,24 +340,24 @@ type CouponUsage struct {
 }
 
 type UserWalletHistory struct {
-	TransactionTime time.Time `gorm:"autoCreateTime" json:"transaction_time"`
-	WalletPaymentID string    `gorm:"column:wallet_payment_id" json:"wallet_payment_id"`
-	UserID          uint      `gorm:"column:user_id" json:"user_id"`
-	Type            string    `gorm:"column:type" json:"type"` //incoming //outgoing
-	OrderID         string    `gorm:"column:order_id" json:"order_id"`
-	Amount          float64   `gorm:"column:amount" json:"amount"`
-	CurrentBalance  float64   `gorm:"column:current_balance" json:"current_balance"`
-	Reason          string    `gorm:"column:reason" json:"reason"`
+	TransactionTime time.Time    `gorm:"autoCreateTime" json:"transaction_time"`
+	WalletPaymentID string       `gorm:"column:wallet_payment_id" json:"wallet_payment_id"`
+	UserID          uint         `gorm:"column:user_id" json:"user_id"`
+	Type            string       `gorm:"column:type" json:"type"` //incoming //outgoing
+	OrderID         string       `gorm:"column:order_id" json:"order_id"`
+	Amount          money.Amount `gorm:"column:amount" json:"amount"`
+	CurrentBalance  money.Amount `gorm:"column:current_balance" json:"current_balance"`
+	Reason          string       `gorm:"column:reason" json:"reason"`
 }
 
 type RestaurantWalletHistory struct {
-	TransactionTime time.Time `gorm:"autoCreateTime" json:"transaction_time"`
-	Type            string    `gorm:"column:type" json:"type"` //incoming //outgoing
-	OrderID         string    `gorm:"column:order_id" json:"order_id"`
-	RestaurantID    uint      `gorm:"column:restaurant_id" json:"restaurant_id"`
-	Amount          float64   `gorm:"column:amount" json:"amount"`
-	CurrentBalance  float64   `gorm:"column:current_balance" json:"current_balance"`
-	Reason          string    `gorm:"column:reason" json:"reason"`
+	TransactionTime time.Time    `gorm:"autoCreateTime" json:"transaction_time"`
+	Type            string       `gorm:"column:type" json:"type"` //incoming //outgoing
+	OrderID         string       `gorm:"column:order_id" json:"order_id"`
+	RestaurantID    uint         `gorm:"column:restaurant_id" json:"restaurant_id"`
+	Amount          money.Amount `gorm:"column:amount" json:"amount"`
+	CurrentBalance  money.Amount `gorm:"column:current_balance" json:"current_balance"`
+	Reason          string       `gorm:"column:reason" json:"reason"`
 }
 
 type DeliveryVerification struct {
@@ -219,3 +366,123 @@ type DeliveryVerification struct {
 	OTP        uint   `gorm:"column:otp" json:"otp"`
 	LastSentAT uint   `gorm:"column:last_sent_at" json:"last_sent_at"`
 }
+
+// LedgerAccount is an account of the wallet ledger. wallets belong to a user or a
+// restaurant, the platform accounts money comes from or goes to have owner 0
+type LedgerAccount struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Type      string    `gorm:"column:type;size:64;uniqueIndex:idx_ledger_accounts_type_owner" json:"type"`
+	OwnerID   uint      `gorm:"column:owner_id;uniqueIndex:idx_ledger_accounts_type_owner" json:"owner_id"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// JournalEntry is one movement of money, its lines debit and credit the same total
+type JournalEntry struct {
+	ID        uint          `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time     `gorm:"autoCreateTime" json:"created_at"`
+	Reason    string        `gorm:"column:reason" json:"reason"`
+	OrderID   string        `gorm:"column:order_id;size:191;index:idx_journal_entries_order_id" json:"order_id"`
+	Lines     []JournalLine `gorm:"foreignKey:EntryID" json:"lines"`
+}
+
+// JournalLine takes money out of (debit) or puts money into (credit) one account
+type JournalLine struct {
+	ID        uint         `gorm:"primaryKey" json:"id"`
+	EntryID   uint         `gorm:"column:entry_id;index:idx_journal_lines_entry_id" json:"entry_id"`
+	AccountID uint         `gorm:"column:account_id;index:idx_journal_lines_account_id" json:"account_id"`
+	Debit     money.Amount `gorm:"column:debit" json:"debit"`
+	Credit    money.Amount `gorm:"column:credit" json:"credit"`
+}
+
+// Session is a signed in device of an account. its refresh token is only kept as a hash
+// and is replaced on every refresh, the previous hash is kept to notice a stolen token being reused
+type Session struct {
+	ID                  string     `gorm:"primaryKey;size:36" json:"id"`
+	Email               string     `gorm:"column:email;size:191;index:idx_sessions_account" json:"-"`
+	Role                string     `gorm:"column:role;size:32;index:idx_sessions_account" json:"-"`
+	RefreshTokenHash    string     `gorm:"column:refresh_token_hash;size:64;uniqueIndex:idx_sessions_refresh_token_hash" json:"-"`
+	PreviousRefreshHash string     `gorm:"column:previous_refresh_hash;size:64;index:idx_sessions_previous_refresh_hash" json:"-"`
+	AccessTokenID       string     `gorm:"column:access_token_id;size:36" json:"-"`
+	AccessExpiresAt     time.Time  `gorm:"column:access_expires_at" json:"-"`
+	UserAgent           string     `gorm:"column:user_agent" json:"user_agent"`
+	IPAddress           string     `gorm:"column:ip_address" json:"ip_address"`
+	CreatedAt           time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	LastUsedAt          time.Time  `gorm:"column:last_used_at" json:"last_used_at"`
+	ExpiresAt           time.Time  `gorm:"column:expires_at" json:"expires_at"`
+	RevokedAt           *time.Time `gorm:"column:revoked_at" json:"revoked_at,omitempty"`
+}
+
+// RevokedToken is an access token that stopped being valid before it expired,
+// the row can be dropped once ExpiresAt has passed
+type RevokedToken struct {
+	JTI       string    `gorm:"column:jti;primaryKey;size:36"`
+	ExpiresAt time.Time `gorm:"column:expires_at;index:idx_revoked_tokens_expires_at"`
+}
+
+// OutboxMessage is an email written in the same transaction as the change it is about and
+// delivered by the outbox worker afterwards, so a slow or failing mail server can't fail
+// the request. failed deliveries are retried with backoff until they are dead lettered
+type OutboxMessage struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Recipient     string     `gorm:"column:recipient" json:"recipient"`
+	Template      string     `gorm:"column:template;size:64" json:"template"`
+	Subject       string     `gorm:"column:subject" json:"subject"`
+	HTML          string     `gorm:"column:html;type:text" json:"-"`
+	Text          string     `gorm:"column:text;type:text" json:"-"`
+	Status        string     `gorm:"column:status;size:16;index:idx_outbox_messages_due,priority:1" json:"status"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at;index:idx_outbox_messages_due,priority:2" json:"next_attempt_at"`
+	Attempts      int        `gorm:"column:attempts" json:"attempts"`
+	LastError     string     `gorm:"column:last_error;type:text" json:"last_error,omitempty"`
+	LockedUntil   *time.Time `gorm:"column:locked_until" json:"-"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	SentAt        *time.Time `gorm:"column:sent_at" json:"sent_at,omitempty"`
+}
+
+// OrderStatusHistory is one step of an order item through the order state machine, who
+// moved it and why. the first step of every item comes from no status at all
+type OrderStatusHistory struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	OrderID    string    `gorm:"column:order_id;size:191;index:idx_order_status_histories_order_id" json:"order_id"`
+	ProductID  uint      `gorm:"column:product_id" json:"product_id"`
+	FromStatus string    `gorm:"column:from_status;size:32" json:"from_status"`
+	ToStatus   string    `gorm:"column:to_status;size:32" json:"to_status"`
+	Actor      string    `gorm:"column:actor;size:16" json:"actor"`
+	ActorID    uint      `gorm:"column:actor_id" json:"actor_id,omitempty"`
+	Reason     string    `gorm:"column:reason" json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// KitchenEvent tells a restaurant's kitchen about an order item to cook or a change to one. Seq
+// numbers the restaurant's events in the order they were committed, it is the cursor a kitchen
+// reconnects with to replay the events it missed
+type KitchenEvent struct {
+	ID             uint      `gorm:"primaryKey;index:idx_kitchen_events_restaurant,priority:2" json:"id"`
+	RestaurantID   uint      `gorm:"column:restaurant_id;index:idx_kitchen_events_restaurant,priority:1;uniqueIndex:idx_kitchen_events_restaurant_seq,priority:1" json:"restaurant_id"`
+	Seq            uint      `gorm:"column:seq;not null;default:0;uniqueIndex:idx_kitchen_events_restaurant_seq,priority:2" json:"seq"`
+	OrderID        string    `gorm:"column:order_id;size:191" json:"order_id"`
+	ProductID      uint      `gorm:"column:product_id" json:"product_id"`
+	EventType      string    `gorm:"column:event_type;size:32" json:"type"`
+	Quantity       uint      `gorm:"column:quantity" json:"quantity"`
+	CookingRequest string    `gorm:"column:cooking_request" json:"cooking_request"`
+	Reason         string    `gorm:"column:reason" json:"reason,omitempty"`
+	CreatedAt      time.Time `gorm:"index:idx_kitchen_events_created_at" json:"created_at"`
+}
+
+// KitchenSequence is the last Seq given to a kitchen event of the restaurant, the row is locked
+// by the transaction adding an event until it commits so the events commit in their Seq order
+type KitchenSequence struct {
+	RestaurantID uint `gorm:"primaryKey;autoIncrement:false"`
+	LastSeq      uint `gorm:"column:last_seq;not null;default:0"`
+}
+
+// StockReservation holds the quantity of a product for an online order until it is paid or the
+// hold expires, the products' Reserved is the sum of their holds
+type StockReservation struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	OrderID   string    `gorm:"column:order_id;size:191;index:idx_stock_reservations_order_id" json:"order_id"`
+	ProductID uint      `gorm:"column:product_id" json:"product_id"`
+	Quantity  uint      `gorm:"column:quantity" json:"quantity"`
+	ExpiresAt time.Time `gorm:"column:expires_at;index:idx_stock_reservations_expires_at" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -1,5 +1,7 @@
 package model
 
+import "foodbuddy/internal/money"
+
 type EmailSignupRequest struct {
 	Name            string `validate:"required" json:"name"`
 	Email           string `validate:"required,email" json:"email"`
@@ -42,7 +44,33 @@ type RestaurantLoginRequest struct {
 }
 
 type AdminLoginRequest struct {
-	Email string `json:"email" validate:"required,email"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+	// Code is the current code of the authenticator app, RecoveryCode can be sent instead
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type AdminInviteRequest struct {
+	Email     string `json:"email" validate:"required,email"`
+	Privilege string `json:"privilege" validate:"required,oneof=SUPER STANDARD"`
+}
+
+type AcceptAdminInviteRequest struct {
+	Token           string `json:"token" validate:"required"`
+	Name            string `json:"name" validate:"required"`
+	Password        string `json:"password" validate:"required"`
+	ConfirmPassword string `json:"confirm_password" validate:"required"`
+}
+
+type ConfirmAdminTOTPRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+type RegenerateRecoveryCodesRequest struct {
+	Code string `json:"code" validate:"required"`
 }
 
 type AddToCartReq struct {
@@ -93,9 +121,26 @@ type UpdateOrderStatusForRestaurant struct {
 	ProductID uint   `json:"product_id"`
 }
 
+// OrderDecision accepts or rejects the items of an order waiting for the restaurant, all of
+// them or only the product's. the reason is shown to the user and required to reject
+type OrderDecision struct {
+	OrderID   string `json:"order_id"`
+	ProductID uint   `json:"product_id"`
+	Reason    string `json:"reason"`
+}
+
+// OrderCookingRequest changes the cooking request of an ordered item the kitchen hasn't started on
+type OrderCookingRequest struct {
+	OrderID        string `json:"order_id"`
+	ProductID      uint   `json:"product_id"`
+	CookingRequest string `json:"cooking_request"`
+}
+
 type CancelOrderedProduct struct {
 	OrderID   string `json:"order_id"`
 	ProductId uint   `json:"product_id"`
+	// RefundTo is WALLET, the default, or SOURCE to refund through the gateway the order was paid with
+	RefundTo string `json:"refund_to"`
 }
 
 type IncrementStock struct {
@@ -115,6 +160,10 @@ type Step2PasswordReset struct {
 	ConfirmPassword string `form:"password2" binding:"required" json:"password2"`
 }
 
+type RefreshSessionRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 type UserReviewonOrderItem struct {
 	OrderID    string `validate:"required" json:"order_id"`
 	ProductID  uint   `validate:"required" json:"product_id"`
@@ -128,11 +177,11 @@ type UserRatingOrderItem struct {
 }
 
 type CouponInventoryRequest struct {
-	CouponCode    string `validate:"required" json:"coupon_code"`
-	Expiry        uint   `validate:"required" json:"expiry"`
-	Percentage    uint   `validate:"required" json:"percentage"`
-	MaximumUsage  uint   `validate:"required" json:"maximum_usage"`
-	MinimumAmount uint   `validate:"required" json:"minimum_amount"`
+	CouponCode    string       `validate:"required" json:"coupon_code"`
+	Expiry        uint         `validate:"required" json:"expiry"`
+	Percentage    uint         `validate:"required" json:"percentage"`
+	MaximumUsage  uint         `validate:"required" json:"maximum_usage"`
+	MinimumAmount money.Amount `validate:"required" json:"minimum_amount"`
 }
 
 type ApplyCouponOnOrderRequest struct {
@@ -165,32 +214,32 @@ type EditRestaurantRequest struct {
 }
 
 type AddProductRequest struct {
-	CategoryID  uint    `validate:"required,number" json:"category_id"`
-	Name        string  `validate:"required" json:"name"`
-	Description string  `validate:"required" json:"description"`
-	ImageURL    string  `validate:"required" json:"image_url"`
-	Price       float64 `validate:"required,number" json:"price"`
-	OfferAmount float64 `json:"offer_amount"`
-	MaxStock    uint    `validate:"required,number" json:"max_stock"`
-	StockLeft   uint    `validate:"required,number" json:"stock_left"`
-	Veg         string  `validate:"required" json:"veg"`
+	CategoryID  uint         `validate:"required,number" json:"category_id"`
+	Name        string       `validate:"required" json:"name"`
+	Description string       `validate:"required" json:"description"`
+	ImageURL    string       `validate:"required" json:"image_url"`
+	Price       money.Amount `validate:"required,number" json:"price"`
+	OfferAmount money.Amount `json:"offer_amount"`
+	MaxStock    uint         `validate:"required,number" json:"max_stock"`
+	StockLeft   uint         `validate:"required,number" json:"stock_left"`
+	Veg         string       `validate:"required" json:"veg"`
 }
 
 type EditProductRequest struct {
-	ProductID   uint    `validate:"required,number" json:"product_id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	ImageURL    string  `json:"image_url"`
-	Price       float64 `json:"price"`
-	OfferAmount float64 `json:"offer_amount"`
-	MaxStock    uint    `json:"max_stock"`
-	StockLeft   uint    `json:"stock_left"`
-	Veg         string  `json:"veg"`
+	ProductID   uint         `validate:"required,number" json:"product_id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	ImageURL    string       `json:"image_url"`
+	Price       money.Amount `json:"price"`
+	OfferAmount money.Amount `json:"offer_amount"`
+	MaxStock    uint         `json:"max_stock"`
+	StockLeft   uint         `json:"stock_left"`
+	Veg         string       `json:"veg"`
 }
 
 type AddOfferRequest struct {
-	ProductID   uint    `json:"product_id" binding:"required"`
-	OfferAmount float64 `json:"offer_amount" binding:"required"`
+	ProductID   uint         `json:"product_id" binding:"required"`
+	OfferAmount money.Amount `json:"offer_amount" binding:"required"`
 }
 
 type RestaurantProfileUpdate struct {
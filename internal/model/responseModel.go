@@ -1,6 +1,9 @@
 package model
 
-import "time"
+import (
+	"foodbuddy/internal/money"
+	"time"
+)
 
 type GoogleResponse struct {
 	ID            string `json:"id"`
@@ -24,39 +27,40 @@ type ErrorResponse struct {
 }
 
 type ProductSales struct {
-	TotalAmount uint    `json:"total_product_sales_amount"`
-	TotalOrders uint    `json:"total_orders"`
-	AvgRating   float64 `json:"avg_rating"`
-	Quantity    uint    `json:"quantity"`
+	TotalAmount money.Amount `json:"total_product_sales_amount"`
+	TotalOrders uint         `json:"total_orders"`
+	AvgRating   float64      `json:"avg_rating"`
+	Quantity    uint         `json:"quantity"`
 }
 
 type BestProduct struct {
-	ProductID    uint    `json:"product_id"`
-	Name         string  `json:"name"`
-	CategoryName string  `json:"category_name"`
-	Description  string  `json:"description"`
-	ImageURL     string  `json:"image_url"`
-	Price        float64 `json:"price"`
-	Rating       float64 `json:"rating"`
-	TotalSales   uint    `json:"total_sales"`
+	ProductID    uint         `json:"product_id"`
+	Name         string       `json:"name"`
+	CategoryName string       `json:"category_name"`
+	Description  string       `json:"description"`
+	ImageURL     string       `json:"image_url"`
+	Price        money.Amount `json:"price"`
+	Rating       float64      `json:"rating"`
+	TotalSales   uint         `json:"total_sales"`
 }
 
 type ProductResponse struct {
-	ID             uint    `json:"product_id"`
-	RestaurantName string  `json:"restaurant_name"`
-	CategoryName   string  `json:"category_name"`
-	Name           string  `json:"product_name"`
-	Description    string  `json:"description"`
-	ImageURL       string  `json:"image_url"`
-	Price          float64 `json:"price"`
-	StockLeft      uint    `json:"stock_left"`
-	AverageRating  float64 `json:"average_rating"`
-	Veg            string  `json:"veg"`
+	ID             uint         `json:"product_id"`
+	RestaurantName string       `json:"restaurant_name"`
+	CategoryName   string       `json:"category_name"`
+	Name           string       `json:"product_name"`
+	Description    string       `json:"description"`
+	ImageURL       string       `json:"image_url"`
+	Price          money.Amount `json:"price"`
+	StockLeft      uint         `json:"stock_left"` //what isn't held for orders waiting for their payment
+	AverageRating  float64      `json:"average_rating"`
+	Veg            string       `json:"veg"`
 }
 
 type OrderCount struct {
 	TotalOrder         uint `json:"total_order"`
 	TotalProcessing    uint `json:"total_processing"`
+	TotalAccepted      uint `json:"total_accepted"`
 	TotalInitiated     uint `json:"total_initiated"`
 	TotalInPreparation uint `json:"total_in_preparation"`
 	TotalPrepared      uint `json:"total_prepared"`
@@ -65,17 +69,17 @@ type OrderCount struct {
 	TotalCancelled     uint `json:"total_cancelled"`
 }
 type AmountInformation struct {
-	TotalCouponDeduction       float64 `json:"total_coupon_deduction"`
-	TotalProductOfferDeduction float64 `json:"total_product_offer_deduction"`
-	TotalAmountBeforeDeduction float64 `json:"total_amount_before_deduction"`
-	TotalAmountAfterDeduction  float64 `json:"total_amount_after_deduction"`
+	TotalCouponDeduction       money.Amount `json:"total_coupon_deduction"`
+	TotalProductOfferDeduction money.Amount `json:"total_product_offer_deduction"`
+	TotalAmountBeforeDeduction money.Amount `json:"total_amount_before_deduction"`
+	TotalAmountAfterDeduction  money.Amount `json:"total_amount_after_deduction"`
 }
 
 type OrderSales struct {
-	TotalRevenue            float64 `json:"total_revenue"`
-	CouponDiscounts         float64 `json:"coupon_discounts"`
-	ProductOffers           float64 `json:"product_offers"`
-	TotalCancelOrderRefunds float64 `json:"total_cancelorder_refunds"`
+	TotalRevenue            money.Amount `json:"total_revenue"`
+	CouponDiscounts         money.Amount `json:"coupon_discounts"`
+	ProductOffers           money.Amount `json:"product_offers"`
+	TotalCancelOrderRefunds money.Amount `json:"total_cancelorder_refunds"`
 }
 
 type OverallOrderReport struct {
@@ -99,13 +103,36 @@ type RestaurantOverallSalesReport struct {
 }
 
 type BlockedUserResponse struct {
-	ID           uint    `json:"id"`
-	Name         string  `json:"name"`
-	Email        string  `json:"email"`
-	PhoneNumber  string  `json:"phone_number"`
-	Picture      string  `json:"picture"`
-	ReferralCode string  `json:"referral_code"`
-	WalletAmount float64 `json:"wallet_amount"`
-	LoginMethod  string  `json:"login_method"`
-	Blocked      bool    `json:"blocked"`
+	ID           uint         `json:"id"`
+	Name         string       `json:"name"`
+	Email        string       `json:"email"`
+	PhoneNumber  string       `json:"phone_number"`
+	Picture      string       `json:"picture"`
+	ReferralCode string       `json:"referral_code"`
+	WalletAmount money.Amount `json:"wallet_amount"`
+	LoginMethod  string       `json:"login_method"`
+	Blocked      bool         `json:"blocked"`
+}
+
+// LedgerCheck is the result of auditing the wallet ledger
+type LedgerCheck struct {
+	Balanced          bool                   `json:"balanced"`
+	TotalDebit        money.Amount           `json:"total_debit"`
+	TotalCredit       money.Amount           `json:"total_credit"`
+	UnbalancedEntries []LedgerEntryImbalance `json:"unbalanced_entries"`
+	WalletMismatches  []LedgerWalletMismatch `json:"wallet_mismatches"`
+}
+
+type LedgerEntryImbalance struct {
+	EntryID uint         `json:"entry_id"`
+	Debit   money.Amount `json:"debit"`
+	Credit  money.Amount `json:"credit"`
+}
+
+// LedgerWalletMismatch is a wallet whose stored balance differs from its ledger balance
+type LedgerWalletMismatch struct {
+	AccountType   string       `json:"account_type"`
+	OwnerID       uint         `json:"owner_id"`
+	LedgerBalance money.Amount `json:"ledger_balance"`
+	WalletBalance money.Amount `json:"wallet_balance"`
 }
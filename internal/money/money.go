@@ -0,0 +1,242 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Amount is a sum of money in paise, the smallest unit of the rupee. it is stored as an
+// integer so sums never drift, and it is written as rupees with two decimals in json and csv.
+//
+// rounding rules: converting from rupees, taking a percentage and dividing all round half
+// away from zero, and Allocate splits an amount without losing or creating a single paisa
+type Amount int64
+
+// Rupee is one rupee in paise
+const Rupee Amount = 100
+
+// Rupees returns a whole number of rupees as an Amount
+func Rupees(rupees int64) Amount {
+	return Amount(rupees) * Rupee
+}
+
+// FromRupees converts a rupee value to paise, rounding half away from zero
+func FromRupees(rupees float64) Amount {
+	return Amount(math.Round(rupees * 100))
+}
+
+// Paise returns the amount in paise
+func (a Amount) Paise() int64 {
+	return int64(a)
+}
+
+// Rupees returns the amount in rupees, only for display and for apis that take a float
+func (a Amount) Rupees() float64 {
+	return float64(a) / 100
+}
+
+// Times multiplies the amount by a quantity
+func (a Amount) Times(quantity uint) Amount {
+	return a * Amount(quantity)
+}
+
+// Percent returns percent percent of the amount, rounding half away from zero
+func (a Amount) Percent(percent uint) Amount {
+	return a.MulDiv(int64(percent), 100)
+}
+
+// MulDiv returns a*num/den, rounding half away from zero
+func (a Amount) MulDiv(num int64, den int64) Amount {
+	if den == 0 {
+		panic("money: division by zero")
+	}
+	negative := (a < 0) != (num < 0) != (den < 0)
+	hi, lo := bits.Mul64(abs(int64(a)), abs(num))
+	d := abs(den)
+	if hi >= d {
+		panic("money: overflow")
+	}
+	q, r := bits.Div64(hi, lo, d)
+	if r >= d-r {
+		q++
+	}
+	if negative {
+		return -Amount(q)
+	}
+	return Amount(q)
+}
+
+// Allocate splits the amount in proportion to weights using the largest remainder method.
+// every part is rounded down first and the paise left over go one each to the parts with
+// the largest remainders, earlier parts win ties. the parts always add up to the amount.
+// with no positive weight the whole amount goes to the first part
+func (a Amount) Allocate(weights []int64) []Amount {
+	parts := make([]Amount, len(weights))
+	if len(weights) == 0 {
+		return parts
+	}
+
+	var total int64
+	for _, w := range weights {
+		if w < 0 {
+			panic("money: negative allocation weight")
+		}
+		total += w
+	}
+	if total == 0 {
+		parts[0] = a
+		return parts
+	}
+
+	whole := abs(int64(a))
+	remainders := make([]uint64, len(weights))
+	var allocated uint64
+	for i, w := range weights {
+		hi, lo := bits.Mul64(whole, uint64(w))
+		q, r := bits.Div64(hi, lo, uint64(total))
+		parts[i] = Amount(q)
+		remainders[i] = r
+		allocated += q
+	}
+
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return remainders[order[i]] > remainders[order[j]]
+	})
+	for i := uint64(0); i < whole-allocated; i++ {
+		parts[order[i]]++
+	}
+
+	if a < 0 {
+		for i := range parts {
+			parts[i] = -parts[i]
+		}
+	}
+	return parts
+}
+
+// Sum adds up amounts
+func Sum(amounts ...Amount) Amount {
+	var sum Amount
+	for _, amount := range amounts {
+		sum += amount
+	}
+	return sum
+}
+
+// String formats the amount as rupees with two decimals, like 1234.50
+func (a Amount) String() string {
+	sign := ""
+	paise := abs(int64(a))
+	if a < 0 {
+		sign = "-"
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, paise/100, paise%100)
+}
+
+// Parse reads a rupee value like "12", "12.5" or "-0.05". more than two decimals are
+// rounded half away from zero
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, errors.New("money: empty amount")
+	}
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+	if strings.ContainsAny(s, "eE") {
+		// exponent notation is not worth parsing by hand, go through float
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("money: invalid amount %q", s)
+		}
+		if negative {
+			f = -f
+		}
+		return FromRupees(f), nil
+	}
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" && fraction == "" {
+		return 0, fmt.Errorf("money: invalid amount %q", s)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	rupees, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || rupees > math.MaxInt64/100-1 {
+		return 0, fmt.Errorf("money: invalid amount %q", s)
+	}
+	for _, c := range fraction {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("money: invalid amount %q", s)
+		}
+	}
+	fraction += "000"
+	paise, _ := strconv.ParseInt(fraction[:2], 10, 64)
+	if fraction[2] >= '5' {
+		paise++
+	}
+
+	amount := Amount(rupees*100 + paise)
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+// MarshalJSON writes the amount as a rupee number, like 1234.50
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON reads a rupee number or a string holding one
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	amount, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// MarshalText is used by csv exports
+func (a Amount) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *Amount) UnmarshalText(data []byte) error {
+	amount, err := Parse(string(data))
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+func abs(n int64) uint64 {
+	if n < 0 {
+		return uint64(-n)
+	}
+	return uint64(n)
+}
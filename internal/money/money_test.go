@@ -0,0 +1,131 @@
+package money
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMulDiv(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   Amount
+		num, den int64
+		want     Amount
+	}{
+		{"exact", 1000, 3, 4, 750},
+		{"rounds down below half", 1001, 1, 4, 250},
+		{"rounds half up", 1002, 1, 4, 251},
+		{"rounds above half up", 1003, 1, 4, 251},
+		{"negative amount rounds half away from zero", -1002, 1, 4, -251},
+		{"negative num rounds half away from zero", 1002, -1, 4, -251},
+		{"negative den rounds half away from zero", 1002, 1, -4, -251},
+		{"two negatives are positive", -1002, -1, 4, 251},
+		{"half a paisa", 1, 1, 2, 1},
+		{"less than half a paisa", 1, 1, 3, 0},
+		{"zero amount", 0, 7, 3, 0},
+		{"zero num", 999, 0, 3, 0},
+		{"percent at half", 50, 1, 100, 1},
+		{"intermediate product overflows int64", Amount(1) << 62, 12, 16, Amount(3) << 60},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.amount.MulDiv(tt.num, tt.den); got != tt.want {
+				t.Errorf("%d.MulDiv(%d, %d) = %d, want %d", tt.amount, tt.num, tt.den, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMulDivPanics(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   Amount
+		num, den int64
+	}{
+		{"division by zero", 100, 1, 0},
+		{"result overflows", Amount(1) << 62, 8, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d.MulDiv(%d, %d) didn't panic", tt.amount, tt.num, tt.den)
+				}
+			}()
+			tt.amount.MulDiv(tt.num, tt.den)
+		})
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  Amount
+		weights []int64
+		want    []Amount
+	}{
+		{"even split", 900, []int64{1, 1, 1}, []Amount{300, 300, 300}},
+		{"leftover paisa goes to the first tie", 100, []int64{1, 1, 1}, []Amount{34, 33, 33}},
+		{"leftover paise go to the earlier ties", 200, []int64{1, 1, 1}, []Amount{67, 67, 66}},
+		{"leftover paisa goes to the largest remainder", 100, []int64{1, 2, 4}, []Amount{14, 29, 57}},
+		{"exactly half a paisa each", 1, []int64{1, 1}, []Amount{1, 0}},
+		{"half a paisa left over goes to the earlier part", 2, []int64{1, 1, 2}, []Amount{1, 0, 1}},
+		{"equal remainders beat a smaller one", 3, []int64{1, 1, 2}, []Amount{1, 1, 1}},
+		{"proportional", 10000, []int64{2500, 7500}, []Amount{2500, 7500}},
+		{"zero weight gets nothing", 1000, []int64{0, 3, 0, 1}, []Amount{0, 750, 0, 250}},
+		{"zero weight gets no leftover paisa", 5, []int64{0, 1, 1}, []Amount{0, 3, 2}},
+		{"all zero weights go to the first part", 1000, []int64{0, 0, 0}, []Amount{1000, 0, 0}},
+		{"single part", 1234, []int64{7}, []Amount{1234}},
+		{"no parts", 1234, nil, []Amount{}},
+		{"zero amount", 0, []int64{1, 2}, []Amount{0, 0}},
+		{"negative amount mirrors the positive split", -100, []int64{1, 1, 1}, []Amount{-34, -33, -33}},
+		{"more parts than paise", 2, []int64{1, 1, 1, 1}, []Amount{1, 1, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.amount.Allocate(tt.weights)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%d.Allocate(%v) = %v, want %v", tt.amount, tt.weights, got, tt.want)
+			}
+			if len(tt.weights) > 0 && Sum(got...) != tt.amount {
+				t.Errorf("%d.Allocate(%v) adds up to %d", tt.amount, tt.weights, Sum(got...))
+			}
+		})
+	}
+}
+
+// the parts add up to the amount whatever the amount and the weights are
+func TestAllocateAddsUp(t *testing.T) {
+	weightSets := [][]int64{
+		{1},
+		{1, 1},
+		{1, 2, 3},
+		{3, 3, 3, 3, 3, 3, 3},
+		{0, 1, 0, 1},
+		{9999, 1},
+		{17, 23, 29, 31, 37},
+		{1 << 40, 1 << 40, 1},
+	}
+	for _, weights := range weightSets {
+		for amount := Amount(-1000); amount <= 1000; amount += 7 {
+			parts := amount.Allocate(weights)
+			if Sum(parts...) != amount {
+				t.Fatalf("%d.Allocate(%v) = %v adds up to %d", amount, weights, parts, Sum(parts...))
+			}
+			for i, part := range parts {
+				if weights[i] == 0 && part != 0 {
+					t.Fatalf("%d.Allocate(%v) gives %d to a zero weight", amount, weights, part)
+				}
+			}
+		}
+	}
+}
+
+func TestAllocateNegativeWeightPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Allocate with a negative weight didn't panic")
+		}
+	}()
+	Amount(100).Allocate([]int64{1, -1, 2})
+}
@@ -0,0 +1,170 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/money"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// FakeGateway settles payments in memory without any provider. the checkout approves itself:
+// its RedirectURL is the return url with a valid proof in the query, so following it pays the
+// order. Decline makes a checkout fail instead, and Err makes every call fail
+type FakeGateway struct {
+	mu       sync.Mutex
+	secret   []byte
+	payments map[string]*fakePayment
+	refunds  int
+	// Err is returned by every call when set, to test how provider failures are handled
+	Err error
+}
+
+type fakePayment struct {
+	intent    Intent
+	paymentID string
+	status    string
+	refunded  money.Amount
+}
+
+func NewFakeGateway() *FakeGateway {
+	return &FakeGateway{secret: []byte(randomID()), payments: map[string]*fakePayment{}}
+}
+
+func (g *FakeGateway) Name() string {
+	return model.FakeGateway
+}
+
+func (g *FakeGateway) CreateIntent(ctx context.Context, intent Intent) (Checkout, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.Err != nil {
+		return Checkout{}, g.Err
+	}
+
+	reference := "fake_order_" + randomID()
+	g.payments[reference] = &fakePayment{intent: intent, paymentID: "fake_pay_" + randomID(), status: StatusPending}
+	proof := g.proof(reference)
+
+	query := url.Values{}
+	query.Set("reference", proof.Reference)
+	query.Set("payment_id", proof.PaymentID)
+	query.Set("signature", proof.Signature)
+	separator := "?"
+	if strings.Contains(intent.ReturnURL, "?") {
+		separator = "&"
+	}
+	return Checkout{Reference: reference, Status: StatusPending, RedirectURL: intent.ReturnURL + separator + query.Encode()}, nil
+}
+
+func (g *FakeGateway) Verify(ctx context.Context, proof Proof) (Result, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.Err != nil {
+		return Result{}, g.Err
+	}
+
+	p, ok := g.payments[proof.Reference]
+	if !ok || proof != g.proof(proof.Reference) {
+		return Result{Reference: proof.Reference, Status: StatusFailed}, ErrInvalidProof
+	}
+	if p.status == StatusPending {
+		p.status = StatusPaid
+	}
+	return g.result(proof.Reference, p), nil
+}
+
+func (g *FakeGateway) Refund(ctx context.Context, request RefundRequest) (Refund, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.Err != nil {
+		return Refund{}, g.Err
+	}
+
+	p, ok := g.payments[request.Reference]
+	if !ok || (p.status != StatusPaid && p.status != StatusRefunded) {
+		return Refund{}, errors.New("fake gateway: nothing was paid to refund")
+	}
+	if request.Amount <= 0 || p.refunded+request.Amount > p.intent.Amount {
+		return Refund{}, fmt.Errorf("fake gateway: cannot refund %v of a %v payment with %v already refunded", request.Amount, p.intent.Amount, p.refunded)
+	}
+	p.refunded += request.Amount
+	if p.refunded == p.intent.Amount {
+		p.status = StatusRefunded
+	}
+	g.refunds++
+	return Refund{ID: fmt.Sprintf("fake_refund_%d", g.refunds), Status: RefundProcessed}, nil
+}
+
+func (g *FakeGateway) FetchStatus(ctx context.Context, reference string) (Result, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.Err != nil {
+		return Result{}, g.Err
+	}
+
+	p, ok := g.payments[reference]
+	if !ok {
+		return Result{}, fmt.Errorf("fake gateway: no checkout %s", reference)
+	}
+	return g.result(reference, p), nil
+}
+
+// Proof returns the proof that pays the checkout, as the client would bring it back
+func (g *FakeGateway) Proof(reference string) Proof {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.proof(reference)
+}
+
+// Decline fails the checkout as if the payment was declined
+func (g *FakeGateway) Decline(reference string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if p, ok := g.payments[reference]; ok && p.status == StatusPending {
+		p.status = StatusFailed
+	}
+}
+
+// Refunded returns how much of the checkout's payment was refunded
+func (g *FakeGateway) Refunded(reference string) money.Amount {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if p, ok := g.payments[reference]; ok {
+		return p.refunded
+	}
+	return 0
+}
+
+func (g *FakeGateway) proof(reference string) Proof {
+	var paymentID string
+	if p, ok := g.payments[reference]; ok {
+		paymentID = p.paymentID
+	}
+	h := hmac.New(sha256.New, g.secret)
+	h.Write([]byte(reference + "|" + paymentID))
+	return Proof{Reference: reference, PaymentID: paymentID, Signature: hex.EncodeToString(h.Sum(nil))}
+}
+
+func (g *FakeGateway) result(reference string, p *fakePayment) Result {
+	result := Result{Reference: reference, Status: p.status, Amount: p.intent.Amount, OrderID: p.intent.OrderID}
+	if p.status != StatusPending && p.status != StatusFailed {
+		result.PaymentID = p.paymentID
+	}
+	return result
+}
+
+func randomID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
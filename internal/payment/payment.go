@@ -0,0 +1,190 @@
+// Package payment talks to the payment providers through a Gateway. every provider is an
+// adapter behind the same four calls, and the Registry picks one by the name the client
+// chose at checkout. the fake gateway settles payments offline, for development and tests
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/money"
+	"log"
+	"sort"
+	"strings"
+)
+
+// payment and refund states as reported by the gateways
+const (
+	StatusPending  = "PENDING"
+	StatusPaid     = "PAID"
+	StatusFailed   = "FAILED"
+	StatusRefunded = "REFUNDED"
+
+	RefundPending   = "PENDING"
+	RefundProcessed = "PROCESSED"
+	RefundFailed    = "FAILED"
+)
+
+var (
+	// ErrUnknownGateway is returned by the registry for a gateway it doesn't have
+	ErrUnknownGateway = errors.New("unknown payment gateway")
+	// ErrInvalidProof is returned by Verify when the proof of payment doesn't check out
+	ErrInvalidProof = errors.New("payment could not be verified")
+	// ErrInsufficientFunds is returned by CreateIntent when the payer can't cover the amount
+	ErrInsufficientFunds = errors.New("insufficient funds")
+)
+
+// Gateway is a payment provider
+type Gateway interface {
+	// Name is the value of InitiatePayment.PaymentGateway that picks the gateway
+	Name() string
+	// CreateIntent starts paying for an order. a gateway that settles right away,
+	// like the wallet, returns the checkout with StatusPaid
+	CreateIntent(ctx context.Context, intent Intent) (Checkout, error)
+	// Verify checks the proof the client came back with from the checkout
+	Verify(ctx context.Context, proof Proof) (Result, error)
+	// Refund gives back part or all of a payment
+	Refund(ctx context.Context, request RefundRequest) (Refund, error)
+	// FetchStatus asks the provider where the payment of a checkout stands
+	FetchStatus(ctx context.Context, reference string) (Result, error)
+}
+
+// Intent is an order to be paid
+type Intent struct {
+	OrderID     string
+	UserID      uint
+	Amount      money.Amount
+	Currency    string
+	Description string
+	// ReturnURL is where the provider sends the user once the payment is made,
+	// CancelURL where it goes when the user gives up
+	ReturnURL string
+	CancelURL string
+}
+
+// Checkout is what the client needs to pay an intent
+type Checkout struct {
+	// Reference identifies the checkout with the provider: the razorpay order,
+	// the stripe checkout session or the wallet payment
+	Reference string
+	Status    string
+	// RedirectURL is the page the user pays on, when the provider hosts one
+	RedirectURL string
+	// Page is the data of the payment.html page, for providers paid from our own page
+	Page map[string]interface{}
+}
+
+// Proof is what the client brings back from the checkout
+type Proof struct {
+	Reference string
+	PaymentID string
+	Signature string
+}
+
+// Result is the state of the payment of a checkout
+type Result struct {
+	Reference string
+	// PaymentID is the provider's id of the payment itself, the razorpay payment
+	// or the stripe payment intent
+	PaymentID string
+	Status    string
+	// Amount and OrderID are filled in when the provider reports them
+	Amount  money.Amount
+	OrderID string
+}
+
+// RefundRequest refunds Amount of the payment made through the checkout
+type RefundRequest struct {
+	Reference string
+	PaymentID string
+	Amount    money.Amount
+	Reason    string
+}
+
+// Refund is the provider's record of a refund
+type Refund struct {
+	ID     string
+	Status string
+}
+
+// Registry holds the gateways by name
+type Registry struct {
+	gateways map[string]Gateway
+}
+
+func NewRegistry(gateways ...Gateway) *Registry {
+	r := &Registry{gateways: map[string]Gateway{}}
+	for _, g := range gateways {
+		r.Register(g)
+	}
+	return r
+}
+
+// Register adds the gateway, replacing any gateway of the same name
+func (r *Registry) Register(g Gateway) {
+	r.gateways[strings.ToUpper(g.Name())] = g
+}
+
+// Get returns the gateway of the name, the name is not case sensitive
+func (r *Registry) Get(name string) (Gateway, error) {
+	g, ok := r.gateways[strings.ToUpper(strings.TrimSpace(name))]
+	if !ok {
+		return nil, fmt.Errorf("%w %q, use one of %s", ErrUnknownGateway, name, strings.Join(r.Names(), ", "))
+	}
+	return g, nil
+}
+
+// Names returns the names of the registered gateways, sorted
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.gateways))
+	for name := range r.gateways {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Config holds the provider credentials
+type Config struct {
+	RazorpayKeyID     string
+	RazorpayKeySecret string
+	// RazorpayWebhookSecret signs the webhooks razorpay sends to /webhooks/razorpay
+	RazorpayWebhookSecret string
+	StripeKey             string
+	// StripeWebhookSecret signs the webhooks stripe sends to /webhooks/stripe
+	StripeWebhookSecret string
+	// Fake registers the offline fake gateway, never enable it in production
+	Fake bool
+}
+
+// NewConfig reads the payment configuration out of the environment variables
+func NewConfig(env model.EnvVariables) Config {
+	return Config{
+		RazorpayKeyID:         env.RazorpayKeyID,
+		RazorpayKeySecret:     env.RazorpayKeySecret,
+		RazorpayWebhookSecret: env.RazorpayWebhookSecret,
+		StripeKey:             env.StripeKey,
+		StripeWebhookSecret:   env.StripeWebhookSecret,
+		Fake:                  env.FakePayments == "true",
+	}
+}
+
+// New returns a registry with the razorpay and stripe gateways, and the fake one when enabled
+func New(config Config) *Registry {
+	if config.RazorpayKeyID == "" || config.RazorpayKeySecret == "" {
+		log.Printf("Warning: RAZORPAY_KEY_ID or RAZORPAY_KEY_SECRET is not set, razorpay payments will fail")
+	}
+	if config.StripeKey == "" {
+		log.Printf("Warning: STRIPE_KEY is not set, stripe payments will fail")
+	}
+	registry := NewRegistry(
+		NewRazorpayGateway(config.RazorpayKeyID, config.RazorpayKeySecret, config.RazorpayWebhookSecret),
+		NewStripeGateway(config.StripeKey, config.StripeWebhookSecret),
+	)
+	if config.Fake {
+		log.Printf("Warning: the fake payment gateway is enabled, orders can be paid without money")
+		registry.Register(NewFakeGateway())
+	}
+	return registry
+}
@@ -0,0 +1,197 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/money"
+	"net/http"
+
+	"github.com/razorpay/razorpay-go"
+)
+
+// RazorpayGateway creates razorpay orders that are paid from the payment.html checkout page
+type RazorpayGateway struct {
+	client        *razorpay.Client
+	keyID         string
+	secret        string
+	webhookSecret string
+}
+
+func NewRazorpayGateway(keyID string, secret string, webhookSecret string) *RazorpayGateway {
+	return &RazorpayGateway{client: razorpay.NewClient(keyID, secret), keyID: keyID, secret: secret, webhookSecret: webhookSecret}
+}
+
+func (g *RazorpayGateway) Name() string {
+	return model.Razorpay
+}
+
+func (g *RazorpayGateway) CreateIntent(ctx context.Context, intent Intent) (Checkout, error) {
+	if _, err := g.client.Order.All(nil, nil); err != nil {
+		return Checkout{}, errors.New("razorpay service is down")
+	}
+
+	order, err := g.client.Order.Create(map[string]interface{}{
+		"amount":          intent.Amount.Paise(),
+		"currency":        intent.Currency,
+		"receipt":         intent.OrderID,
+		"payment_capture": 1,
+	}, nil)
+	if err != nil {
+		return Checkout{}, err
+	}
+	id, _ := order["id"].(string)
+	if id == "" {
+		return Checkout{}, errors.New("razorpay returned an order without an id")
+	}
+
+	return Checkout{
+		Reference: id,
+		Status:    StatusPending,
+		Page: map[string]interface{}{
+			"razorpay_order_id": id,
+			"amount":            order["amount"],
+			"key":               g.keyID,
+			"callbackurl":       intent.ReturnURL,
+			"cancelurl":         intent.CancelURL,
+		},
+	}, nil
+}
+
+// Verify checks the signature razorpay's checkout hands back, made with the key secret
+// over the order and payment ids, so it doesn't call razorpay
+func (g *RazorpayGateway) Verify(ctx context.Context, proof Proof) (Result, error) {
+	if !validSignature(g.secret, []byte(proof.Reference+"|"+proof.PaymentID), proof.Signature) {
+		return Result{Reference: proof.Reference, Status: StatusFailed}, ErrInvalidProof
+	}
+	return Result{Reference: proof.Reference, PaymentID: proof.PaymentID, Status: StatusPaid}, nil
+}
+
+func (g *RazorpayGateway) Refund(ctx context.Context, request RefundRequest) (Refund, error) {
+	if request.PaymentID == "" {
+		return Refund{}, errors.New("razorpay refunds need the payment id")
+	}
+	refund, err := g.client.Payment.Refund(request.PaymentID, int(request.Amount.Paise()), map[string]interface{}{
+		"notes": map[string]interface{}{"reason": request.Reason},
+	}, nil)
+	if err != nil {
+		return Refund{}, err
+	}
+	id, _ := refund["id"].(string)
+	status, _ := refund["status"].(string)
+	return Refund{ID: id, Status: razorpayRefundStatus(status)}, nil
+}
+
+// FetchStatus looks at the payments made against the razorpay order, a captured one means paid
+func (g *RazorpayGateway) FetchStatus(ctx context.Context, reference string) (Result, error) {
+	response, err := g.client.Order.Payments(reference, nil, nil)
+	if err != nil {
+		return Result{}, err
+	}
+	result := Result{Reference: reference, Status: StatusPending}
+	items, _ := response["items"].([]interface{})
+	failed := 0
+	for _, item := range items {
+		p, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, _ := p["id"].(string)
+		amount, _ := p["amount"].(float64)
+		switch p["status"] {
+		case "captured":
+			return Result{Reference: reference, PaymentID: id, Status: StatusPaid, Amount: money.Amount(amount)}, nil
+		case "refunded":
+			result = Result{Reference: reference, PaymentID: id, Status: StatusRefunded, Amount: money.Amount(amount)}
+		case "failed":
+			failed++
+		}
+	}
+	if len(items) > 0 && failed == len(items) {
+		result.Status = StatusFailed
+	}
+	return result, nil
+}
+
+// razorpayWebhook is the part of a razorpay webhook that is read, the payment entity comes
+// with every payment and refund event
+type razorpayWebhook struct {
+	Event   string `json:"event"`
+	Payload struct {
+		Refund *struct {
+			Entity struct {
+				ID string `json:"id"`
+			} `json:"entity"`
+		} `json:"refund"`
+		Payment *struct {
+			Entity struct {
+				ID             string `json:"id"`
+				OrderID        string `json:"order_id"`
+				AmountRefunded int64  `json:"amount_refunded"`
+			} `json:"entity"`
+		} `json:"payment"`
+	} `json:"payload"`
+}
+
+// ParseWebhook verifies the X-Razorpay-Signature header, an hmac of the body made with the
+// webhook secret, and reads payment.captured, payment.failed, refund.processed and refund.failed
+func (g *RazorpayGateway) ParseWebhook(payload []byte, header http.Header) (Event, error) {
+	if g.webhookSecret == "" {
+		return Event{}, fmt.Errorf("%w: RAZORPAY_WEBHOOK_SECRET is not set", ErrInvalidSignature)
+	}
+	if !validSignature(g.webhookSecret, payload, header.Get("X-Razorpay-Signature")) {
+		return Event{}, ErrInvalidSignature
+	}
+
+	var webhook razorpayWebhook
+	if err := json.Unmarshal(payload, &webhook); err != nil {
+		return Event{}, err
+	}
+	// razorpay sends the same id with every delivery of an event
+	event := Event{ID: header.Get("X-Razorpay-Event-Id"), ProviderType: webhook.Event}
+	if event.ID == "" {
+		sum := sha256.Sum256(payload)
+		event.ID = hex.EncodeToString(sum[:])
+	}
+	if webhook.Payload.Payment == nil {
+		return event, nil
+	}
+	payment := webhook.Payload.Payment.Entity
+	event.Reference, event.PaymentID = payment.OrderID, payment.ID
+	if webhook.Payload.Refund != nil {
+		event.RefundID = webhook.Payload.Refund.Entity.ID
+	}
+
+	switch webhook.Event {
+	case "payment.captured":
+		event.Type = EventPaid
+	case "payment.failed":
+		event.Type = EventFailed
+	case "refund.processed":
+		event.Type, event.Refunded = EventRefunded, money.Amount(payment.AmountRefunded)
+	case "refund.failed":
+		event.Type = EventRefundFailed
+	}
+	return event, nil
+}
+
+func validSignature(secret string, payload []byte, signature string) bool {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write(payload)
+	return hmac.Equal([]byte(hex.EncodeToString(h.Sum(nil))), []byte(signature))
+}
+
+func razorpayRefundStatus(status string) string {
+	switch status {
+	case "processed":
+		return RefundProcessed
+	case "failed":
+		return RefundFailed
+	}
+	return RefundPending
+}
@@ -0,0 +1,200 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/money"
+	"net/http"
+	"strings"
+
+	"github.com/stripe/stripe-go/v78"
+	"github.com/stripe/stripe-go/v78/checkout/session"
+	"github.com/stripe/stripe-go/v78/refund"
+	"github.com/stripe/stripe-go/v78/webhook"
+)
+
+// StripeGateway sends the user to a stripe hosted checkout session
+type StripeGateway struct {
+	sessions      session.Client
+	refunds       refund.Client
+	webhookSecret string
+}
+
+func NewStripeGateway(key string, webhookSecret string) *StripeGateway {
+	backend := stripe.GetBackend(stripe.APIBackend)
+	return &StripeGateway{
+		sessions:      session.Client{B: backend, Key: key},
+		refunds:       refund.Client{B: backend, Key: key},
+		webhookSecret: webhookSecret,
+	}
+}
+
+func (g *StripeGateway) Name() string {
+	return model.Stripe
+}
+
+func (g *StripeGateway) CreateIntent(ctx context.Context, intent Intent) (Checkout, error) {
+	// stripe fills in the session id when it sends the user back
+	returnURL := intent.ReturnURL + "?session_id={CHECKOUT_SESSION_ID}"
+	if strings.Contains(intent.ReturnURL, "?") {
+		returnURL = intent.ReturnURL + "&session_id={CHECKOUT_SESSION_ID}"
+	}
+	params := &stripe.CheckoutSessionParams{
+		Params:             stripe.Params{Context: ctx},
+		PaymentMethodTypes: stripe.StringSlice([]string{"card"}),
+		LineItems: []*stripe.CheckoutSessionLineItemParams{
+			{
+				PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+					Currency: stripe.String(strings.ToLower(intent.Currency)),
+					ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
+						Name:        stripe.String(intent.OrderID),
+						Description: stripe.String(intent.Description),
+					},
+					UnitAmount: stripe.Int64(intent.Amount.Paise()), // Amount in paise, same as Razorpay
+				},
+				Quantity: stripe.Int64(1),
+			},
+		},
+		Metadata: map[string]string{"order_id": intent.OrderID},
+		// the payment intent carries the order too, its events don't mention the session
+		PaymentIntentData: &stripe.CheckoutSessionPaymentIntentDataParams{
+			Metadata: map[string]string{"order_id": intent.OrderID},
+		},
+		Mode:       stripe.String(string(stripe.CheckoutSessionModePayment)),
+		SuccessURL: stripe.String(returnURL),
+		CancelURL:  stripe.String(returnURL),
+	}
+
+	checkout, err := g.sessions.New(params)
+	if err != nil {
+		return Checkout{}, err
+	}
+	return Checkout{Reference: checkout.ID, Status: StatusPending, RedirectURL: checkout.URL}, nil
+}
+
+// Verify looks the checkout session up, the session id is all stripe hands back
+func (g *StripeGateway) Verify(ctx context.Context, proof Proof) (Result, error) {
+	return g.FetchStatus(ctx, proof.Reference)
+}
+
+func (g *StripeGateway) Refund(ctx context.Context, request RefundRequest) (Refund, error) {
+	paymentID := request.PaymentID
+	if paymentID == "" {
+		result, err := g.FetchStatus(ctx, request.Reference)
+		if err != nil {
+			return Refund{}, err
+		}
+		paymentID = result.PaymentID
+	}
+	if paymentID == "" {
+		return Refund{}, errors.New("the stripe checkout session has no payment to refund")
+	}
+
+	params := &stripe.RefundParams{
+		Params:        stripe.Params{Context: ctx},
+		PaymentIntent: stripe.String(paymentID),
+		Amount:        stripe.Int64(request.Amount.Paise()),
+	}
+	params.AddMetadata("reason", request.Reason)
+	r, err := g.refunds.New(params)
+	if err != nil {
+		return Refund{}, err
+	}
+	return Refund{ID: r.ID, Status: stripeRefundStatus(r.Status)}, nil
+}
+
+func (g *StripeGateway) FetchStatus(ctx context.Context, reference string) (Result, error) {
+	checkout, err := g.sessions.Get(reference, &stripe.CheckoutSessionParams{Params: stripe.Params{Context: ctx}})
+	if err != nil {
+		return Result{}, err
+	}
+
+	result := Result{
+		Reference: checkout.ID,
+		Status:    StatusPending,
+		Amount:    money.Amount(checkout.AmountTotal),
+		OrderID:   checkout.Metadata["order_id"],
+	}
+	if checkout.PaymentIntent != nil {
+		result.PaymentID = checkout.PaymentIntent.ID
+	}
+	switch {
+	case checkout.PaymentStatus == stripe.CheckoutSessionPaymentStatusPaid:
+		result.Status = StatusPaid
+	case checkout.Status == stripe.CheckoutSessionStatusExpired:
+		result.Status = StatusFailed
+	}
+	return result, nil
+}
+
+// ParseWebhook verifies the Stripe-Signature header and reads checkout.session.completed,
+// payment_intent.payment_failed, charge.refunded and the refund updates, other events come
+// back without a Type
+func (g *StripeGateway) ParseWebhook(payload []byte, header http.Header) (Event, error) {
+	if g.webhookSecret == "" {
+		return Event{}, fmt.Errorf("%w: STRIPE_WEBHOOK_SECRET is not set", ErrInvalidSignature)
+	}
+	// the events are read field by field below, so they are accepted from any api version
+	e, err := webhook.ConstructEventWithOptions(payload, header.Get("Stripe-Signature"), g.webhookSecret,
+		webhook.ConstructEventOptions{IgnoreAPIVersionMismatch: true})
+	if err != nil {
+		return Event{}, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	event := Event{ID: e.ID, ProviderType: string(e.Type)}
+	switch e.Type {
+	case "checkout.session.completed":
+		var checkout stripe.CheckoutSession
+		if err := json.Unmarshal(e.Data.Raw, &checkout); err != nil {
+			return event, err
+		}
+		// delayed payment methods complete the session before the money arrives
+		if checkout.PaymentStatus != stripe.CheckoutSessionPaymentStatusPaid {
+			return event, nil
+		}
+		event.Type, event.Reference, event.OrderID = EventPaid, checkout.ID, checkout.Metadata["order_id"]
+		if checkout.PaymentIntent != nil {
+			event.PaymentID = checkout.PaymentIntent.ID
+		}
+	case "payment_intent.payment_failed":
+		var intent stripe.PaymentIntent
+		if err := json.Unmarshal(e.Data.Raw, &intent); err != nil {
+			return event, err
+		}
+		event.Type, event.PaymentID, event.OrderID = EventFailed, intent.ID, intent.Metadata["order_id"]
+	case "charge.refunded":
+		var charge stripe.Charge
+		if err := json.Unmarshal(e.Data.Raw, &charge); err != nil {
+			return event, err
+		}
+		if charge.PaymentIntent == nil {
+			return event, nil
+		}
+		event.Type, event.PaymentID, event.Refunded = EventRefunded, charge.PaymentIntent.ID, money.Amount(charge.AmountRefunded)
+	case "refund.updated", "charge.refund.updated":
+		var r stripe.Refund
+		if err := json.Unmarshal(e.Data.Raw, &r); err != nil {
+			return event, err
+		}
+		switch stripeRefundStatus(r.Status) {
+		case RefundProcessed:
+			event.Type, event.RefundID = EventRefundProcessed, r.ID
+		case RefundFailed:
+			event.Type, event.RefundID = EventRefundFailed, r.ID
+		}
+	}
+	return event, nil
+}
+
+func stripeRefundStatus(status stripe.RefundStatus) string {
+	switch status {
+	case stripe.RefundStatusSucceeded:
+		return RefundProcessed
+	case stripe.RefundStatusFailed, stripe.RefundStatusCanceled:
+		return RefundFailed
+	}
+	return RefundPending
+}
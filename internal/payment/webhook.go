@@ -0,0 +1,46 @@
+package payment
+
+import (
+	"errors"
+	"foodbuddy/internal/money"
+	"net/http"
+)
+
+// the payment events the platform acts on, whatever the provider calls them
+const (
+	EventPaid     = "PAID"
+	EventFailed   = "FAILED"
+	EventRefunded = "REFUNDED"
+	// a refund we asked for went through or failed, the refund is found by RefundID
+	EventRefundProcessed = "REFUND_PROCESSED"
+	EventRefundFailed    = "REFUND_FAILED"
+)
+
+// ErrInvalidSignature is returned when a webhook is not signed with the webhook secret
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// WebhookGateway is a gateway that tells us about payments through signed webhooks, so
+// an order is settled even when the user never makes it back to the callback
+type WebhookGateway interface {
+	Gateway
+	// ParseWebhook checks the signature of the webhook and reads the event out of it
+	ParseWebhook(payload []byte, header http.Header) (Event, error)
+}
+
+// Event is a webhook translated to what it means for a payment. Type is empty for
+// events the platform doesn't act on
+type Event struct {
+	// ID is the provider's id of the event, deliveries of the same event share it
+	ID string
+	// ProviderType is the event type as the provider named it
+	ProviderType string
+	Type         string
+	// the payment is found by the first of Reference, PaymentID and OrderID that is set
+	Reference string
+	PaymentID string
+	OrderID   string
+	// Refunded is the total refunded of the payment so far, for EventRefunded
+	Refunded money.Amount
+	// RefundID is the provider's id of the refund the event is about, when it names one
+	RefundID string
+}
@@ -0,0 +1,93 @@
+// Package pubsub passes events between the goroutines of one process. publishers never wait
+// for subscribers, a subscriber that can't keep up is dropped and expected to subscribe again
+// and catch up from the database
+package pubsub
+
+import (
+	"sync"
+	"time"
+)
+
+// SubscriptionBuffer is how many events a subscriber can fall behind before it is dropped
+const SubscriptionBuffer = 32
+
+// Event is something that happened, published on a topic
+type Event struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+	At   time.Time   `json:"at"`
+}
+
+// Hub delivers the events published on a topic to its current subscribers
+type Hub struct {
+	mu     sync.Mutex
+	topics map[string]map[*Subscription]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{topics: map[string]map[*Subscription]struct{}{}}
+}
+
+// Subscription receives the events of its topic until it is closed
+type Subscription struct {
+	hub    *Hub
+	topic  string
+	events chan Event
+}
+
+// Subscribe starts receiving the events published on the topic from now on
+func (h *Hub) Subscribe(topic string) *Subscription {
+	s := &Subscription{hub: h, topic: topic, events: make(chan Event, SubscriptionBuffer)}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.topics[topic] == nil {
+		h.topics[topic] = map[*Subscription]struct{}{}
+	}
+	h.topics[topic][s] = struct{}{}
+	return s
+}
+
+// Publish hands the event to the subscribers of the topic. a nil hub publishes nothing
+func (h *Hub) Publish(topic string, event Event) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.topics[topic] {
+		select {
+		case s.events <- event:
+		default:
+			// fell behind, closing the channel tells it to start over
+			h.remove(s)
+		}
+	}
+}
+
+// Events is closed when the subscription is closed or dropped
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close stops the subscription, closing it twice is fine
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+// remove drops the subscription from its topic, h.mu must be held
+func (h *Hub) remove(s *Subscription) {
+	subscribers, ok := h.topics[s.topic]
+	if !ok {
+		return
+	}
+	if _, ok := subscribers[s]; !ok {
+		return
+	}
+	delete(subscribers, s)
+	if len(subscribers) == 0 {
+		delete(h.topics, s.topic)
+	}
+	close(s.events)
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"foodbuddy/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AdminRepository stores admin accounts, their invites and recovery codes
+type AdminRepository interface {
+	Create(admin *model.Admin) error
+	Save(admin *model.Admin) error
+	FindByID(id uint) (model.Admin, error)
+	FindByEmail(email string) (model.Admin, error)
+	List() ([]model.Admin, error)
+	EnableTOTP(id uint, step int64) error
+	// UseTOTPStep records the step of a code that was just used, it reports false when
+	// the same or a later code was used concurrently
+	UseTOTPStep(id uint, step int64) (bool, error)
+
+	CreateInvite(invite *model.AdminInvite) error
+	FindInviteByTokenHash(hash string) (model.AdminInvite, error)
+	ListInvites() ([]model.AdminInvite, error)
+	// AcceptInvite marks the invite accepted, it reports false when it already was
+	AcceptInvite(id uint, at time.Time) (bool, error)
+
+	// ReplaceRecoveryCodes drops the codes of the admin and stores the new hashes
+	ReplaceRecoveryCodes(adminID uint, hashes []string) error
+	// UseRecoveryCode marks an unused code of the admin as used, it reports false when there is none
+	UseRecoveryCode(adminID uint, hash string, at time.Time) (bool, error)
+	CountUnusedRecoveryCodes(adminID uint) (int64, error)
+}
+
+type adminRepository struct {
+	db *gorm.DB
+}
+
+func NewAdminRepository(db *gorm.DB) AdminRepository {
+	return &adminRepository{db: db}
+}
+
+func (r *adminRepository) Create(admin *model.Admin) error {
+	return r.db.Create(admin).Error
+}
+
+func (r *adminRepository) Save(admin *model.Admin) error {
+	return r.db.Save(admin).Error
+}
+
+func (r *adminRepository) FindByID(id uint) (model.Admin, error) {
+	var admin model.Admin
+	err := r.db.Where("id = ?", id).First(&admin).Error
+	return admin, translate(err)
+}
+
+func (r *adminRepository) FindByEmail(email string) (model.Admin, error) {
+	var admin model.Admin
+	err := r.db.Where("email = ?", email).First(&admin).Error
+	return admin, translate(err)
+}
+
+func (r *adminRepository) List() ([]model.Admin, error) {
+	var admins []model.Admin
+	err := r.db.Order("id").Find(&admins).Error
+	return admins, err
+}
+
+func (r *adminRepository) EnableTOTP(id uint, step int64) error {
+	return r.db.Model(&model.Admin{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"totp_enabled": true, "totp_last_step": step}).Error
+}
+
+func (r *adminRepository) UseTOTPStep(id uint, step int64) (bool, error) {
+	result := r.db.Model(&model.Admin{}).Where("id = ? AND totp_last_step < ?", id, step).Update("totp_last_step", step)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *adminRepository) CreateInvite(invite *model.AdminInvite) error {
+	return r.db.Create(invite).Error
+}
+
+func (r *adminRepository) FindInviteByTokenHash(hash string) (model.AdminInvite, error) {
+	var invite model.AdminInvite
+	err := r.db.Where("token_hash = ?", hash).First(&invite).Error
+	return invite, translate(err)
+}
+
+func (r *adminRepository) ListInvites() ([]model.AdminInvite, error) {
+	var invites []model.AdminInvite
+	err := r.db.Order("created_at DESC").Find(&invites).Error
+	return invites, err
+}
+
+func (r *adminRepository) AcceptInvite(id uint, at time.Time) (bool, error) {
+	result := r.db.Model(&model.AdminInvite{}).Where("id = ? AND accepted_at IS NULL", id).Update("accepted_at", at)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *adminRepository) ReplaceRecoveryCodes(adminID uint, hashes []string) error {
+	if err := r.db.Where("admin_id = ?", adminID).Delete(&model.AdminRecoveryCode{}).Error; err != nil {
+		return err
+	}
+	codes := make([]model.AdminRecoveryCode, len(hashes))
+	for i, hash := range hashes {
+		codes[i] = model.AdminRecoveryCode{AdminID: adminID, CodeHash: hash}
+	}
+	return r.db.Create(&codes).Error
+}
+
+func (r *adminRepository) UseRecoveryCode(adminID uint, hash string, at time.Time) (bool, error) {
+	result := r.db.Model(&model.AdminRecoveryCode{}).
+		Where("admin_id = ? AND code_hash = ? AND used_at IS NULL", adminID, hash).
+		Update("used_at", at)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *adminRepository) CountUnusedRecoveryCodes(adminID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.AdminRecoveryCode{}).Where("admin_id = ? AND used_at IS NULL", adminID).Count(&count).Error
+	return count, err
+}
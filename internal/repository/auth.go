@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"foodbuddy/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// AuthRepository stores email verification and password reset records
+type AuthRepository interface {
+	FindVerification(email string, role string) (model.VerificationTable, error)
+	FindVerificationByEmail(email string) (model.VerificationTable, error)
+	CreateVerification(verification *model.VerificationTable) error
+	UpdateVerification(verification *model.VerificationTable) error
+	SetVerificationStatus(email string, role string, status string) error
+
+	FindPasswordReset(email string, role string) (model.PasswordReset, error)
+	CreatePasswordReset(reset *model.PasswordReset) error
+	UpdatePasswordReset(reset *model.PasswordReset) error
+	DeactivatePasswordReset(email string, role string) error
+}
+
+type authRepository struct {
+	db *gorm.DB
+}
+
+func NewAuthRepository(db *gorm.DB) AuthRepository {
+	return &authRepository{db: db}
+}
+
+func (r *authRepository) FindVerification(email string, role string) (model.VerificationTable, error) {
+	var verification model.VerificationTable
+	err := r.db.Where("email = ? AND role = ?", email, role).First(&verification).Error
+	return verification, translate(err)
+}
+
+func (r *authRepository) FindVerificationByEmail(email string) (model.VerificationTable, error) {
+	var verification model.VerificationTable
+	err := r.db.Where("email = ?", email).First(&verification).Error
+	return verification, translate(err)
+}
+
+func (r *authRepository) CreateVerification(verification *model.VerificationTable) error {
+	return r.db.Create(verification).Error
+}
+
+func (r *authRepository) UpdateVerification(verification *model.VerificationTable) error {
+	return r.db.Where("email = ? AND role = ?", verification.Email, verification.Role).Updates(verification).Error
+}
+
+func (r *authRepository) SetVerificationStatus(email string, role string, status string) error {
+	return r.db.Model(&model.VerificationTable{}).Where("email = ? AND role = ?", email, role).Update("verification_status", status).Error
+}
+
+func (r *authRepository) FindPasswordReset(email string, role string) (model.PasswordReset, error) {
+	var reset model.PasswordReset
+	err := r.db.Where("email = ? AND role = ?", email, role).First(&reset).Error
+	return reset, translate(err)
+}
+
+func (r *authRepository) CreatePasswordReset(reset *model.PasswordReset) error {
+	return r.db.Create(reset).Error
+}
+
+func (r *authRepository) UpdatePasswordReset(reset *model.PasswordReset) error {
+	return r.db.Where("email = ? AND role = ?", reset.Email, reset.Role).Updates(reset).Error
+}
+
+func (r *authRepository) DeactivatePasswordReset(email string, role string) error {
+	return r.db.Model(&model.PasswordReset{}).Where("email = ? AND role = ?", email, role).Update("active", model.NO).Error
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"foodbuddy/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// CartRepository stores the items users keep in their carts
+type CartRepository interface {
+	Find(userID uint, productID uint) (model.CartItems, error)
+	ListByUser(userID uint) ([]model.CartItems, error)
+	ListByUserAndRestaurant(userID uint, restaurantID uint) ([]model.CartItems, error)
+	Create(item *model.CartItems) error
+	Update(item *model.CartItems) error
+	Delete(userID uint, productID uint) error
+	Clear(userID uint) error
+	ClearRestaurant(userID uint, restaurantID uint) error
+	SetCookingRequest(userID uint, productID uint, cookingRequest string) error
+}
+
+type cartRepository struct {
+	db *gorm.DB
+}
+
+func NewCartRepository(db *gorm.DB) CartRepository {
+	return &cartRepository{db: db}
+}
+
+func (r *cartRepository) Find(userID uint, productID uint) (model.CartItems, error) {
+	var item model.CartItems
+	err := r.db.Where("user_id = ? AND product_id = ?", userID, productID).First(&item).Error
+	return item, translate(err)
+}
+
+func (r *cartRepository) ListByUser(userID uint) ([]model.CartItems, error) {
+	var items []model.CartItems
+	err := r.db.Where("user_id = ?", userID).Find(&items).Error
+	return items, err
+}
+
+func (r *cartRepository) ListByUserAndRestaurant(userID uint, restaurantID uint) ([]model.CartItems, error) {
+	var items []model.CartItems
+	err := r.db.Where("user_id = ? AND restaurant_id = ?", userID, restaurantID).Find(&items).Error
+	return items, err
+}
+
+func (r *cartRepository) Create(item *model.CartItems) error {
+	return r.db.Create(item).Error
+}
+
+func (r *cartRepository) Update(item *model.CartItems) error {
+	return r.db.Where("user_id = ? AND product_id = ?", item.UserID, item.ProductID).Updates(item).Error
+}
+
+func (r *cartRepository) Delete(userID uint, productID uint) error {
+	return r.db.Where("user_id = ? AND product_id = ?", userID, productID).Delete(&model.CartItems{}).Error
+}
+
+func (r *cartRepository) Clear(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&model.CartItems{}).Error
+}
+
+func (r *cartRepository) ClearRestaurant(userID uint, restaurantID uint) error {
+	return r.db.Where("user_id = ? AND restaurant_id = ?", userID, restaurantID).Delete(&model.CartItems{}).Error
+}
+
+func (r *cartRepository) SetCookingRequest(userID uint, productID uint, cookingRequest string) error {
+	return r.db.Where("user_id = ? AND product_id = ?", userID, productID).Updates(model.CartItems{CookingRequest: cookingRequest}).Error
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"foodbuddy/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// CategoryRepository stores product categories
+type CategoryRepository interface {
+	FindByID(id uint) (model.Category, error)
+	FindByName(name string) (model.Category, error)
+	List() ([]model.Category, error)
+	ListWithProducts() ([]model.Category, error)
+	Save(category *model.Category) error
+	Delete(id uint) error
+}
+
+type categoryRepository struct {
+	db *gorm.DB
+}
+
+func NewCategoryRepository(db *gorm.DB) CategoryRepository {
+	return &categoryRepository{db: db}
+}
+
+func (r *categoryRepository) FindByID(id uint) (model.Category, error) {
+	var category model.Category
+	err := r.db.Where("id = ?", id).First(&category).Error
+	return category, translate(err)
+}
+
+func (r *categoryRepository) FindByName(name string) (model.Category, error) {
+	var category model.Category
+	err := r.db.Where("name = ?", name).First(&category).Error
+	return category, translate(err)
+}
+
+func (r *categoryRepository) List() ([]model.Category, error) {
+	var categories []model.Category
+	err := r.db.Find(&categories).Error
+	return categories, err
+}
+
+func (r *categoryRepository) ListWithProducts() ([]model.Category, error) {
+	var categories []model.Category
+	err := r.db.Preload("Products").Find(&categories).Error
+	return categories, err
+}
+
+func (r *categoryRepository) Save(category *model.Category) error {
+	return r.db.Save(category).Error
+}
+
+func (r *categoryRepository) Delete(id uint) error {
+	return r.db.Delete(&model.Category{}, id).Error
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"foodbuddy/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// CouponRepository stores the coupon inventory and per user coupon usage
+type CouponRepository interface {
+	FindByCode(code string) (model.CouponInventory, error)
+	List() ([]model.CouponInventory, error)
+	Create(coupon *model.CouponInventory) error
+	Save(coupon *model.CouponInventory) error
+
+	FindUsage(userID uint, code string) (model.CouponUsage, error)
+	CreateUsage(usage *model.CouponUsage) error
+	SaveUsage(usage *model.CouponUsage) error
+}
+
+type couponRepository struct {
+	db *gorm.DB
+}
+
+func NewCouponRepository(db *gorm.DB) CouponRepository {
+	return &couponRepository{db: db}
+}
+
+func (r *couponRepository) FindByCode(code string) (model.CouponInventory, error) {
+	var coupon model.CouponInventory
+	err := r.db.Where("coupon_code = ?", code).First(&coupon).Error
+	return coupon, translate(err)
+}
+
+func (r *couponRepository) List() ([]model.CouponInventory, error) {
+	var coupons []model.CouponInventory
+	err := r.db.Find(&coupons).Error
+	return coupons, err
+}
+
+func (r *couponRepository) Create(coupon *model.CouponInventory) error {
+	return r.db.Create(coupon).Error
+}
+
+func (r *couponRepository) Save(coupon *model.CouponInventory) error {
+	return r.db.Save(coupon).Error
+}
+
+func (r *couponRepository) FindUsage(userID uint, code string) (model.CouponUsage, error) {
+	var usage model.CouponUsage
+	err := r.db.Where("user_id = ? AND coupon_code = ?", userID, code).First(&usage).Error
+	return usage, translate(err)
+}
+
+func (r *couponRepository) CreateUsage(usage *model.CouponUsage) error {
+	return r.db.Create(usage).Error
+}
+
+func (r *couponRepository) SaveUsage(usage *model.CouponUsage) error {
+	return r.db.Save(usage).Error
+}
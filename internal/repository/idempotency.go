@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"foodbuddy/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyRepository stores the responses replayed for repeated Idempotency-Keys
+type IdempotencyRepository interface {
+	// Claim stores the key as processing, it reports false when the owner used the key before
+	Claim(key *model.IdempotencyKey) (bool, error)
+	Find(owner string, key string) (model.IdempotencyKey, error)
+	// Reclaim takes over a key that expired, or that was left processing since before
+	// abandonedBefore by a request with the same fingerprint. it reports false when the key
+	// is neither, or another request reclaimed it first
+	Reclaim(key *model.IdempotencyKey, expiredBefore time.Time, abandonedBefore time.Time) (bool, error)
+	Complete(id uint, code int, contentType string, body []byte) error
+	// Release forgets the key so the request can be made again with it
+	Release(id uint) error
+	// Purge deletes the keys created before the time
+	Purge(before time.Time) (int64, error)
+}
+
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+func (r *idempotencyRepository) Claim(key *model.IdempotencyKey) (bool, error) {
+	key.Status = model.IdempotencyProcessing
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *idempotencyRepository) Find(owner string, key string) (model.IdempotencyKey, error) {
+	var found model.IdempotencyKey
+	err := r.db.Where("owner = ? AND idempotency_key = ?", owner, key).First(&found).Error
+	return found, translate(err)
+}
+
+func (r *idempotencyRepository) Reclaim(key *model.IdempotencyKey, expiredBefore time.Time, abandonedBefore time.Time) (bool, error) {
+	now := time.Now()
+	result := r.db.Model(&model.IdempotencyKey{}).
+		Where("owner = ? AND idempotency_key = ?", key.Owner, key.Key).
+		Where(r.db.Where("created_at < ?", expiredBefore).
+			Or("status = ? AND updated_at < ? AND fingerprint = ?", model.IdempotencyProcessing, abandonedBefore, key.Fingerprint)).
+		Updates(map[string]interface{}{
+			"fingerprint":   key.Fingerprint,
+			"status":        model.IdempotencyProcessing,
+			"response_code": 0,
+			"content_type":  "",
+			"response_body": nil,
+			"created_at":    now,
+			"updated_at":    now,
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	found, err := r.Find(key.Owner, key.Key)
+	*key = found
+	return err == nil, err
+}
+
+func (r *idempotencyRepository) Complete(id uint, code int, contentType string, body []byte) error {
+	return r.db.Model(&model.IdempotencyKey{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":        model.IdempotencyCompleted,
+		"response_code": code,
+		"content_type":  contentType,
+		"response_body": body,
+	}).Error
+}
+
+func (r *idempotencyRepository) Release(id uint) error {
+	return r.db.Where("id = ?", id).Delete(&model.IdempotencyKey{}).Error
+}
+
+func (r *idempotencyRepository) Purge(before time.Time) (int64, error) {
+	result := r.db.Where("created_at < ?", before).Delete(&model.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
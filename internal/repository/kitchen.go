@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"errors"
+	"foodbuddy/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// KitchenRepository stores the events of the restaurants' kitchen feeds
+type KitchenRepository interface {
+	// Add numbers the event after the restaurant's last one and stores it. the restaurant's
+	// sequence stays locked until the transaction commits, so events commit in their Seq order
+	Add(event *model.KitchenEvent) error
+	// ListAfter returns up to limit events of the restaurant with a Seq after the cursor, oldest first
+	ListAfter(restaurantID uint, cursor uint, limit int) ([]model.KitchenEvent, error)
+	// FirstSeq returns the Seq of the restaurant's oldest event kept, or the Seq its next
+	// event gets when none is kept
+	FirstSeq(restaurantID uint) (uint, error)
+	// Purge deletes the events created before the time
+	Purge(before time.Time) (int64, error)
+}
+
+type kitchenRepository struct {
+	db *gorm.DB
+}
+
+func NewKitchenRepository(db *gorm.DB) KitchenRepository {
+	return &kitchenRepository{db: db}
+}
+
+func (r *kitchenRepository) Add(event *model.KitchenEvent) error {
+	sequence := model.KitchenSequence{RestaurantID: event.RestaurantID}
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&sequence).Error; err != nil {
+		return err
+	}
+	err := r.db.Model(&model.KitchenSequence{}).Where("restaurant_id = ?", event.RestaurantID).
+		Update("last_seq", gorm.Expr("last_seq + 1")).Error
+	if err != nil {
+		return err
+	}
+	if err := r.db.Where("restaurant_id = ?", event.RestaurantID).First(&sequence).Error; err != nil {
+		return err
+	}
+	event.Seq = sequence.LastSeq
+	return r.db.Create(event).Error
+}
+
+func (r *kitchenRepository) ListAfter(restaurantID uint, cursor uint, limit int) ([]model.KitchenEvent, error) {
+	var events []model.KitchenEvent
+	err := r.db.Where("restaurant_id = ? AND seq > ?", restaurantID, cursor).Order("seq").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+func (r *kitchenRepository) FirstSeq(restaurantID uint) (uint, error) {
+	var oldest uint
+	err := r.db.Model(&model.KitchenEvent{}).Select("COALESCE(MIN(seq), 0)").Where("restaurant_id = ?", restaurantID).Scan(&oldest).Error
+	if err != nil || oldest != 0 {
+		return oldest, err
+	}
+	var sequence model.KitchenSequence
+	err = r.db.Where("restaurant_id = ?", restaurantID).First(&sequence).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 1, nil
+	}
+	return sequence.LastSeq + 1, err
+}
+
+func (r *kitchenRepository) Purge(before time.Time) (int64, error) {
+	result := r.db.Where("created_at < ?", before).Delete(&model.KitchenEvent{})
+	return result.RowsAffected, result.Error
+}
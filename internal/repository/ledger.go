@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/money"
+
+	"gorm.io/gorm"
+)
+
+// LedgerRepository stores the ledger accounts and the journal entries moving money between them
+type LedgerRepository interface {
+	// Account returns the account of the type and owner, opening it on first use
+	Account(accountType string, ownerID uint) (model.LedgerAccount, error)
+	CreateEntry(entry *model.JournalEntry) error
+	ListAccounts() ([]model.LedgerAccount, error)
+	// Balances returns credits minus debits of every account that has lines, by account id
+	Balances() (map[uint]money.Amount, error)
+	Totals() (debit money.Amount, credit money.Amount, err error)
+	UnbalancedEntries() ([]model.LedgerEntryImbalance, error)
+	// UserWallets and RestaurantWallets return the stored wallet balances that are not zero, by owner id
+	UserWallets() (map[uint]money.Amount, error)
+	RestaurantWallets() (map[uint]money.Amount, error)
+}
+
+type ledgerRepository struct {
+	db *gorm.DB
+}
+
+func NewLedgerRepository(db *gorm.DB) LedgerRepository {
+	return &ledgerRepository{db: db}
+}
+
+func (r *ledgerRepository) Account(accountType string, ownerID uint) (model.LedgerAccount, error) {
+	account := model.LedgerAccount{Type: accountType, OwnerID: ownerID}
+	err := r.db.Where("type = ? AND owner_id = ?", accountType, ownerID).FirstOrCreate(&account).Error
+	return account, err
+}
+
+func (r *ledgerRepository) CreateEntry(entry *model.JournalEntry) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *ledgerRepository) ListAccounts() ([]model.LedgerAccount, error) {
+	var accounts []model.LedgerAccount
+	err := r.db.Order("id").Find(&accounts).Error
+	return accounts, err
+}
+
+func (r *ledgerRepository) Balances() (map[uint]money.Amount, error) {
+	var rows []struct {
+		AccountID uint
+		Balance   money.Amount
+	}
+	err := r.db.Model(&model.JournalLine{}).
+		Select("account_id, SUM(credit) - SUM(debit) AS balance").
+		Group("account_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	balances := make(map[uint]money.Amount, len(rows))
+	for _, row := range rows {
+		balances[row.AccountID] = row.Balance
+	}
+	return balances, nil
+}
+
+func (r *ledgerRepository) Totals() (money.Amount, money.Amount, error) {
+	var totals struct {
+		Debit  money.Amount
+		Credit money.Amount
+	}
+	err := r.db.Model(&model.JournalLine{}).
+		Select("COALESCE(SUM(debit), 0) AS debit, COALESCE(SUM(credit), 0) AS credit").
+		Scan(&totals).Error
+	return totals.Debit, totals.Credit, err
+}
+
+func (r *ledgerRepository) UnbalancedEntries() ([]model.LedgerEntryImbalance, error) {
+	var entries []model.LedgerEntryImbalance
+	err := r.db.Model(&model.JournalLine{}).
+		Select("entry_id, SUM(debit) AS debit, SUM(credit) AS credit").
+		Group("entry_id").
+		Having("SUM(debit) <> SUM(credit)").
+		Scan(&entries).Error
+	return entries, err
+}
+
+func (r *ledgerRepository) UserWallets() (map[uint]money.Amount, error) {
+	return r.wallets(&model.User{})
+}
+
+func (r *ledgerRepository) RestaurantWallets() (map[uint]money.Amount, error) {
+	return r.wallets(&model.Restaurant{})
+}
+
+// wallets includes soft deleted rows, their money is still on the ledger
+func (r *ledgerRepository) wallets(table interface{}) (map[uint]money.Amount, error) {
+	var rows []struct {
+		ID           uint
+		WalletAmount money.Amount
+	}
+	err := r.db.Unscoped().Model(table).
+		Select("id, wallet_amount").
+		Where("wallet_amount <> 0").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	wallets := make(map[uint]money.Amount, len(rows))
+	for _, row := range rows {
+		wallets[row.ID] = row.WalletAmount
+	}
+	return wallets, nil
+}
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"foodbuddy/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OrderRepository stores orders, their items and the delivery verification codes
+type OrderRepository interface {
+	Create(order *model.Order) error
+	FindByID(orderID string) (model.Order, error)
+	Update(order *model.Order) error
+	SetPaymentStatus(orderID string, status string) error
+	// ListUnpaid returns the online orders placed before the time that are still pending or failed
+	ListUnpaid(before time.Time) ([]model.Order, error)
+	// Expire marks an unpaid online order expired, it reports false when the order was paid
+	// or expired in the meantime
+	Expire(orderID string) (bool, error)
+
+	CreateItem(item *model.OrderItem) error
+	FindItem(orderID string, productID uint) (model.OrderItem, error)
+	ListItems(orderID string) ([]model.OrderItem, error)
+	ListItemsByStatus(orderID string, statuses ...string) ([]model.OrderItem, error)
+	ListItemsByRestaurant(restaurantID uint) ([]model.OrderItem, error)
+	ListItemsByRestaurantAndStatus(restaurantID uint, status string) ([]model.OrderItem, error)
+	ListItemsByUserOrder(userID uint, orderID string) ([]model.OrderItem, error)
+	ListItemsByProduct(productID uint) ([]model.OrderItem, error)
+	// ListItemsWaitingSince returns the order items in the status that have been in it since
+	// before the time, by the last time their history shows them moving into it
+	ListItemsWaitingSince(status string, before time.Time) ([]model.OrderItem, error)
+	CountItemsByUserAndStatus(userID uint, status string) (int64, error)
+	UpdateItem(item *model.OrderItem) error
+	// MoveItem changes the status of an order item that is still in the from status, it reports
+	// false when the item moved in the meantime
+	MoveItem(orderID string, productID uint, from string, to string) (bool, error)
+	SetItemRating(orderID string, productID uint, rating float64) error
+	// SetItemCookingRequest changes the cooking request of an order item that is still in the
+	// status, it reports false when the item moved on in the meantime
+	SetItemCookingRequest(orderID string, productID uint, status string, cookingRequest string) (bool, error)
+
+	FindDeliveryVerification(orderID string) (model.DeliveryVerification, error)
+	SaveDeliveryVerification(verification *model.DeliveryVerification) error
+
+	AddStatusHistory(entry *model.OrderStatusHistory) error
+	// ListStatusHistory returns the steps of the order's items, oldest first
+	ListStatusHistory(orderID string) ([]model.OrderStatusHistory, error)
+}
+
+type orderRepository struct {
+	db *gorm.DB
+}
+
+func NewOrderRepository(db *gorm.DB) OrderRepository {
+	return &orderRepository{db: db}
+}
+
+func (r *orderRepository) Create(order *model.Order) error {
+	return r.db.Create(order).Error
+}
+
+func (r *orderRepository) FindByID(orderID string) (model.Order, error) {
+	var order model.Order
+	err := r.db.Where("order_id = ?", orderID).First(&order).Error
+	return order, translate(err)
+}
+
+func (r *orderRepository) Update(order *model.Order) error {
+	return r.db.Where("order_id = ?", order.OrderID).Updates(order).Error
+}
+
+func (r *orderRepository) SetPaymentStatus(orderID string, status string) error {
+	return r.db.Model(&model.Order{}).Where("order_id = ?", orderID).Update("payment_status", status).Error
+}
+
+func (r *orderRepository) ListUnpaid(before time.Time) ([]model.Order, error) {
+	var orders []model.Order
+	err := r.db.Where("payment_method = ? AND payment_status IN ? AND ordered_at < ?",
+		model.OnlinePayment, []string{model.OnlinePaymentPending, model.OnlinePaymentFailed}, before).
+		Order("ordered_at").Find(&orders).Error
+	return orders, err
+}
+
+func (r *orderRepository) Expire(orderID string) (bool, error) {
+	result := r.db.Model(&model.Order{}).
+		Where("order_id = ? AND payment_method = ? AND payment_status IN ?",
+			orderID, model.OnlinePayment, []string{model.OnlinePaymentPending, model.OnlinePaymentFailed}).
+		Update("payment_status", model.OnlinePaymentExpired)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *orderRepository) CreateItem(item *model.OrderItem) error {
+	return r.db.Create(item).Error
+}
+
+func (r *orderRepository) FindItem(orderID string, productID uint) (model.OrderItem, error) {
+	var item model.OrderItem
+	err := r.db.Where("order_id = ? AND product_id = ?", orderID, productID).First(&item).Error
+	return item, translate(err)
+}
+
+func (r *orderRepository) ListItems(orderID string) ([]model.OrderItem, error) {
+	var items []model.OrderItem
+	err := r.db.Where("order_id = ?", orderID).Find(&items).Error
+	return items, err
+}
+
+func (r *orderRepository) ListItemsByStatus(orderID string, statuses ...string) ([]model.OrderItem, error) {
+	var items []model.OrderItem
+	err := r.db.Where("order_id = ? AND order_status IN ?", orderID, statuses).Find(&items).Error
+	return items, err
+}
+
+func (r *orderRepository) ListItemsByRestaurant(restaurantID uint) ([]model.OrderItem, error) {
+	var items []model.OrderItem
+	err := r.db.Where("restaurant_id = ?", restaurantID).Find(&items).Error
+	return items, err
+}
+
+func (r *orderRepository) ListItemsByRestaurantAndStatus(restaurantID uint, status string) ([]model.OrderItem, error) {
+	var items []model.OrderItem
+	err := r.db.Where("restaurant_id = ? AND order_status = ?", restaurantID, status).Find(&items).Error
+	return items, err
+}
+
+func (r *orderRepository) ListItemsByUserOrder(userID uint, orderID string) ([]model.OrderItem, error) {
+	var items []model.OrderItem
+	err := r.db.Where("user_id = ? AND order_id = ?", userID, orderID).Find(&items).Error
+	return items, err
+}
+
+func (r *orderRepository) ListItemsByProduct(productID uint) ([]model.OrderItem, error) {
+	var items []model.OrderItem
+	err := r.db.Where("product_id = ?", productID).Find(&items).Error
+	return items, err
+}
+
+func (r *orderRepository) ListItemsWaitingSince(status string, before time.Time) ([]model.OrderItem, error) {
+	var items []model.OrderItem
+	moved := r.db.Model(&model.OrderStatusHistory{}).Select("1").
+		Where("order_status_histories.order_id = order_items.order_id AND order_status_histories.product_id = order_items.product_id").
+		Where("order_status_histories.to_status = ? AND order_status_histories.created_at >= ?", status, before)
+	err := r.db.Where("order_status = ? AND NOT EXISTS (?)", status, moved).Order("order_id").Find(&items).Error
+	return items, err
+}
+
+func (r *orderRepository) CountItemsByUserAndStatus(userID uint, status string) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.OrderItem{}).Where("user_id = ? AND order_status = ?", userID, status).Count(&count).Error
+	return count, err
+}
+
+func (r *orderRepository) UpdateItem(item *model.OrderItem) error {
+	return r.db.Where("order_id = ? AND product_id = ?", item.OrderID, item.ProductID).Updates(item).Error
+}
+
+func (r *orderRepository) MoveItem(orderID string, productID uint, from string, to string) (bool, error) {
+	result := r.db.Model(&model.OrderItem{}).
+		Where("order_id = ? AND product_id = ? AND order_status = ?", orderID, productID, from).
+		Update("order_status", to)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *orderRepository) SetItemRating(orderID string, productID uint, rating float64) error {
+	return r.db.Model(&model.OrderItem{}).Where("order_id = ? AND product_id = ?", orderID, productID).Update("order_rating", rating).Error
+}
+
+func (r *orderRepository) SetItemCookingRequest(orderID string, productID uint, status string, cookingRequest string) (bool, error) {
+	result := r.db.Model(&model.OrderItem{}).
+		Where("order_id = ? AND product_id = ? AND order_status = ?", orderID, productID, status).
+		Update("cooking_request", cookingRequest)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *orderRepository) FindDeliveryVerification(orderID string) (model.DeliveryVerification, error) {
+	var verification model.DeliveryVerification
+	err := r.db.Where("order_id = ?", orderID).First(&verification).Error
+	return verification, translate(err)
+}
+
+// SaveDeliveryVerification inserts the first code for an order and overwrites it afterwards,
+// the table has no primary key so a plain Save would keep adding rows
+func (r *orderRepository) SaveDeliveryVerification(verification *model.DeliveryVerification) error {
+	var count int64
+	if err := r.db.Model(&model.DeliveryVerification{}).Where("order_id = ?", verification.OrderID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return r.db.Create(verification).Error
+	}
+	return r.db.Model(&model.DeliveryVerification{}).Where("order_id = ?", verification.OrderID).Updates(map[string]interface{}{
+		"user_id":      verification.UserID,
+		"otp":          verification.OTP,
+		"last_sent_at": verification.LastSentAT,
+	}).Error
+}
+
+func (r *orderRepository) AddStatusHistory(entry *model.OrderStatusHistory) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *orderRepository) ListStatusHistory(orderID string) ([]model.OrderStatusHistory, error) {
+	var history []model.OrderStatusHistory
+	err := r.db.Where("order_id = ?", orderID).Order("id").Find(&history).Error
+	return history, err
+}
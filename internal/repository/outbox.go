@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"foodbuddy/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OutboxRepository stores the messages waiting to be delivered by the outbox worker
+type OutboxRepository interface {
+	Enqueue(msg *model.OutboxMessage) error
+	// Claim leases up to limit messages that are due, pending or abandoned by a worker whose
+	// lease ran out. a message is only handed to one caller even with several instances running,
+	// claiming it counts an attempt so a message that keeps its worker from finishing runs out too
+	Claim(now time.Time, limit int, lease time.Duration) ([]model.OutboxMessage, error)
+	MarkSent(id uint, at time.Time) error
+	// MarkFailed records a failed attempt, status is pending with the next attempt time or dead
+	MarkFailed(id uint, attempts int, status string, nextAttemptAt time.Time, lastError string) error
+	FindByID(id uint) (model.OutboxMessage, error)
+	List(status string, limit int) ([]model.OutboxMessage, error)
+	// Requeue makes a dead message pending again with a fresh set of attempts
+	Requeue(id uint, now time.Time) (bool, error)
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) Enqueue(msg *model.OutboxMessage) error {
+	if msg.Status == "" {
+		msg.Status = model.OutboxPending
+	}
+	if msg.NextAttemptAt.IsZero() {
+		msg.NextAttemptAt = time.Now()
+	}
+	return r.db.Create(msg).Error
+}
+
+func (r *outboxRepository) Claim(now time.Time, limit int, lease time.Duration) ([]model.OutboxMessage, error) {
+	var due []model.OutboxMessage
+	err := r.db.Where("(status = ? AND next_attempt_at <= ?) OR (status = ? AND locked_until < ?)",
+		model.OutboxPending, now, model.OutboxProcessing, now).
+		Order("next_attempt_at").
+		Limit(limit).
+		Find(&due).Error
+	if err != nil {
+		return nil, err
+	}
+
+	lockedUntil := now.Add(lease)
+	claimed := make([]model.OutboxMessage, 0, len(due))
+	for _, msg := range due {
+		// the update only matches while the row is still in the state it was read in,
+		// a concurrent worker that claimed it first makes it affect no rows
+		query := r.db.Model(&model.OutboxMessage{}).Where("id = ? AND status = ?", msg.ID, msg.Status)
+		if msg.Status == model.OutboxProcessing {
+			query = query.Where("locked_until < ?", now)
+		}
+		result := query.Updates(map[string]interface{}{
+			"status":       model.OutboxProcessing,
+			"locked_until": lockedUntil,
+			"attempts":     gorm.Expr("attempts + 1"),
+		})
+		if result.Error != nil {
+			return claimed, result.Error
+		}
+		if result.RowsAffected == 1 {
+			msg.Status, msg.LockedUntil = model.OutboxProcessing, &lockedUntil
+			msg.Attempts++
+			claimed = append(claimed, msg)
+		}
+	}
+	return claimed, nil
+}
+
+func (r *outboxRepository) MarkSent(id uint, at time.Time) error {
+	return r.db.Model(&model.OutboxMessage{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       model.OutboxSent,
+		"sent_at":      at,
+		"locked_until": nil,
+		"last_error":   "",
+	}).Error
+}
+
+func (r *outboxRepository) MarkFailed(id uint, attempts int, status string, nextAttemptAt time.Time, lastError string) error {
+	return r.db.Model(&model.OutboxMessage{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":          status,
+		"attempts":        attempts,
+		"next_attempt_at": nextAttemptAt,
+		"last_error":      lastError,
+		"locked_until":    nil,
+	}).Error
+}
+
+func (r *outboxRepository) FindByID(id uint) (model.OutboxMessage, error) {
+	var msg model.OutboxMessage
+	err := r.db.Where("id = ?", id).First(&msg).Error
+	return msg, translate(err)
+}
+
+func (r *outboxRepository) List(status string, limit int) ([]model.OutboxMessage, error) {
+	var messages []model.OutboxMessage
+	query := r.db.Order("id DESC").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&messages).Error
+	return messages, err
+}
+
+func (r *outboxRepository) Requeue(id uint, now time.Time) (bool, error) {
+	result := r.db.Model(&model.OutboxMessage{}).Where("id = ? AND status = ?", id, model.OutboxDead).Updates(map[string]interface{}{
+		"status":          model.OutboxPending,
+		"attempts":        0,
+		"next_attempt_at": now,
+	})
+	return result.RowsAffected == 1, result.Error
+}
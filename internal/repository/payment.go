@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/money"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PaymentRepository stores the payment attempts made against orders
+type PaymentRepository interface {
+	Create(payment *model.Payment) error
+	ListByOrder(orderID string) ([]model.Payment, error)
+	ListByOrderAndStatus(orderID string, status string) ([]model.Payment, error)
+	FindByOrderAndStatus(orderID string, status string) (model.Payment, error)
+	FindByReference(gateway string, reference string) (model.Payment, error)
+	FindByPaymentID(gateway string, paymentID string) (model.Payment, error)
+	// ListOpen returns the payments still pending, and the failed ones of orders that still
+	// wait for a payment or expired after being placed since the time, a gateway can capture
+	// a payment after reporting it failed
+	ListOpen(expiredSince time.Time) ([]model.Payment, error)
+	// Settle records the confirmed payment of a checkout, it reports false when the
+	// checkout was already confirmed so the order is settled only once
+	Settle(gateway string, reference string, payment *model.Payment) (bool, error)
+	// Fail marks the payment of a checkout failed unless it was confirmed already
+	Fail(gateway string, reference string) error
+	// SetRefunded moves the refunded total of a checkout from previous to refunded, it
+	// reports false when another refund changed the total in the meantime
+	SetRefunded(gateway string, reference string, previous money.Amount, refunded money.Amount, status string) (bool, error)
+}
+
+type paymentRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepository(db *gorm.DB) PaymentRepository {
+	return &paymentRepository{db: db}
+}
+
+func (r *paymentRepository) Create(payment *model.Payment) error {
+	return r.db.Create(payment).Error
+}
+
+func (r *paymentRepository) ListByOrder(orderID string) ([]model.Payment, error) {
+	var payments []model.Payment
+	err := r.db.Where("order_id = ?", orderID).Find(&payments).Error
+	return payments, err
+}
+
+func (r *paymentRepository) ListByOrderAndStatus(orderID string, status string) ([]model.Payment, error) {
+	var payments []model.Payment
+	err := r.db.Where("order_id = ? AND payment_status = ?", orderID, status).Find(&payments).Error
+	return payments, err
+}
+
+func (r *paymentRepository) FindByOrderAndStatus(orderID string, status string) (model.Payment, error) {
+	var payment model.Payment
+	err := r.db.Where("order_id = ? AND payment_status = ?", orderID, status).First(&payment).Error
+	return payment, translate(err)
+}
+
+func (r *paymentRepository) FindByReference(gateway string, reference string) (model.Payment, error) {
+	var payment model.Payment
+	err := r.db.Where("payment_gateway = ? AND gateway_reference = ?", gateway, reference).First(&payment).Error
+	return payment, translate(err)
+}
+
+func (r *paymentRepository) Settle(gateway string, reference string, payment *model.Payment) (bool, error) {
+	result := r.db.Model(&model.Payment{}).
+		Where("payment_gateway = ? AND gateway_reference = ? AND payment_status <> ?", gateway, reference, model.OnlinePaymentConfirmed).
+		Updates(payment)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *paymentRepository) Fail(gateway string, reference string) error {
+	return r.db.Model(&model.Payment{}).
+		Where("payment_gateway = ? AND gateway_reference = ? AND payment_status <> ?", gateway, reference, model.OnlinePaymentConfirmed).
+		Update("payment_status", model.OnlinePaymentFailed).Error
+}
+
+func (r *paymentRepository) FindByPaymentID(gateway string, paymentID string) (model.Payment, error) {
+	var payment model.Payment
+	err := r.db.Where("payment_gateway = ? AND gateway_payment_id = ?", gateway, paymentID).First(&payment).Error
+	return payment, translate(err)
+}
+
+func (r *paymentRepository) ListOpen(expiredSince time.Time) ([]model.Payment, error) {
+	var payments []model.Payment
+	waiting := r.db.Model(&model.Order{}).Select("order_id").
+		Where("payment_status IN ?", []string{model.OnlinePaymentPending, model.OnlinePaymentFailed}).
+		Or("payment_status = ? AND ordered_at >= ?", model.OnlinePaymentExpired, expiredSince)
+	err := r.db.Where("payment_status = ?", model.OnlinePaymentPending).
+		Or("payment_status = ? AND order_id IN (?)", model.OnlinePaymentFailed, waiting).
+		Find(&payments).Error
+	return payments, err
+}
+
+func (r *paymentRepository) SetRefunded(gateway string, reference string, previous money.Amount, refunded money.Amount, status string) (bool, error) {
+	result := r.db.Model(&model.Payment{}).
+		Where("payment_gateway = ? AND gateway_reference = ? AND refunded_amount = ?", gateway, reference, previous).
+		Updates(map[string]interface{}{"refunded_amount": refunded, "payment_status": status})
+	return result.RowsAffected > 0, result.Error
+}
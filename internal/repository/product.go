@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/money"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ProductRepository stores the products listed by restaurants
+type ProductRepository interface {
+	FindByID(id uint) (model.Product, error)
+	FindByName(restaurantID uint, name string) (model.Product, error)
+	List() ([]model.Product, error)
+	ListByRestaurant(restaurantID uint) ([]model.Product, error)
+	ListVeg() ([]model.Product, error)
+	ListWithOffers() ([]model.Product, error)
+	ListByPrice(descending bool) ([]model.Product, error)
+	ListCreatedSince(since time.Time) ([]model.Product, error)
+	CountByCategory(categoryID uint) (int64, error)
+	Create(product *model.Product) error
+	Update(product *model.Product) error
+	// SetStock sets the stock that isn't held for orders, the held stock is kept on top of it
+	SetStock(id uint, available uint) error
+	Delete(id uint) error
+	SetOfferAmount(id uint, offerAmount money.Amount) error
+	// TakeStock takes the quantity out of the stock that isn't held for orders in one conditional
+	// update, it returns ErrOutOfStock when there isn't that much left
+	TakeStock(id uint, quantity uint) error
+	ReturnStock(id uint, quantity uint) error
+	// Reserve holds the quantity of the product when that much of its stock isn't held yet, it
+	// reports false when there isn't
+	Reserve(id uint, quantity uint) (bool, error)
+	// Unreserve lets go of the quantity held, it returns ErrNotReserved when the product holds less
+	Unreserve(id uint, quantity uint) error
+	UpdateRating(id uint, ratingSum float64, ratingCount uint, averageRating float64) error
+}
+
+type productRepository struct {
+	db *gorm.DB
+}
+
+func NewProductRepository(db *gorm.DB) ProductRepository {
+	return &productRepository{db: db}
+}
+
+func (r *productRepository) FindByID(id uint) (model.Product, error) {
+	var product model.Product
+	err := r.db.Where("id = ?", id).First(&product).Error
+	return product, translate(err)
+}
+
+func (r *productRepository) FindByName(restaurantID uint, name string) (model.Product, error) {
+	var product model.Product
+	err := r.db.Where("name = ? AND restaurant_id = ?", name, restaurantID).First(&product).Error
+	return product, translate(err)
+}
+
+func (r *productRepository) List() ([]model.Product, error) {
+	var products []model.Product
+	err := r.db.Find(&products).Error
+	return products, err
+}
+
+func (r *productRepository) ListByRestaurant(restaurantID uint) ([]model.Product, error) {
+	var products []model.Product
+	err := r.db.Where("restaurant_id = ?", restaurantID).Find(&products).Error
+	return products, err
+}
+
+func (r *productRepository) ListVeg() ([]model.Product, error) {
+	var products []model.Product
+	err := r.db.Where("veg = ?", model.YES).Order("price ASC").Find(&products).Error
+	return products, err
+}
+
+func (r *productRepository) ListWithOffers() ([]model.Product, error) {
+	var products []model.Product
+	err := r.db.Where("offer_amount > ?", 0).Find(&products).Error
+	return products, err
+}
+
+func (r *productRepository) ListByPrice(descending bool) ([]model.Product, error) {
+	order := "price ASC"
+	if descending {
+		order = "price DESC"
+	}
+	var products []model.Product
+	err := r.db.Order(order).Find(&products).Error
+	return products, err
+}
+
+func (r *productRepository) ListCreatedSince(since time.Time) ([]model.Product, error) {
+	var products []model.Product
+	err := r.db.Where("created_at >= ?", since).Order("price ASC").Find(&products).Error
+	return products, err
+}
+
+func (r *productRepository) CountByCategory(categoryID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.Product{}).Where("category_id = ?", categoryID).Count(&count).Error
+	return count, err
+}
+
+func (r *productRepository) Create(product *model.Product) error {
+	return r.db.Create(product).Error
+}
+
+// Update leaves the stock alone, SetStock changes it and only the holds change the reserved stock
+func (r *productRepository) Update(product *model.Product) error {
+	return r.db.Where("id = ?", product.ID).Omit("stock_left", "reserved").Updates(product).Error
+}
+
+func (r *productRepository) SetStock(id uint, available uint) error {
+	return r.db.Model(&model.Product{}).Where("id = ?", id).
+		Update("stock_left", gorm.Expr("reserved + ?", available)).Error
+}
+
+func (r *productRepository) Delete(id uint) error {
+	return r.db.Delete(&model.Product{}, id).Error
+}
+
+func (r *productRepository) SetOfferAmount(id uint, offerAmount money.Amount) error {
+	return r.db.Model(&model.Product{}).Where("id = ?", id).Update("offer_amount", offerAmount).Error
+}
+
+func (r *productRepository) TakeStock(id uint, quantity uint) error {
+	result := r.db.Model(&model.Product{}).Where("id = ? AND stock_left >= reserved + ?", id, quantity).
+		Update("stock_left", gorm.Expr("stock_left - ?", quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrOutOfStock
+	}
+	return nil
+}
+
+func (r *productRepository) ReturnStock(id uint, quantity uint) error {
+	return r.db.Model(&model.Product{}).Where("id = ?", id).
+		Update("stock_left", gorm.Expr("stock_left + ?", quantity)).Error
+}
+
+func (r *productRepository) Reserve(id uint, quantity uint) (bool, error) {
+	result := r.db.Model(&model.Product{}).Where("id = ? AND stock_left >= reserved + ?", id, quantity).
+		Update("reserved", gorm.Expr("reserved + ?", quantity))
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *productRepository) Unreserve(id uint, quantity uint) error {
+	result := r.db.Model(&model.Product{}).Where("id = ? AND reserved >= ?", id, quantity).
+		Update("reserved", gorm.Expr("reserved - ?", quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotReserved
+	}
+	return nil
+}
+
+func (r *productRepository) UpdateRating(id uint, ratingSum float64, ratingCount uint, averageRating float64) error {
+	return r.db.Model(&model.Product{}).Where("id = ?", id).Updates(model.Product{RatingSum: ratingSum, RatingCount: ratingCount, AverageRating: averageRating}).Error
+}
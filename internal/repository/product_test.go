@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"errors"
+	"foodbuddy/internal/database"
+	"foodbuddy/internal/database/migrations"
+	"foodbuddy/internal/model"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB migrates a fresh sqlite file, the busy timeout makes concurrent writers wait for the lock
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "foodbuddy.db") + "?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)"
+	db, err := database.Open(database.Config{Driver: database.DriverSQLite, Name: name})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	db.Logger = logger.Discard
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestTakeStockNeverOversells(t *testing.T) {
+	const (
+		stock    = 10
+		quantity = 3
+		buyers   = 40
+	)
+	db := openTestDB(t)
+	repos := New(db)
+	product := model.Product{RestaurantID: 1, CategoryID: 1, Name: "biryani", StockLeft: stock}
+	if err := repos.Products.Create(&product); err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+
+	// watches the stock while the buyers take it, it must never go below zero
+	done := make(chan struct{})
+	var lowest atomic.Int64
+	lowest.Store(stock)
+	var watcher sync.WaitGroup
+	watcher.Add(1)
+	go func() {
+		defer watcher.Done()
+		for {
+			var left int64
+			if err := db.Raw("SELECT stock_left FROM products WHERE id = ?", product.ID).Scan(&left).Error; err == nil && left < lowest.Load() {
+				lowest.Store(left)
+			}
+			select {
+			case <-done:
+				return
+			default:
+			}
+		}
+	}()
+
+	start := make(chan struct{})
+	var taken, outOfStock atomic.Int64
+	var buyersDone sync.WaitGroup
+	errs := make(chan error, buyers)
+	for i := 0; i < buyers; i++ {
+		buyersDone.Add(1)
+		go func() {
+			defer buyersDone.Done()
+			<-start
+			err := repos.Products.TakeStock(product.ID, quantity)
+			switch {
+			case err == nil:
+				taken.Add(1)
+			case errors.Is(err, ErrOutOfStock):
+				outOfStock.Add(1)
+			default:
+				errs <- err
+			}
+		}()
+	}
+	close(start)
+	buyersDone.Wait()
+	close(done)
+	watcher.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("take stock: %v", err)
+	}
+	if got, want := taken.Load(), int64(stock/quantity); got != want {
+		t.Errorf("%d buyers took the stock, want %d", got, want)
+	}
+	if got, want := outOfStock.Load(), int64(buyers-stock/quantity); got != want {
+		t.Errorf("%d buyers were told the stock ran out, want %d", got, want)
+	}
+	stored, err := repos.Products.FindByID(product.ID)
+	if err != nil {
+		t.Fatalf("find product: %v", err)
+	}
+	if stored.StockLeft != stock%quantity {
+		t.Errorf("stock_left is %d, want %d", stored.StockLeft, stock%quantity)
+	}
+	if lowest.Load() < 0 {
+		t.Errorf("stock_left went down to %d", lowest.Load())
+	}
+}
+
+func TestSetStockKeepsHeldStock(t *testing.T) {
+	repos := New(openTestDB(t))
+	product := model.Product{RestaurantID: 1, CategoryID: 1, Name: "biryani", StockLeft: 10}
+	if err := repos.Products.Create(&product); err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	if reserved, err := repos.Products.Reserve(product.ID, 4); err != nil || !reserved {
+		t.Fatalf("reserve stock: %v, %v", reserved, err)
+	}
+
+	// the restaurant puts 3 on sale, the 4 held for a checkout stay held
+	if err := repos.Products.SetStock(product.ID, 3); err != nil {
+		t.Fatalf("set stock: %v", err)
+	}
+	stored, err := repos.Products.FindByID(product.ID)
+	if err != nil {
+		t.Fatalf("find product: %v", err)
+	}
+	if stored.StockLeft != 7 || stored.Reserved != 4 {
+		t.Errorf("product has %d in stock with %d reserved, want 7 with 4 reserved", stored.StockLeft, stored.Reserved)
+	}
+
+	if err := repos.Products.SetStock(product.ID, 0); err != nil {
+		t.Fatalf("set stock: %v", err)
+	}
+	if err := repos.Products.TakeStock(product.ID, 1); !errors.Is(err, ErrOutOfStock) {
+		t.Errorf("take stock returned %v, want %v", err, ErrOutOfStock)
+	}
+}
+
+func TestUnreserveMoreThanHeld(t *testing.T) {
+	repos := New(openTestDB(t))
+	product := model.Product{RestaurantID: 1, CategoryID: 1, Name: "biryani", StockLeft: 10}
+	if err := repos.Products.Create(&product); err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	if reserved, err := repos.Products.Reserve(product.ID, 2); err != nil || !reserved {
+		t.Fatalf("reserve stock: %v, %v", reserved, err)
+	}
+
+	if err := repos.Products.Unreserve(product.ID, 3); !errors.Is(err, ErrNotReserved) {
+		t.Errorf("unreserve returned %v, want %v", err, ErrNotReserved)
+	}
+	if err := repos.Products.Unreserve(product.ID, 2); err != nil {
+		t.Errorf("unreserve: %v", err)
+	}
+	stored, err := repos.Products.FindByID(product.ID)
+	if err != nil {
+		t.Fatalf("find product: %v", err)
+	}
+	if stored.Reserved != 0 {
+		t.Errorf("product has %d reserved, want 0", stored.Reserved)
+	}
+}
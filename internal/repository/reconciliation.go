@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"foodbuddy/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ReconciliationRepository stores the payment reconciliation runs and their discrepancies
+type ReconciliationRepository interface {
+	CreateRun(run *model.ReconciliationRun) error
+	// FinishRun saves the counts and the finish time of the run
+	FinishRun(run *model.ReconciliationRun) error
+	FindRun(id uint) (model.ReconciliationRun, error)
+	ListRuns(limit int) ([]model.ReconciliationRun, error)
+	AddDiscrepancy(discrepancy *model.PaymentDiscrepancy) error
+	ListDiscrepancies(runID uint) ([]model.PaymentDiscrepancy, error)
+	// ListUnassigned returns the discrepancies recorded outside a run, by the payment
+	// callbacks and webhooks
+	ListUnassigned() ([]model.PaymentDiscrepancy, error)
+	// Assign adds a discrepancy recorded outside a run to the run, it reports false when
+	// another run took it first
+	Assign(id uint, runID uint) (bool, error)
+}
+
+type reconciliationRepository struct {
+	db *gorm.DB
+}
+
+func NewReconciliationRepository(db *gorm.DB) ReconciliationRepository {
+	return &reconciliationRepository{db: db}
+}
+
+func (r *reconciliationRepository) CreateRun(run *model.ReconciliationRun) error {
+	return r.db.Create(run).Error
+}
+
+func (r *reconciliationRepository) FinishRun(run *model.ReconciliationRun) error {
+	return r.db.Model(&model.ReconciliationRun{}).Where("id = ?", run.ID).Updates(map[string]interface{}{
+		"checked":       run.Checked,
+		"fixed":         run.Fixed,
+		"expired":       run.Expired,
+		"discrepancies": run.Discrepancies,
+		"finished_at":   run.FinishedAt,
+	}).Error
+}
+
+func (r *reconciliationRepository) FindRun(id uint) (model.ReconciliationRun, error) {
+	var run model.ReconciliationRun
+	err := r.db.Where("id = ?", id).First(&run).Error
+	return run, translate(err)
+}
+
+func (r *reconciliationRepository) ListRuns(limit int) ([]model.ReconciliationRun, error) {
+	var runs []model.ReconciliationRun
+	err := r.db.Order("started_at DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
+
+func (r *reconciliationRepository) AddDiscrepancy(discrepancy *model.PaymentDiscrepancy) error {
+	return r.db.Create(discrepancy).Error
+}
+
+func (r *reconciliationRepository) ListDiscrepancies(runID uint) ([]model.PaymentDiscrepancy, error) {
+	var discrepancies []model.PaymentDiscrepancy
+	err := r.db.Where("run_id = ?", runID).Order("id").Find(&discrepancies).Error
+	return discrepancies, err
+}
+
+func (r *reconciliationRepository) ListUnassigned() ([]model.PaymentDiscrepancy, error) {
+	var discrepancies []model.PaymentDiscrepancy
+	err := r.db.Where("run_id = ?", 0).Order("id").Find(&discrepancies).Error
+	return discrepancies, err
+}
+
+func (r *reconciliationRepository) Assign(id uint, runID uint) (bool, error) {
+	result := r.db.Model(&model.PaymentDiscrepancy{}).Where("id = ? AND run_id = ?", id, 0).Update("run_id", runID)
+	return result.RowsAffected > 0, result.Error
+}
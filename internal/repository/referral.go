@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"foodbuddy/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ReferralRepository stores who referred whom and whether the reward was claimed
+type ReferralRepository interface {
+	FindByUser(userID uint) (model.UserReferralHistory, error)
+	FindByCode(code string) (model.UserReferralHistory, error)
+	Create(history *model.UserReferralHistory) error
+	SetReferredBy(code string, referredBy string) error
+	ListReferredBy(code string) ([]model.UserReferralHistory, error)
+	ListUnclaimedReferredBy(code string) ([]model.UserReferralHistory, error)
+	MarkClaimed(code string) error
+	CountClaimed(code string) (int64, error)
+}
+
+type referralRepository struct {
+	db *gorm.DB
+}
+
+func NewReferralRepository(db *gorm.DB) ReferralRepository {
+	return &referralRepository{db: db}
+}
+
+func (r *referralRepository) FindByUser(userID uint) (model.UserReferralHistory, error) {
+	var history model.UserReferralHistory
+	err := r.db.Where("user_id = ?", userID).First(&history).Error
+	return history, translate(err)
+}
+
+func (r *referralRepository) FindByCode(code string) (model.UserReferralHistory, error) {
+	var history model.UserReferralHistory
+	err := r.db.Where("referral_code = ?", code).First(&history).Error
+	return history, translate(err)
+}
+
+func (r *referralRepository) Create(history *model.UserReferralHistory) error {
+	return r.db.Create(history).Error
+}
+
+func (r *referralRepository) SetReferredBy(code string, referredBy string) error {
+	return r.db.Model(&model.UserReferralHistory{}).Where("referral_code = ?", code).Update("referred_by", referredBy).Error
+}
+
+func (r *referralRepository) ListReferredBy(code string) ([]model.UserReferralHistory, error) {
+	var history []model.UserReferralHistory
+	err := r.db.Where("referred_by = ?", code).Find(&history).Error
+	return history, err
+}
+
+func (r *referralRepository) ListUnclaimedReferredBy(code string) ([]model.UserReferralHistory, error) {
+	var history []model.UserReferralHistory
+	err := r.db.Where("referred_by = ? AND refer_claimed = ?", code, false).Find(&history).Error
+	return history, err
+}
+
+func (r *referralRepository) MarkClaimed(code string) error {
+	return r.db.Model(&model.UserReferralHistory{}).Where("referred_by = ? AND refer_claimed = ?", code, false).Update("refer_claimed", true).Error
+}
+
+func (r *referralRepository) CountClaimed(code string) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.UserReferralHistory{}).Where("referred_by = ? AND refer_claimed = ?", code, true).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"foodbuddy/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefundRepository stores the refunds of cancelled order items
+type RefundRepository interface {
+	Create(refund *model.Refund) error
+	FindByID(id uint) (model.Refund, error)
+	FindByGatewayRefundID(gateway string, refundID string) (model.Refund, error)
+	ListByOrder(orderID string) ([]model.Refund, error)
+	// ListUnsent returns the gateway refunds created before the time that never got an id
+	// from the gateway, the gateway was not reached or the answer was lost
+	ListUnsent(before time.Time) ([]model.Refund, error)
+	// SetGatewayRefundID stores the gateway's id of a pending refund
+	SetGatewayRefundID(id uint, refundID string) error
+	// Finish moves a pending refund to processed or failed, it reports false when the
+	// refund was not pending anymore
+	Finish(id uint, status string, refundID string, reason string) (bool, error)
+}
+
+type refundRepository struct {
+	db *gorm.DB
+}
+
+func NewRefundRepository(db *gorm.DB) RefundRepository {
+	return &refundRepository{db: db}
+}
+
+func (r *refundRepository) Create(refund *model.Refund) error {
+	return r.db.Create(refund).Error
+}
+
+func (r *refundRepository) FindByID(id uint) (model.Refund, error) {
+	var refund model.Refund
+	err := r.db.Where("id = ?", id).First(&refund).Error
+	return refund, translate(err)
+}
+
+func (r *refundRepository) FindByGatewayRefundID(gateway string, refundID string) (model.Refund, error) {
+	var refund model.Refund
+	err := r.db.Where("payment_gateway = ? AND gateway_refund_id = ?", gateway, refundID).First(&refund).Error
+	return refund, translate(err)
+}
+
+func (r *refundRepository) ListByOrder(orderID string) ([]model.Refund, error) {
+	var refunds []model.Refund
+	err := r.db.Where("order_id = ?", orderID).Order("id").Find(&refunds).Error
+	return refunds, err
+}
+
+func (r *refundRepository) ListUnsent(before time.Time) ([]model.Refund, error) {
+	var refunds []model.Refund
+	err := r.db.Where("destination = ? AND status = ? AND gateway_refund_id = ? AND created_at < ?",
+		model.RefundToSource, model.RefundStatusPending, "", before).Find(&refunds).Error
+	return refunds, err
+}
+
+func (r *refundRepository) SetGatewayRefundID(id uint, refundID string) error {
+	return r.db.Model(&model.Refund{}).Where("id = ?", id).Update("gateway_refund_id", refundID).Error
+}
+
+func (r *refundRepository) Finish(id uint, status string, refundID string, reason string) (bool, error) {
+	updates := map[string]interface{}{"status": status, "failure_reason": reason}
+	if refundID != "" {
+		updates["gateway_refund_id"] = refundID
+	}
+	if status == model.RefundStatusProcessed {
+		updates["processed_at"] = time.Now()
+	}
+	result := r.db.Model(&model.Refund{}).Where("id = ? AND status = ?", id, model.RefundStatusPending).Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"foodbuddy/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ReportRepository runs the aggregate queries behind the sales reports
+type ReportRepository interface {
+	ProductSales(productID uint) (model.ProductSales, error)
+	BestSellingProducts() ([]model.BestProduct, error)
+	OrdersBetween(from string, till string, paymentStatus string, restaurantID uint) ([]model.Order, error)
+}
+
+type reportRepository struct {
+	db *gorm.DB
+}
+
+func NewReportRepository(db *gorm.DB) ReportRepository {
+	return &reportRepository{db: db}
+}
+
+func (r *reportRepository) ProductSales(productID uint) (model.ProductSales, error) {
+	var report model.ProductSales
+	err := r.db.Raw(`SELECT SUM(order_items.amount) AS total_amount,
+                        COUNT(order_items.amount) AS total_orders,
+                        AVG(order_items.order_rating) AS avg_rating,
+                        SUM(order_items.quantity) AS quantity
+                         FROM order_items
+                         JOIN orders ON order_items.order_id = orders.order_id
+                         WHERE order_items.product_id = ?
+                         AND (order_items.order_status = ? OR order_items.order_status IS NULL)`, productID, model.OrderStatusDelivered).Scan(&report).Error
+	return report, err
+}
+
+func (r *reportRepository) BestSellingProducts() ([]model.BestProduct, error) {
+	var products []model.BestProduct
+	err := r.db.Table("order_items").
+		Select("product_id, COUNT(product_id) AS total_sales").
+		Group("product_id").
+		Order("total_sales desc").
+		Find(&products).Error
+	return products, err
+}
+
+// OrdersBetween lists orders placed in [from, till] with the given payment status,
+// a zero restaurantID covers every restaurant
+func (r *reportRepository) OrdersBetween(from string, till string, paymentStatus string, restaurantID uint) ([]model.Order, error) {
+	var orders []model.Order
+	query := r.db.Where("ordered_at BETWEEN ? AND ? AND payment_status = ?", from, till, paymentStatus)
+	if restaurantID != 0 {
+		query = query.Where("restaurant_id = ?", restaurantID)
+	}
+	err := query.Find(&orders).Error
+	return orders, err
+}
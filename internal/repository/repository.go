@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned by every repository when the requested row doesn't exist
+var ErrNotFound = errors.New("record not found")
+
+// ErrOutOfStock is returned when a product doesn't have the stock asked for
+var ErrOutOfStock = errors.New("not enough stock left")
+
+// ErrNotReserved is returned when a product holds less stock than is let go of
+var ErrNotReserved = errors.New("not that much stock held")
+
+// Repositories groups the repositories used by the services, all of them share
+// the same database handle so they can take part in one transaction
+type Repositories struct {
+	Users           UserRepository
+	Auth            AuthRepository
+	Admins          AdminRepository
+	Sessions        SessionRepository
+	Restaurants     RestaurantRepository
+	Products        ProductRepository
+	Categories      CategoryRepository
+	Carts           CartRepository
+	Orders          OrderRepository
+	Payments        PaymentRepository
+	Wallets         WalletRepository
+	Ledger          LedgerRepository
+	Coupons         CouponRepository
+	Referrals       ReferralRepository
+	Reports         ReportRepository
+	Outbox          OutboxRepository
+	Webhooks        WebhookRepository
+	Reconciliations ReconciliationRepository
+	Idempotency     IdempotencyRepository
+	Refunds         RefundRepository
+	Kitchen         KitchenRepository
+	Reservations    ReservationRepository
+
+	transaction func(fn func(tx *Repositories) error) error
+	// committed collects the AfterCommit callbacks of the transaction the repositories are bound to
+	committed *[]func()
+}
+
+// New returns gorm backed repositories
+func New(db *gorm.DB) *Repositories {
+	repos := &Repositories{
+		Users:           NewUserRepository(db),
+		Auth:            NewAuthRepository(db),
+		Admins:          NewAdminRepository(db),
+		Sessions:        NewSessionRepository(db),
+		Restaurants:     NewRestaurantRepository(db),
+		Products:        NewProductRepository(db),
+		Categories:      NewCategoryRepository(db),
+		Carts:           NewCartRepository(db),
+		Orders:          NewOrderRepository(db),
+		Payments:        NewPaymentRepository(db),
+		Wallets:         NewWalletRepository(db),
+		Ledger:          NewLedgerRepository(db),
+		Coupons:         NewCouponRepository(db),
+		Referrals:       NewReferralRepository(db),
+		Reports:         NewReportRepository(db),
+		Outbox:          NewOutboxRepository(db),
+		Webhooks:        NewWebhookRepository(db),
+		Reconciliations: NewReconciliationRepository(db),
+		Idempotency:     NewIdempotencyRepository(db),
+		Refunds:         NewRefundRepository(db),
+		Kitchen:         NewKitchenRepository(db),
+		Reservations:    NewReservationRepository(db),
+	}
+	repos.transaction = func(fn func(tx *Repositories) error) error {
+		// a nested transaction hands its callbacks to the outer one, they wait for the real commit
+		outer := repos.committed != nil
+		committed := repos.committed
+		if !outer {
+			committed = &[]func(){}
+		}
+		before := len(*committed)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			bound := New(tx)
+			bound.committed = committed
+			return fn(bound)
+		})
+		if err != nil {
+			*committed = (*committed)[:before]
+			return err
+		}
+		if !outer {
+			for _, callback := range *committed {
+				callback()
+			}
+		}
+		return nil
+	}
+	return repos
+}
+
+// Transaction runs fn with repositories bound to a single database transaction,
+// returning an error from fn rolls back every write made through tx.
+// repositories built by hand (fakes in tests) simply run fn with themselves
+func (r *Repositories) Transaction(fn func(tx *Repositories) error) error {
+	if r.transaction == nil {
+		return fn(r)
+	}
+	return r.transaction(fn)
+}
+
+// AfterCommit runs fn once the transaction the repositories are bound to commits and drops it
+// when the transaction rolls back. outside a transaction fn runs right away
+func (r *Repositories) AfterCommit(fn func()) {
+	if r.committed == nil {
+		fn()
+		return
+	}
+	*r.committed = append(*r.committed, fn)
+}
+
+func translate(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"foodbuddy/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReservationRepository stores the stock held for the online orders waiting for their payment
+type ReservationRepository interface {
+	Create(reservation *model.StockReservation) error
+	ListByOrder(orderID string) ([]model.StockReservation, error)
+	// ListExpired returns up to limit holds that expired before the time, oldest first
+	ListExpired(before time.Time, limit int) ([]model.StockReservation, error)
+	// ExpireOrder sets the holds of the order to expire at the time
+	ExpireOrder(orderID string, at time.Time) error
+	// Delete removes a hold, it reports false when the hold was released in the meantime
+	Delete(id uint) (bool, error)
+	// DeleteExpired removes a hold that expired before the time, it reports false when the
+	// hold was released or extended in the meantime
+	DeleteExpired(id uint, before time.Time) (bool, error)
+}
+
+type reservationRepository struct {
+	db *gorm.DB
+}
+
+func NewReservationRepository(db *gorm.DB) ReservationRepository {
+	return &reservationRepository{db: db}
+}
+
+func (r *reservationRepository) Create(reservation *model.StockReservation) error {
+	return r.db.Create(reservation).Error
+}
+
+func (r *reservationRepository) ListByOrder(orderID string) ([]model.StockReservation, error) {
+	var reservations []model.StockReservation
+	err := r.db.Where("order_id = ?", orderID).Order("id").Find(&reservations).Error
+	return reservations, err
+}
+
+func (r *reservationRepository) ListExpired(before time.Time, limit int) ([]model.StockReservation, error) {
+	var reservations []model.StockReservation
+	err := r.db.Where("expires_at < ?", before).Order("expires_at").Limit(limit).Find(&reservations).Error
+	return reservations, err
+}
+
+func (r *reservationRepository) ExpireOrder(orderID string, at time.Time) error {
+	return r.db.Model(&model.StockReservation{}).Where("order_id = ?", orderID).Update("expires_at", at).Error
+}
+
+func (r *reservationRepository) Delete(id uint) (bool, error) {
+	result := r.db.Where("id = ?", id).Delete(&model.StockReservation{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *reservationRepository) DeleteExpired(id uint, before time.Time) (bool, error) {
+	result := r.db.Where("id = ? AND expires_at < ?", id, before).Delete(&model.StockReservation{})
+	return result.RowsAffected > 0, result.Error
+}
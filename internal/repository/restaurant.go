@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"foodbuddy/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// RestaurantRepository stores restaurant accounts
+type RestaurantRepository interface {
+	FindByID(id uint) (model.Restaurant, error)
+	FindByEmail(email string) (model.Restaurant, error)
+	Create(restaurant *model.Restaurant) error
+	List() ([]model.Restaurant, error)
+	SetBlocked(id uint, blocked bool) error
+	SetVerificationStatus(id uint, status string) error
+	UpdateProfile(id uint, profile model.RestaurantProfileUpdate) error
+	UpdateImage(id uint, imageURL string) error
+	UpdatePassword(email string, salt string, hashedPassword string) error
+	Delete(id uint) error
+}
+
+type restaurantRepository struct {
+	db *gorm.DB
+}
+
+func NewRestaurantRepository(db *gorm.DB) RestaurantRepository {
+	return &restaurantRepository{db: db}
+}
+
+func (r *restaurantRepository) FindByID(id uint) (model.Restaurant, error) {
+	var restaurant model.Restaurant
+	err := r.db.Where("id = ?", id).First(&restaurant).Error
+	return restaurant, translate(err)
+}
+
+func (r *restaurantRepository) FindByEmail(email string) (model.Restaurant, error) {
+	var restaurant model.Restaurant
+	err := r.db.Where("email = ?", email).First(&restaurant).Error
+	return restaurant, translate(err)
+}
+
+func (r *restaurantRepository) Create(restaurant *model.Restaurant) error {
+	return r.db.Create(restaurant).Error
+}
+
+func (r *restaurantRepository) List() ([]model.Restaurant, error) {
+	var restaurants []model.Restaurant
+	err := r.db.Find(&restaurants).Error
+	return restaurants, err
+}
+
+func (r *restaurantRepository) SetBlocked(id uint, blocked bool) error {
+	return r.db.Model(&model.Restaurant{}).Where("id = ?", id).UpdateColumn("blocked", blocked).Error
+}
+
+func (r *restaurantRepository) SetVerificationStatus(id uint, status string) error {
+	return r.db.Model(&model.Restaurant{}).Where("id = ?", id).Update("verification_status", status).Error
+}
+
+func (r *restaurantRepository) UpdateProfile(id uint, profile model.RestaurantProfileUpdate) error {
+	return r.db.Model(&model.Restaurant{}).Where("id = ?", id).Updates(profile).Error
+}
+
+func (r *restaurantRepository) UpdateImage(id uint, imageURL string) error {
+	return r.db.Model(&model.Restaurant{}).Where("id = ?", id).Update("image_url", imageURL).Error
+}
+
+func (r *restaurantRepository) UpdatePassword(email string, salt string, hashedPassword string) error {
+	return r.db.Model(&model.Restaurant{}).Where("email = ?", email).Updates(model.Restaurant{Salt: salt, HashedPassword: hashedPassword}).Error
+}
+
+func (r *restaurantRepository) Delete(id uint) error {
+	return r.db.Delete(&model.Restaurant{}, id).Error
+}
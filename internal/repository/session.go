@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"foodbuddy/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SessionRepository stores the signed in sessions and the access tokens revoked before they expired
+type SessionRepository interface {
+	Create(session *model.Session) error
+	// Rotate saves the session with its new tokens if its refresh token is still previousHash,
+	// it reports false when a concurrent refresh got there first
+	Rotate(session *model.Session, previousHash string) (bool, error)
+	FindByID(id string) (model.Session, error)
+	FindByRefreshHash(hash string) (model.Session, error)
+	FindByPreviousRefreshHash(hash string) (model.Session, error)
+	// ListActive returns the sessions of the account that are neither revoked nor expired
+	ListActive(email string, role string) ([]model.Session, error)
+	Revoke(id string, at time.Time) error
+
+	RevokeToken(jti string, expiresAt time.Time) error
+	IsTokenRevoked(jti string) (bool, error)
+	DeleteExpiredRevocations(before time.Time) error
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(session *model.Session) error {
+	return r.db.Create(session).Error
+}
+
+func (r *sessionRepository) Rotate(session *model.Session, previousHash string) (bool, error) {
+	result := r.db.Model(&model.Session{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", session.ID, previousHash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":    session.RefreshTokenHash,
+			"previous_refresh_hash": session.PreviousRefreshHash,
+			"access_token_id":       session.AccessTokenID,
+			"access_expires_at":     session.AccessExpiresAt,
+			"last_used_at":          session.LastUsedAt,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *sessionRepository) FindByID(id string) (model.Session, error) {
+	var session model.Session
+	err := r.db.Where("id = ?", id).First(&session).Error
+	return session, translate(err)
+}
+
+func (r *sessionRepository) FindByRefreshHash(hash string) (model.Session, error) {
+	var session model.Session
+	err := r.db.Where("refresh_token_hash = ?", hash).First(&session).Error
+	return session, translate(err)
+}
+
+func (r *sessionRepository) FindByPreviousRefreshHash(hash string) (model.Session, error) {
+	var session model.Session
+	err := r.db.Where("previous_refresh_hash = ?", hash).First(&session).Error
+	return session, translate(err)
+}
+
+func (r *sessionRepository) ListActive(email string, role string) ([]model.Session, error) {
+	var sessions []model.Session
+	err := r.db.Where("email = ? AND role = ? AND revoked_at IS NULL AND expires_at > ?", email, role, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *sessionRepository) Revoke(id string, at time.Time) error {
+	return r.db.Model(&model.Session{}).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", at).Error
+}
+
+func (r *sessionRepository) RevokeToken(jti string, expiresAt time.Time) error {
+	return r.db.Where("jti = ?", jti).FirstOrCreate(&model.RevokedToken{JTI: jti, ExpiresAt: expiresAt}).Error
+}
+
+func (r *sessionRepository) IsTokenRevoked(jti string) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *sessionRepository) DeleteExpiredRevocations(before time.Time) error {
+	return r.db.Where("expires_at < ?", before).Delete(&model.RevokedToken{}).Error
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"foodbuddy/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// UserRepository stores users along with their addresses and favourite products
+type UserRepository interface {
+	FindByID(id uint) (model.User, error)
+	FindByEmail(email string) (model.User, error)
+	Create(user *model.User) error
+	List() ([]model.User, error)
+	ListBlocked() ([]model.User, error)
+	SetBlocked(id uint, blocked bool) error
+	UpdateProfile(id uint, profile model.UpdateUserInformation) error
+	UpdatePicture(id uint, picture string) error
+	UpdatePassword(email string, salt string, hashedPassword string) error
+	SetReferralCode(id uint, code string) error
+
+	ListAddresses(userID uint) ([]model.Address, error)
+	FindAddress(userID uint, addressID uint) (model.Address, error)
+	FindAddressByID(addressID uint) (model.Address, error)
+	CreateAddress(address *model.Address) error
+	UpdateAddress(address *model.Address) error
+	DeleteAddress(address *model.Address) error
+
+	ListFavourites(userID uint) ([]model.FavouriteProduct, error)
+	FindFavourite(userID uint, productID uint) (model.FavouriteProduct, error)
+	AddFavourite(favourite *model.FavouriteProduct) error
+	RemoveFavourite(userID uint, productID uint) error
+}
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &userRepository{db: db}
+}
+
+func (r *userRepository) FindByID(id uint) (model.User, error) {
+	var user model.User
+	err := r.db.Where("id = ?", id).First(&user).Error
+	return user, translate(err)
+}
+
+func (r *userRepository) FindByEmail(email string) (model.User, error) {
+	var user model.User
+	err := r.db.Where("email = ?", email).First(&user).Error
+	return user, translate(err)
+}
+
+func (r *userRepository) Create(user *model.User) error {
+	return r.db.Create(user).Error
+}
+
+func (r *userRepository) List() ([]model.User, error) {
+	var users []model.User
+	err := r.db.Find(&users).Error
+	return users, err
+}
+
+func (r *userRepository) ListBlocked() ([]model.User, error) {
+	var users []model.User
+	err := r.db.Where("blocked = ?", true).Find(&users).Error
+	return users, err
+}
+
+func (r *userRepository) SetBlocked(id uint, blocked bool) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).UpdateColumn("blocked", blocked).Error
+}
+
+func (r *userRepository) UpdateProfile(id uint, profile model.UpdateUserInformation) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).Updates(&profile).Error
+}
+
+func (r *userRepository) UpdatePicture(id uint, picture string) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).Update("picture", picture).Error
+}
+
+func (r *userRepository) UpdatePassword(email string, salt string, hashedPassword string) error {
+	return r.db.Model(&model.User{}).Where("email = ?", email).Updates(model.User{Salt: salt, HashedPassword: hashedPassword}).Error
+}
+
+func (r *userRepository) SetReferralCode(id uint, code string) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).Update("referral_code", code).Error
+}
+
+func (r *userRepository) ListAddresses(userID uint) ([]model.Address, error) {
+	var addresses []model.Address
+	err := r.db.Where("user_id = ?", userID).Find(&addresses).Error
+	return addresses, err
+}
+
+func (r *userRepository) FindAddress(userID uint, addressID uint) (model.Address, error) {
+	var address model.Address
+	err := r.db.Where("user_id = ? AND address_id = ?", userID, addressID).First(&address).Error
+	return address, translate(err)
+}
+
+func (r *userRepository) FindAddressByID(addressID uint) (model.Address, error) {
+	var address model.Address
+	err := r.db.Where("address_id = ?", addressID).First(&address).Error
+	return address, translate(err)
+}
+
+func (r *userRepository) CreateAddress(address *model.Address) error {
+	return r.db.Create(address).Error
+}
+
+func (r *userRepository) UpdateAddress(address *model.Address) error {
+	return r.db.Updates(address).Error
+}
+
+func (r *userRepository) DeleteAddress(address *model.Address) error {
+	return r.db.Delete(address).Error
+}
+
+func (r *userRepository) ListFavourites(userID uint) ([]model.FavouriteProduct, error) {
+	var favourites []model.FavouriteProduct
+	err := r.db.Where("user_id = ?", userID).Find(&favourites).Error
+	return favourites, err
+}
+
+func (r *userRepository) FindFavourite(userID uint, productID uint) (model.FavouriteProduct, error) {
+	var favourite model.FavouriteProduct
+	err := r.db.Where("user_id = ? AND product_id = ?", userID, productID).First(&favourite).Error
+	return favourite, translate(err)
+}
+
+func (r *userRepository) AddFavourite(favourite *model.FavouriteProduct) error {
+	return r.db.Create(favourite).Error
+}
+
+func (r *userRepository) RemoveFavourite(userID uint, productID uint) error {
+	return r.db.Where("user_id = ? AND product_id = ?", userID, productID).Delete(&model.FavouriteProduct{}).Error
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/money"
+
+	"gorm.io/gorm"
+)
+
+// WalletRepository stores user and restaurant wallet balances and their history
+type WalletRepository interface {
+	// MoveUserBalance adds delta, negative to take money out, to the user's wallet in one
+	// conditional update and returns the new balance. it reports false when the wallet doesn't
+	// exist or would go below zero
+	MoveUserBalance(userID uint, delta money.Amount) (money.Amount, bool, error)
+	MoveRestaurantBalance(restaurantID uint, delta money.Amount) (money.Amount, bool, error)
+	CreateUserHistory(history *model.UserWalletHistory) error
+	CreateRestaurantHistory(history *model.RestaurantWalletHistory) error
+	ListUserHistory(userID uint) ([]model.UserWalletHistory, error)
+	ListUserHistoryByReason(userID uint, reason string) ([]model.UserWalletHistory, error)
+	ListRestaurantHistory(restaurantID uint) ([]model.RestaurantWalletHistory, error)
+}
+
+type walletRepository struct {
+	db *gorm.DB
+}
+
+func NewWalletRepository(db *gorm.DB) WalletRepository {
+	return &walletRepository{db: db}
+}
+
+func (r *walletRepository) MoveUserBalance(userID uint, delta money.Amount) (money.Amount, bool, error) {
+	return moveBalance(r.db, &model.User{}, userID, delta)
+}
+
+func (r *walletRepository) MoveRestaurantBalance(restaurantID uint, delta money.Amount) (money.Amount, bool, error) {
+	return moveBalance(r.db, &model.Restaurant{}, restaurantID, delta)
+}
+
+// moveBalance changes the wallet_amount of the wallet's row and reads it back. the update locks
+// the row until the transaction ends, so the balance read is the one it left
+func moveBalance(db *gorm.DB, wallet interface{}, id uint, delta money.Amount) (money.Amount, bool, error) {
+	result := db.Model(wallet).Where("id = ? AND wallet_amount + ? >= 0", id, delta).
+		Update("wallet_amount", gorm.Expr("wallet_amount + ?", delta))
+	if result.Error != nil || result.RowsAffected == 0 {
+		return 0, false, result.Error
+	}
+	var balance money.Amount
+	err := db.Model(wallet).Where("id = ?", id).Select("wallet_amount").Scan(&balance).Error
+	return balance, true, err
+}
+
+func (r *walletRepository) CreateUserHistory(history *model.UserWalletHistory) error {
+	return r.db.Create(history).Error
+}
+
+func (r *walletRepository) CreateRestaurantHistory(history *model.RestaurantWalletHistory) error {
+	return r.db.Create(history).Error
+}
+
+func (r *walletRepository) ListUserHistory(userID uint) ([]model.UserWalletHistory, error) {
+	var history []model.UserWalletHistory
+	err := r.db.Where("user_id = ?", userID).Find(&history).Error
+	return history, err
+}
+
+func (r *walletRepository) ListUserHistoryByReason(userID uint, reason string) ([]model.UserWalletHistory, error) {
+	var history []model.UserWalletHistory
+	err := r.db.Where("user_id = ? AND reason = ?", userID, reason).Find(&history).Error
+	return history, err
+}
+
+func (r *walletRepository) ListRestaurantHistory(restaurantID uint) ([]model.RestaurantWalletHistory, error) {
+	var history []model.RestaurantWalletHistory
+	err := r.db.Where("restaurant_id = ?", restaurantID).Find(&history).Error
+	return history, err
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"foodbuddy/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WebhookRepository records the webhook events that were handled
+type WebhookRepository interface {
+	// Record stores the event, it reports false when the event was recorded before
+	Record(provider string, eventID string, eventType string) (bool, error)
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) Record(provider string, eventID string, eventType string) (bool, error) {
+	event := model.WebhookEvent{Provider: provider, EventID: eventID, Type: eventType}
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&event)
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,324 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"foodbuddy/internal/mail"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/repository"
+	"foodbuddy/internal/totp"
+	"foodbuddy/internal/utils"
+	"net/http"
+	"strings"
+	"time"
+
+	passwordvalidator "github.com/wagslane/go-password-validator"
+)
+
+// AdminService manages admin accounts. admins are invited by a super admin, set a password
+// when accepting the invite and have to enroll an authenticator app before they can sign in,
+// every login asks for the password and a totp code or one of the recovery codes
+type AdminService struct {
+	repos     *repository.Repositories
+	templates *mail.Templates
+}
+
+func NewAdminService(repos *repository.Repositories, templates *mail.Templates) *AdminService {
+	return &AdminService{repos: repos, templates: templates}
+}
+
+var (
+	errInviteUsed = errors.New("invite was already accepted")
+	errCodeUsed   = errors.New("totp code was already used")
+)
+
+// TOTPEnrollment is what the admin needs to add the account to an authenticator app,
+// ProvisioningURI is meant to be shown as a qr code
+type TOTPEnrollment struct {
+	Email           string `json:"email"`
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// Invite creates an invite for a new admin and mails the link to accept it, only super admins can invite
+func (s *AdminService) Invite(inviterID uint, request model.AdminInviteRequest) (model.AdminInvite, error) {
+	inviter, err := s.repos.Admins.FindByID(inviterID)
+	if err != nil {
+		return model.AdminInvite{}, newError(http.StatusUnauthorized, "unauthorized request")
+	}
+	if inviter.Privilege != model.AdminPrivilegeSuper {
+		return model.AdminInvite{}, newError(http.StatusForbidden, "only super admins can invite admins")
+	}
+	if admin, err := s.repos.Admins.FindByEmail(request.Email); err == nil && admin.TOTPEnabled {
+		return model.AdminInvite{}, newError(http.StatusConflict, "admin already exists")
+	}
+
+	var invite model.AdminInvite
+	err = s.repos.Transaction(func(tx *repository.Repositories) error {
+		var token string
+		invite, token, err = createInvite(tx, request.Email, request.Privilege, inviter.ID)
+		if err != nil {
+			return err
+		}
+		err = enqueueEmail(tx, s.templates, invite.Email, "admin_invite", "", map[string]interface{}{
+			"InvitedBy": inviter.Email,
+			"AcceptURL": fmt.Sprintf("%v/api/v1/auth/admin/invite/accept", utils.GetEnvVariables().ServerURL),
+			"Token":     token,
+			"ExpiresAt": invite.ExpiresAt.Format(time.RFC1123),
+		})
+		if err != nil {
+			return internal("failed to queue the invite mail")
+		}
+		return nil
+	})
+	return invite, err
+}
+
+// BootstrapInvite creates a super admin invite without mailing it, the token is returned
+// instead. it is used from the command line to create the first admin
+func (s *AdminService) BootstrapInvite(email string) (string, error) {
+	_, token, err := createInvite(s.repos, email, model.AdminPrivilegeSuper, 0)
+	return token, err
+}
+
+func createInvite(tx *repository.Repositories, email string, privilege string, invitedBy uint) (model.AdminInvite, string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return model.AdminInvite{}, "", internal("failed to generate the invite token")
+	}
+	invite := model.AdminInvite{
+		Email:     email,
+		Privilege: privilege,
+		TokenHash: hashToken(token),
+		InvitedBy: invitedBy,
+		ExpiresAt: time.Now().Add(model.AdminInviteLifetime * time.Second),
+	}
+	if err := tx.Admins.CreateInvite(&invite); err != nil {
+		return invite, "", internal("failed to create the invite")
+	}
+	return invite, token, nil
+}
+
+// Invites lists every invite that was sent
+func (s *AdminService) Invites() ([]model.AdminInvite, error) {
+	invites, err := s.repos.Admins.ListInvites()
+	if err != nil {
+		return nil, internal("failed to fetch invites")
+	}
+	return invites, nil
+}
+
+// List returns every admin account
+func (s *AdminService) List() ([]model.Admin, error) {
+	admins, err := s.repos.Admins.List()
+	if err != nil {
+		return nil, internal("failed to fetch admins")
+	}
+	return admins, nil
+}
+
+// AcceptInvite sets up the admin account of the invite with a password and a new totp secret.
+// the account can't sign in until the totp enrollment is confirmed with ConfirmTOTP.
+// admins seeded before passwords existed are set up through an invite as well
+func (s *AdminService) AcceptInvite(request model.AcceptAdminInviteRequest) (TOTPEnrollment, error) {
+	invite, err := s.repos.Admins.FindInviteByTokenHash(hashToken(request.Token))
+	if err != nil {
+		return TOTPEnrollment{}, newError(http.StatusUnauthorized, "invalid invite token")
+	}
+	if invite.AcceptedAt != nil {
+		return TOTPEnrollment{}, newError(http.StatusConflict, "invite was already accepted")
+	}
+	if time.Now().After(invite.ExpiresAt) {
+		return TOTPEnrollment{}, badRequest("invite has expired, ask for a new one")
+	}
+	if request.Password != request.ConfirmPassword {
+		return TOTPEnrollment{}, badRequest("passwords doesn't match")
+	}
+	if err := passwordvalidator.Validate(request.Password, model.PasswordEntropy); err != nil {
+		return TOTPEnrollment{}, badRequest(err.Error())
+	}
+
+	salt, hash, err := hashPassword(request.Password)
+	if err != nil {
+		return TOTPEnrollment{}, internal("failed to hash the password")
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return TOTPEnrollment{}, internal("failed to generate the totp secret")
+	}
+
+	err = s.repos.Transaction(func(tx *repository.Repositories) error {
+		accepted, err := tx.Admins.AcceptInvite(invite.ID, time.Now())
+		if err != nil {
+			return err
+		}
+		if !accepted {
+			return errInviteUsed
+		}
+
+		admin, err := tx.Admins.FindByEmail(invite.Email)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		if admin.TOTPEnabled {
+			return errInviteUsed
+		}
+		admin.Email = invite.Email
+		admin.Name = request.Name
+		admin.Privilege = invite.Privilege
+		admin.Salt, admin.HashedPassword = salt, hash
+		admin.TOTPSecret, admin.TOTPEnabled, admin.TOTPLastStep = secret, false, 0
+		admin.InvitedBy = invite.InvitedBy
+		if admin.ID == 0 {
+			return tx.Admins.Create(&admin)
+		}
+		return tx.Admins.Save(&admin)
+	})
+	if errors.Is(err, errInviteUsed) {
+		return TOTPEnrollment{}, newError(http.StatusConflict, "invite was already accepted")
+	}
+	if err != nil {
+		return TOTPEnrollment{}, internal("failed to create the admin account")
+	}
+
+	return TOTPEnrollment{
+		Email:           invite.Email,
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(model.TOTPIssuer, invite.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP finishes the enrollment with a code from the authenticator app and returns the
+// recovery codes, they are only shown this once
+func (s *AdminService) ConfirmTOTP(request model.ConfirmAdminTOTPRequest) (model.Admin, []string, error) {
+	admin, err := s.repos.Admins.FindByEmail(request.Email)
+	if err != nil || admin.HashedPassword == "" || !checkPassword(admin.HashedPassword, admin.Salt, request.Password) {
+		return admin, nil, newError(http.StatusUnauthorized, "invalid email or password")
+	}
+	if admin.TOTPEnabled {
+		return admin, nil, newError(http.StatusConflict, "totp is already enabled")
+	}
+	step, ok := totp.Validate(admin.TOTPSecret, request.Code, time.Now(), admin.TOTPLastStep)
+	if !ok {
+		return admin, nil, newError(http.StatusUnauthorized, "invalid totp code")
+	}
+
+	var codes []string
+	err = s.repos.Transaction(func(tx *repository.Repositories) error {
+		if err := tx.Admins.EnableTOTP(admin.ID, step); err != nil {
+			return err
+		}
+		codes, err = replaceRecoveryCodes(tx, admin.ID)
+		return err
+	})
+	if err != nil {
+		return admin, nil, internal("failed to enable totp")
+	}
+	admin.TOTPEnabled = true
+	return admin, codes, nil
+}
+
+// Login checks the password and the second factor, either a totp code or an unused recovery code
+func (s *AdminService) Login(request model.AdminLoginRequest) (model.Admin, error) {
+	admin, err := s.repos.Admins.FindByEmail(request.Email)
+	if err != nil || admin.HashedPassword == "" || !checkPassword(admin.HashedPassword, admin.Salt, request.Password) {
+		return admin, newError(http.StatusUnauthorized, "invalid email or password")
+	}
+	if !admin.TOTPEnabled {
+		return admin, newError(http.StatusForbidden, "totp enrollment is not finished, confirm a code from the authenticator app first")
+	}
+
+	switch {
+	case request.Code != "":
+		step, ok := totp.Validate(admin.TOTPSecret, request.Code, time.Now(), admin.TOTPLastStep)
+		if !ok {
+			return admin, newError(http.StatusUnauthorized, "invalid totp code")
+		}
+		used, err := s.repos.Admins.UseTOTPStep(admin.ID, step)
+		if err != nil {
+			return admin, internal("failed to verify the totp code")
+		}
+		if !used {
+			return admin, newError(http.StatusUnauthorized, "invalid totp code")
+		}
+	case request.RecoveryCode != "":
+		used, err := s.repos.Admins.UseRecoveryCode(admin.ID, hashToken(normalizeRecoveryCode(request.RecoveryCode)), time.Now())
+		if err != nil {
+			return admin, internal("failed to verify the recovery code")
+		}
+		if !used {
+			return admin, newError(http.StatusUnauthorized, "invalid recovery code")
+		}
+	default:
+		return admin, badRequest("totp code or recovery code is required")
+	}
+	return admin, nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the admin, a current totp code is required
+func (s *AdminService) RegenerateRecoveryCodes(adminID uint, code string) ([]string, error) {
+	admin, err := s.repos.Admins.FindByID(adminID)
+	if err != nil {
+		return nil, notFound("admin not found")
+	}
+	step, ok := totp.Validate(admin.TOTPSecret, code, time.Now(), admin.TOTPLastStep)
+	if !ok {
+		return nil, newError(http.StatusUnauthorized, "invalid totp code")
+	}
+
+	var codes []string
+	err = s.repos.Transaction(func(tx *repository.Repositories) error {
+		used, err := tx.Admins.UseTOTPStep(admin.ID, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return errCodeUsed
+		}
+		codes, err = replaceRecoveryCodes(tx, admin.ID)
+		return err
+	})
+	if errors.Is(err, errCodeUsed) {
+		return nil, newError(http.StatusUnauthorized, "invalid totp code")
+	}
+	if err != nil {
+		return nil, internal("failed to generate recovery codes")
+	}
+	return codes, nil
+}
+
+// RecoveryCodesLeft returns the number of recovery codes the admin didn't use yet
+func (s *AdminService) RecoveryCodesLeft(adminID uint) (int64, error) {
+	count, err := s.repos.Admins.CountUnusedRecoveryCodes(adminID)
+	if err != nil {
+		return 0, internal("failed to count recovery codes")
+	}
+	return count, nil
+}
+
+// replaceRecoveryCodes generates a new set of recovery codes, only their hashes are stored
+func replaceRecoveryCodes(tx *repository.Repositories, adminID uint) ([]string, error) {
+	codes := make([]string, model.AdminRecoveryCodeCount)
+	hashes := make([]string, model.AdminRecoveryCodeCount)
+	for i := range codes {
+		random := make([]byte, 5)
+		if _, err := rand.Read(random); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(random))
+		codes[i] = code[:4] + "-" + code[4:]
+		hashes[i] = hashToken(code)
+	}
+	if err := tx.Admins.ReplaceRecoveryCodes(adminID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode accepts the codes as shown, in any case and with or without the dash
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
@@ -0,0 +1,460 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"foodbuddy/internal/mail"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/repository"
+	"foodbuddy/internal/utils"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	passwordvalidator "github.com/wagslane/go-password-validator"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type AuthService struct {
+	repos     *repository.Repositories
+	templates *mail.Templates
+}
+
+func NewAuthService(repos *repository.Repositories, templates *mail.Templates) *AuthService {
+	return &AuthService{repos: repos, templates: templates}
+}
+
+// Principal is who an authenticated request acts for
+type Principal struct {
+	ID      uint   `json:"id"`
+	Email   string `json:"email"`
+	Role    string `json:"role"`
+	Blocked bool   `json:"blocked"`
+	// Privilege is set for admins, only super admins can manage other admins
+	Privilege string `json:"privilege,omitempty"`
+	// SessionID is the session the token was issued for
+	SessionID string `json:"session_id"`
+	// TokenID and TokenExpiresAt are the jti and expiry of the access token, streams opened
+	// with the token end with it
+	TokenID        string    `json:"-"`
+	TokenExpiresAt time.Time `json:"-"`
+}
+
+// Principal loads the account behind a verified token
+func (s *AuthService) Principal(email string, role string) (Principal, error) {
+	principal := Principal{Email: email, Role: role}
+	switch role {
+	case model.UserRole:
+		user, err := s.repos.Users.FindByEmail(email)
+		if err != nil {
+			return principal, newError(http.StatusUnauthorized, "unauthorized request")
+		}
+		principal.ID, principal.Blocked = user.ID, user.Blocked
+	case model.RestaurantRole:
+		restaurant, err := s.repos.Restaurants.FindByEmail(email)
+		if err != nil {
+			return principal, newError(http.StatusUnauthorized, "unauthorized request")
+		}
+		principal.ID, principal.Blocked = restaurant.ID, restaurant.Blocked
+	case model.AdminRole:
+		admin, err := s.repos.Admins.FindByEmail(email)
+		if err != nil || !admin.TOTPEnabled {
+			return principal, newError(http.StatusUnauthorized, "unauthorized request")
+		}
+		principal.ID, principal.Privilege = admin.ID, admin.Privilege
+	default:
+		return principal, newError(http.StatusUnauthorized, "unauthorized request")
+	}
+	return principal, nil
+}
+
+// GoogleLogin signs the google account in, creating the user on the first login
+func (s *AuthService) GoogleLogin(info model.GoogleResponse) (model.User, error) {
+	user, err := s.repos.Users.FindByEmail(info.Email)
+	if errors.Is(err, repository.ErrNotFound) {
+		user = model.User{
+			Name:        info.Name,
+			Email:       info.Email,
+			LoginMethod: model.GoogleSSOMethod,
+			Picture:     info.Picture,
+			Blocked:     false,
+		}
+		//if no name is present on the response use the email as the name
+		if user.Name == "" {
+			user.Name = info.Email
+		}
+		if err := s.repos.Users.Create(&user); err != nil {
+			return user, internal("failed to create user through google sso")
+		}
+	} else if err != nil {
+		return user, internal("failed to fetch user information")
+	}
+
+	if user.Blocked {
+		return user, newError(http.StatusUnauthorized, "user is unauthorized to access")
+	}
+
+	ensureReferral(s.repos, user)
+	return user, nil
+}
+
+// EmailSignup creates the user and mails the verification link in the locale
+func (s *AuthService) EmailSignup(request model.EmailSignupRequest, locale string) (model.User, error) {
+	if request.Password != request.ConfirmPassword {
+		return model.User{}, badRequest("passwords doesn't match")
+	}
+	if err := passwordvalidator.Validate(request.Password, model.PasswordEntropy); err != nil {
+		return model.User{}, badRequest(err.Error())
+	}
+
+	salt, hash, err := hashPassword(request.Password)
+	if err != nil {
+		return model.User{}, internal("failed to hash the password")
+	}
+
+	_, err = s.repos.Users.FindByEmail(request.Email)
+	if err == nil {
+		return model.User{}, badRequest("user already exists")
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return model.User{}, internal("failed to retreive information from the database")
+	}
+
+	user := model.User{
+		Name:           request.Name,
+		Email:          request.Email,
+		PhoneNumber:    request.PhoneNumber,
+		HashedPassword: hash,
+		LoginMethod:    model.EmailLoginMethod,
+		Blocked:        false,
+		Salt:           salt,
+	}
+	err = s.repos.Transaction(func(tx *repository.Repositories) error {
+		if err := tx.Users.Create(&user); err != nil {
+			return internal("failed to create a new user")
+		}
+
+		verification := model.VerificationTable{
+			Email:              user.Email,
+			Role:               model.UserRole,
+			VerificationStatus: model.VerificationStatusPending,
+		}
+		if err := tx.Auth.CreateVerification(&verification); err != nil {
+			return internal("failed to process otp verification process")
+		}
+
+		if err := s.sendOTP(tx, user.Email, verification.OTPExpiry, model.UserRole, locale); err != nil {
+			return internal(err.Error())
+		}
+		return nil
+	})
+	return user, err
+}
+
+// EmailLogin checks the password, users that didn't verify their email get a new link in the locale
+func (s *AuthService) EmailLogin(request model.EmailLoginRequest, locale string) (model.User, error) {
+	user, err := s.repos.Users.FindByEmail(request.Email)
+	if err != nil {
+		return user, badRequest("invalid email or password")
+	}
+	if user.LoginMethod != model.EmailLoginMethod {
+		return user, badRequest("email uses another method for logging in, use google sso")
+	}
+	if user.Blocked {
+		return user, newError(http.StatusUnauthorized, "user is not authorized to access")
+	}
+	if !checkPassword(user.HashedPassword, user.Salt, request.Password) {
+		return user, badRequest("invalid email or password")
+	}
+
+	//if pending a new otp is sent, use /api/v1/verifyemail to verify it
+	verification, err := s.repos.Auth.FindVerification(user.Email, model.UserRole)
+	if err != nil {
+		return user, internal("failed to process email verification")
+	}
+	if verification.VerificationStatus != model.VerificationStatusVerified {
+		err := s.repos.Transaction(func(tx *repository.Repositories) error {
+			return s.sendOTP(tx, user.Email, verification.OTPExpiry, model.UserRole, locale)
+		})
+		if err != nil {
+			return user, newError(http.StatusTooManyRequests, err.Error())
+		}
+		return user, newError(http.StatusAccepted, "please complete your email verification")
+	}
+
+	ensureReferral(s.repos, user)
+	return user, nil
+}
+
+func (s *AuthService) RestaurantSignup(request model.RestaurantSignupRequest) (model.Restaurant, error) {
+	if request.Password != request.ConfirmPassword {
+		return model.Restaurant{}, badRequest("passwords doesn't match")
+	}
+	if err := passwordvalidator.Validate(request.Password, model.PasswordEntropy); err != nil {
+		return model.Restaurant{}, badRequest(err.Error())
+	}
+
+	_, err := s.repos.Auth.FindVerification(request.Email, model.RestaurantRole)
+	if err == nil {
+		return model.Restaurant{}, badRequest("restaurant email already exists")
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return model.Restaurant{}, internal("database error")
+	}
+
+	verification := model.VerificationTable{
+		Email:              request.Email,
+		Role:               model.RestaurantRole,
+		VerificationStatus: model.VerificationStatusPending,
+	}
+	if err := s.repos.Auth.CreateVerification(&verification); err != nil {
+		return model.Restaurant{}, internal("failed to create restaurant verification entry")
+	}
+
+	salt, hash, err := hashPassword(request.Password)
+	if err != nil {
+		return model.Restaurant{}, internal("failed to process the request")
+	}
+
+	restaurant := model.Restaurant{
+		Name:               request.Name,
+		Description:        request.Description,
+		Address:            request.Address,
+		Email:              request.Email,
+		PhoneNumber:        request.PhoneNumber,
+		ImageURL:           request.ImageURL,
+		CertificateURL:     request.CertificateURL,
+		VerificationStatus: model.VerificationStatusPending,
+		Blocked:            false,
+		Salt:               salt,
+		HashedPassword:     hash,
+	}
+	if err := s.repos.Restaurants.Create(&restaurant); err != nil {
+		return restaurant, internal("failed to save restaurant data")
+	}
+	return restaurant, nil
+}
+
+// RestaurantLogin checks the password, restaurants that didn't verify their email get a new link in the locale
+func (s *AuthService) RestaurantLogin(request model.RestaurantLoginRequest, locale string) (model.Restaurant, error) {
+	restaurant, err := s.repos.Restaurants.FindByEmail(request.Email)
+	if err != nil {
+		return restaurant, internal("Error fetching restaurant details")
+	}
+	if restaurant.Blocked {
+		return restaurant, newError(http.StatusUnauthorized, "Restaurant not authorized to access the route")
+	}
+	if !checkPassword(restaurant.HashedPassword, restaurant.Salt, request.Password) {
+		return restaurant, newError(http.StatusUnauthorized, "Invalid credentials")
+	}
+
+	verification, err := s.repos.Auth.FindVerification(request.Email, model.RestaurantRole)
+	if err != nil {
+		return restaurant, internal("Failed to fetch email verification status")
+	}
+	if verification.VerificationStatus != model.VerificationStatusVerified {
+		err := s.repos.Transaction(func(tx *repository.Repositories) error {
+			return s.sendOTP(tx, request.Email, verification.OTPExpiry, model.RestaurantRole, locale)
+		})
+		if err != nil {
+			return restaurant, newError(http.StatusAlreadyReported, err.Error())
+		}
+		return restaurant, newError(http.StatusUnauthorized, "Please verify your email to continue")
+	}
+	return restaurant, nil
+}
+
+// VerifyEmail checks the otp from the verification link
+func (s *AuthService) VerifyEmail(role string, email string, otp uint64) error {
+	if role != model.UserRole && role != model.RestaurantRole {
+		return badRequest("role should be either user or restaurant")
+	}
+	verification, err := s.repos.Auth.FindVerification(email, role)
+	if err != nil {
+		return notFound("failed to retrieve  information")
+	}
+	if verification.VerificationStatus == model.VerificationStatusVerified {
+		return newError(http.StatusIMUsed, "already verified")
+	}
+	if verification.OTP == 0 {
+		return newError(http.StatusAlreadyReported, "please login once again to verify your email")
+	}
+	if verification.OTPExpiry < uint64(time.Now().Unix()) {
+		return badRequest("otp has expired ,please login once again to verify your otp")
+	}
+	if verification.OTP != otp {
+		return badRequest("otp is invalid")
+	}
+
+	if err := s.repos.Auth.SetVerificationStatus(email, role, model.VerificationStatusVerified); err != nil {
+		return internal("failed to verify email, please try again")
+	}
+	return nil
+}
+
+// RequestPasswordReset mails a reset link to a user or restaurant in the locale
+func (s *AuthService) RequestPasswordReset(request model.Step1PasswordReset, locale string) error {
+	switch request.Role {
+	case model.UserRole:
+		user, err := s.repos.Users.FindByEmail(request.Email)
+		if err != nil || user.LoginMethod != model.EmailLoginMethod {
+			return newError(http.StatusUnauthorized, "unauthorized request")
+		}
+	case model.RestaurantRole:
+		if _, err := s.repos.Restaurants.FindByEmail(request.Email); err != nil {
+			return newError(http.StatusUnauthorized, "unauthorized request")
+		}
+	default:
+		return badRequest("role should be either user or restaurant")
+	}
+
+	resetToken := uuid.New().String()
+	expiryTime := time.Now().Unix() + 1*60
+
+	url := fmt.Sprintf("%v/api/v1/auth/passwordreset?email=%v&token=%v&role=%v", utils.GetEnvVariables().ServerURL, request.Email, resetToken, request.Role)
+	reset := model.PasswordReset{
+		Email:      request.Email,
+		Role:       request.Role,
+		ResetToken: resetToken,
+		Active:     model.YES,
+		ExpiryTime: uint(expiryTime),
+	}
+	return s.repos.Transaction(func(tx *repository.Repositories) error {
+		err := enqueueEmail(tx, s.templates, request.Email, "password_reset", locale, map[string]interface{}{
+			"URL":       url,
+			"ExpiresAt": time.Unix(expiryTime, 0).Format(time.RFC1123),
+		})
+		if err != nil {
+			return internal("failed to sent the password reset mail")
+		}
+
+		if _, err := tx.Auth.FindPasswordReset(request.Email, request.Role); err != nil {
+			err = tx.Auth.CreatePasswordReset(&reset)
+			if err != nil {
+				return internal("failed to save password reset information, try again")
+			}
+			return nil
+		}
+		if err := tx.Auth.UpdatePasswordReset(&reset); err != nil {
+			return internal("failed to save password reset information, try again")
+		}
+		return nil
+	})
+}
+
+// ActivePasswordReset reports whether the reset link can still be used
+func (s *AuthService) ActivePasswordReset(email string, role string) error {
+	reset, err := s.repos.Auth.FindPasswordReset(email, role)
+	if err != nil {
+		return notFound("failed to fetch password reset information")
+	}
+	if reset.Active == model.NO {
+		return badRequest("link is expired")
+	}
+	return nil
+}
+
+// ResetPassword sets the new password from the reset form, the account has to verify its email again
+func (s *AuthService) ResetPassword(request model.Step2PasswordReset) error {
+	reset, err := s.repos.Auth.FindPasswordReset(request.Email, request.Role)
+	if err != nil {
+		return notFound("failed to fetch password reset information")
+	}
+	if reset.Active == model.NO {
+		return badRequest("link is expired")
+	}
+	if request.ConfirmPassword != request.Password {
+		return badRequest("password doesnt match")
+	}
+	if request.Token != reset.ResetToken {
+		return badRequest("password token doesnt match")
+	}
+	if err := passwordvalidator.Validate(request.Password, model.PasswordEntropy); err != nil {
+		return badRequest(err.Error())
+	}
+
+	salt, hash, err := hashPassword(request.Password)
+	if err != nil {
+		return internal("failed to hash the password")
+	}
+
+	return s.repos.Transaction(func(tx *repository.Repositories) error {
+		switch request.Role {
+		case model.UserRole:
+			err = tx.Users.UpdatePassword(request.Email, salt, hash)
+		case model.RestaurantRole:
+			err = tx.Restaurants.UpdatePassword(request.Email, salt, hash)
+		}
+		if err != nil {
+			return internal("failed to update the password")
+		}
+		if err := tx.Auth.DeactivatePasswordReset(request.Email, request.Role); err != nil {
+			return internal("something went wrong")
+		}
+		if err := tx.Auth.SetVerificationStatus(request.Email, request.Role, model.VerificationStatusPending); err != nil {
+			return internal("failed to update the verification status")
+		}
+		// sessions opened with the old password are signed out
+		if err := revokeSessions(tx, request.Email, request.Role); err != nil {
+			return internal("failed to end the existing sessions")
+		}
+		return nil
+	})
+}
+
+// sendOTP queues a verification link unless the previous otp is still valid, tx is the
+// transaction the new otp is saved in
+func (s *AuthService) sendOTP(tx *repository.Repositories, to string, otpExpiry uint64, role string, locale string) error {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	otp := r.Intn(900000) + 100000
+
+	now := time.Now().Unix()
+	if otpExpiry > 0 && uint64(now) < otpExpiry {
+		timeLeft := otpExpiry - uint64(now)
+		return fmt.Errorf("email is still valid. wait before sending another request, %v seconds left", int(timeLeft))
+	}
+
+	var expiryTime int64
+	switch role {
+	case model.RestaurantRole:
+		expiryTime = now + 5*60
+	case model.UserRole:
+		expiryTime = now + 10*60
+	}
+
+	url := fmt.Sprintf("%v/api/v1/auth/verifyemail/%v/%v/%v", utils.GetEnvVariables().ServerURL, role, to, otp)
+	err := enqueueEmail(tx, s.templates, to, "verification", locale, map[string]interface{}{
+		"URL":       url,
+		"ExpiresAt": time.Unix(expiryTime, 0).Format(time.RFC1123),
+	})
+	if err != nil {
+		return errors.New("failed to queue the verification email")
+	}
+
+	verification := model.VerificationTable{
+		Email:              to,
+		Role:               role,
+		OTP:                uint64(otp),
+		OTPExpiry:          uint64(expiryTime),
+		VerificationStatus: model.VerificationStatusPending,
+	}
+	if err := tx.Auth.UpdateVerification(&verification); err != nil {
+		return errors.New("failed to get information using email")
+	}
+	return nil
+}
+
+// hashPassword returns a fresh salt and the bcrypt hash of salt+password
+func hashPassword(password string) (salt string, hash string, err error) {
+	salt = utils.GenerateRandomString(7)
+	hashed, err := bcrypt.GenerateFromPassword([]byte(salt+password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "", err
+	}
+	return salt, string(hashed), nil
+}
+
+func checkPassword(hash string, salt string, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(salt+password)) == nil
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/money"
+	"foodbuddy/internal/repository"
+	"net/http"
+	"strings"
+)
+
+type CartService struct {
+	repos *repository.Repositories
+}
+
+func NewCartService(repos *repository.Repositories) *CartService {
+	return &CartService{repos: repos}
+}
+
+// CartTotal is the cart of a user at a single restaurant
+type CartTotal struct {
+	Items        []model.CartItems
+	ProductOffer money.Amount
+	TotalAmount  money.Amount
+	FinalAmount  money.Amount
+}
+
+func (s *CartService) Add(userID uint, request model.AddToCartReq) error {
+	product, err := s.repos.Products.FindByID(request.ProductID)
+	if err != nil {
+		return badRequest("Failed to fetch product information. Please ensure the specified product exists.")
+	}
+	if request.Quantity > availableStock(product) {
+		return newError(http.StatusConflict, fmt.Sprintf("Requested quantity exceeds available stock. Available stock: %v", availableStock(product)))
+	}
+	if request.Quantity > model.MaxUserQuantity {
+		return newError(http.StatusConflict, fmt.Sprintf("Requested quantity exceeds allowed limit. Maximum quantity per cart:  %v", model.MaxUserQuantity))
+	}
+
+	item, err := s.repos.Carts.Find(userID, request.ProductID)
+	if errors.Is(err, repository.ErrNotFound) {
+		item = model.CartItems{
+			UserID:         userID,
+			ProductID:      request.ProductID,
+			RestaurantID:   product.RestaurantID,
+			Quantity:       request.Quantity,
+			CookingRequest: request.CookingRequest,
+		}
+		if err := s.repos.Carts.Create(&item); err != nil {
+			return internal("Failed to update cart items. Please try again later.")
+		}
+		return nil
+	}
+	if err != nil {
+		return internal("Failed to fetch items of the user. Please provide a valid user ID.")
+	}
+
+	if item.Quantity+request.Quantity > model.MaxUserQuantity {
+		return newError(http.StatusConflict, "Total of Requested and Current need of quantity exceeds the max user quantity")
+	}
+	item.Quantity += request.Quantity
+	if request.CookingRequest != "" {
+		item.CookingRequest = request.CookingRequest
+	}
+	if err := s.repos.Carts.Update(&item); err != nil {
+		return internal("Failed to update cart items. Please try again later.")
+	}
+	return nil
+}
+
+// Totals groups the cart of a user by restaurant
+func (s *CartService) Totals(userID uint) (map[uint]CartTotal, error) {
+	items, err := s.repos.Carts.ListByUser(userID)
+	if err != nil {
+		return nil, internal("Failed to fetch cart items. Please try again later.")
+	}
+	if len(items) == 0 {
+		return nil, notFound("Your cart is empty.")
+	}
+
+	totals := make(map[uint]CartTotal)
+	for _, item := range items {
+		product, err := s.repos.Products.FindByID(item.ProductID)
+		if err != nil {
+			return nil, notFound("Failed to fetch product information. Please try again later.")
+		}
+		total := totals[product.RestaurantID]
+		total.Items = append(total.Items, item)
+		total.ProductOffer += product.OfferAmount.Times(item.Quantity)
+		total.TotalAmount += product.Price.Times(item.Quantity)
+		total.FinalAmount = total.TotalAmount - total.ProductOffer
+		totals[product.RestaurantID] = total
+	}
+	return totals, nil
+}
+
+// Clear empties the cart of a user, or only the items of one restaurant when restaurantID is set
+func (s *CartService) Clear(userID uint, restaurantID uint) error {
+	if restaurantID == 0 {
+		if err := s.repos.Carts.Clear(userID); err != nil {
+			return internal("Failed to delete cart items. Please try again later.")
+		}
+		return nil
+	}
+	if err := s.repos.Carts.ClearRestaurant(userID, restaurantID); err != nil {
+		return internal("Failed to delete cart items for the specified restaurant. Please try again later.")
+	}
+	return nil
+}
+
+func (s *CartService) Remove(userID uint, productID uint) error {
+	if _, err := s.repos.Carts.Find(userID, productID); err != nil {
+		return notFound(err.Error())
+	}
+	if err := s.repos.Carts.Delete(userID, productID); err != nil {
+		return notFound(err.Error())
+	}
+	return nil
+}
+
+func (s *CartService) UpdateQuantity(userID uint, productID uint, quantity uint) error {
+	item, err := s.repos.Carts.Find(userID, productID)
+	if err != nil {
+		return notFound(err.Error())
+	}
+	if quantity > model.MaxUserQuantity {
+		return newError(http.StatusConflict, fmt.Sprintf("Requested quantity exceeds allowed limit. Maximum quantity per cart:  %v", model.MaxUserQuantity))
+	}
+	item.Quantity = quantity
+	if err := s.repos.Carts.Update(&item); err != nil {
+		return notFound(err.Error())
+	}
+	return nil
+}
+
+func (s *CartService) SetCookingRequest(userID uint, productID uint, cookingRequest string) error {
+	if len(strings.Fields(cookingRequest)) < 2 {
+		return badRequest("cooking_request must contain atleast 2 words")
+	}
+	if _, err := s.repos.Carts.Find(userID, productID); err != nil {
+		return notFound("cart is empty or the specified product is not in this cart,please make sure the product exists")
+	}
+	if err := s.repos.Carts.SetCookingRequest(userID, productID, cookingRequest); err != nil {
+		return notFound("cart is empty or the specified product is not in this cart,please make sure the product exists")
+	}
+	return nil
+}
+
+// cartTotal sums the cart of a user at one restaurant
+func cartTotal(repos *repository.Repositories, userID uint, restaurantID uint) (totalAmount money.Amount, productOffer money.Amount, err error) {
+	items, err := repos.Carts.ListByUserAndRestaurant(userID, restaurantID)
+	if err != nil {
+		return 0, 0, errors.New("failed to fetch cart items")
+	}
+	if len(items) == 0 {
+		return 0, 0, errors.New("your cart is empty")
+	}
+	for _, item := range items {
+		product, err := repos.Products.FindByID(item.ProductID)
+		if err != nil {
+			return 0, 0, errors.New("failed to fetch product information")
+		}
+		totalAmount += product.Price.Times(item.Quantity)
+		productOffer += product.OfferAmount.Times(item.Quantity)
+	}
+	return totalAmount, productOffer, nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/repository"
+	"net/http"
+	"strings"
+)
+
+type CategoryService struct {
+	repos *repository.Repositories
+}
+
+func NewCategoryService(repos *repository.Repositories) *CategoryService {
+	return &CategoryService{repos: repos}
+}
+
+func (s *CategoryService) List() ([]model.Category, error) {
+	categories, err := s.repos.Categories.List()
+	if err != nil {
+		return nil, notFound("failed to retrieve data from the database, or the data doesn't exist")
+	}
+	return categories, nil
+}
+
+func (s *CategoryService) ListWithProducts() ([]model.Category, error) {
+	categories, err := s.repos.Categories.ListWithProducts()
+	if err != nil {
+		return nil, notFound("make sure products are added in their respective catgories inorder to be displayed here")
+	}
+	return categories, nil
+}
+
+func (s *CategoryService) Create(request model.AddCategoryRequest) (model.Category, error) {
+	if len(strings.Fields(request.Description)) < 10 {
+		return model.Category{}, internal("description must be a minimum of 10 words")
+	}
+	if _, err := s.repos.Categories.FindByName(request.Name); err == nil {
+		return model.Category{}, badRequest("category already exists")
+	}
+
+	category := model.Category{
+		Name:        request.Name,
+		Description: request.Description,
+		ImageURL:    request.ImageURL,
+	}
+	if err := s.repos.Categories.Save(&category); err != nil {
+		return category, internal("unable to add new category, server error ")
+	}
+	return category, nil
+}
+
+func (s *CategoryService) Update(request model.EditCategoryRequest) error {
+	category, err := s.repos.Categories.FindByID(request.ID)
+	if err != nil {
+		return notFound("category not found")
+	}
+	category.Name = request.Name
+	category.Description = request.Description
+	category.ImageURL = request.ImageURL
+	if err := s.repos.Categories.Save(&category); err != nil {
+		return internal("failed to update category details")
+	}
+	return nil
+}
+
+func (s *CategoryService) Delete(categoryID uint) error {
+	if _, err := s.repos.Categories.FindByID(categoryID); err != nil {
+		return notFound("failed to fetch category from the database")
+	}
+	count, err := s.repos.Products.CountByCategory(categoryID)
+	if err != nil {
+		return internal("failed to delete category from the database")
+	}
+	if count > 0 {
+		return newError(http.StatusMethodNotAllowed, "category contains products, change the category of these products before using this endpoint")
+	}
+	if err := s.repos.Categories.Delete(categoryID); err != nil {
+		return internal("failed to delete category from the database")
+	}
+	return nil
+}
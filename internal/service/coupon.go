@@ -0,0 +1,169 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/money"
+	"foodbuddy/internal/repository"
+	"strconv"
+	"time"
+)
+
+type CouponService struct {
+	repos *repository.Repositories
+}
+
+func NewCouponService(repos *repository.Repositories) *CouponService {
+	return &CouponService{repos: repos}
+}
+
+// CartCoupon is the cart of a user at one restaurant priced with a coupon
+type CartCoupon struct {
+	Items              []model.CartItems
+	TotalAmount        money.Amount
+	CouponDiscount     money.Amount
+	ProductOfferAmount money.Amount
+	FinalAmount        money.Amount
+}
+
+func (s *CouponService) Create(request model.CouponInventoryRequest) error {
+	if _, err := s.repos.Coupons.FindByCode(request.CouponCode); err == nil {
+		return badRequest("coupon code already exists")
+	}
+	if request.Percentage > model.CouponDiscountPercentageLimit {
+		return badRequest("coupon discount percentage should not exceed more than " + strconv.Itoa(model.CouponDiscountPercentageLimit))
+	}
+	if time.Now().Unix()+12*3600 > int64(request.Expiry) {
+		return badRequest("please change the expiry time that is more than a day")
+	}
+
+	coupon := model.CouponInventory{
+		CouponCode:    request.CouponCode,
+		Expiry:        request.Expiry,
+		Percentage:    request.Percentage,
+		MaximumUsage:  request.MaximumUsage,
+		MinimumAmount: request.MinimumAmount,
+	}
+	if err := s.repos.Coupons.Create(&coupon); err != nil {
+		return internal("failed to create coupon")
+	}
+	return nil
+}
+
+func (s *CouponService) List() ([]model.CouponInventory, error) {
+	coupons, err := s.repos.Coupons.List()
+	if err != nil {
+		return nil, badRequest("failed to fetch coupon details")
+	}
+	return coupons, nil
+}
+
+func (s *CouponService) Update(request model.CouponInventoryRequest) error {
+	coupon, err := s.repos.Coupons.FindByCode(request.CouponCode)
+	if errors.Is(err, repository.ErrNotFound) {
+		return notFound("coupon not found")
+	}
+	if err != nil {
+		return internal("failed to find coupon")
+	}
+
+	coupon.Expiry = request.Expiry
+	coupon.Percentage = request.Percentage
+	coupon.MaximumUsage = request.MaximumUsage
+	if err := s.repos.Coupons.Save(&coupon); err != nil {
+		return internal("failed to update coupon")
+	}
+	return nil
+}
+
+// ApplyOnCart prices the cart of a user at one restaurant with the coupon without consuming it
+func (s *CouponService) ApplyOnCart(userID uint, restaurantID uint, code string) (CartCoupon, error) {
+	var result CartCoupon
+	items, err := s.repos.Carts.ListByUserAndRestaurant(userID, restaurantID)
+	if err != nil {
+		return result, internal("Failed to fetch cart items. Please try again later.")
+	}
+	if len(items) == 0 {
+		return result, notFound("Your cart is empty.")
+	}
+	result.Items = items
+
+	for _, item := range items {
+		product, err := s.repos.Products.FindByID(item.ProductID)
+		if err != nil {
+			return result, notFound("Failed to fetch product information. Please try again later.")
+		}
+		result.ProductOfferAmount += product.OfferAmount.Times(item.Quantity)
+		result.TotalAmount += product.Price.Times(item.Quantity)
+	}
+
+	coupon, err := s.repos.Coupons.FindByCode(code)
+	if err != nil {
+		return result, notFound("Invalid coupon code. Please check and try again.")
+	}
+	if time.Now().Unix() > int64(coupon.Expiry) {
+		return result, badRequest("The coupon has expired.")
+	}
+	if result.TotalAmount < coupon.MinimumAmount {
+		return result, badRequest(fmt.Sprintf("minimum of %v is needed for using this coupon", coupon.MinimumAmount))
+	}
+	if usage, err := s.repos.Coupons.FindUsage(userID, code); err == nil && usage.UsageCount >= coupon.MaximumUsage {
+		return result, badRequest("The coupon usage limit has been reached.")
+	}
+
+	result.CouponDiscount = result.TotalAmount.Percent(coupon.Percentage)
+	result.FinalAmount = result.TotalAmount - (result.CouponDiscount + result.ProductOfferAmount)
+	return result, nil
+}
+
+// applyCouponToOrder discounts the order with the coupon and records the usage,
+// tx should be the transaction the order is placed in
+func applyCouponToOrder(tx *repository.Repositories, order model.Order, userID uint, code string) (model.Order, error) {
+	if order.CouponCode != "" {
+		return order, fmt.Errorf("%v coupon already exists, remove this coupon to add a new coupon", order.CouponCode)
+	}
+
+	coupon, err := tx.Coupons.FindByCode(code)
+	if err != nil {
+		return order, errors.New("coupon not found")
+	}
+	if time.Now().Unix() > int64(coupon.Expiry) {
+		return order, errors.New("coupon has expired")
+	}
+
+	usage, usageErr := tx.Coupons.FindUsage(userID, code)
+	if usageErr == nil {
+		if usage.UsageCount >= coupon.MaximumUsage {
+			return order, errors.New("coupon usage limit reached")
+		}
+	} else if !errors.Is(usageErr, repository.ErrNotFound) {
+		return order, errors.New("database error")
+	}
+
+	if order.TotalAmount < coupon.MinimumAmount {
+		return order, fmt.Errorf("minimum of %v is needed for using this coupon", coupon.MinimumAmount)
+	}
+
+	discountAmount := order.TotalAmount.Percent(coupon.Percentage)
+	order.CouponCode = code
+	order.CouponDiscountAmount = discountAmount
+	order.FinalAmount = order.TotalAmount - (discountAmount + order.ProductOfferAmount)
+
+	if err := tx.Orders.Update(&order); err != nil {
+		return order, errors.New("failed to apply coupon to order")
+	}
+
+	if usageErr != nil {
+		usage = model.CouponUsage{UserID: userID, CouponCode: code, UsageCount: 1}
+		if err := tx.Coupons.CreateUsage(&usage); err != nil {
+			return order, errors.New("failed to create coupon usage record")
+		}
+		return order, nil
+	}
+	usage.UsageCount++
+	if err := tx.Coupons.SaveUsage(&usage); err != nil {
+		return order, errors.New("failed to update coupon usage record")
+	}
+	return order, nil
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"errors"
+	"foodbuddy/internal/mail"
+)
+
+// EmailService lets admins look at the email templates
+type EmailService struct {
+	templates *mail.Templates
+}
+
+func NewEmailService(templates *mail.Templates) *EmailService {
+	return &EmailService{templates: templates}
+}
+
+// Templates lists the email templates and their locales
+func (s *EmailService) Templates() ([]mail.TemplateInfo, error) {
+	infos, err := s.templates.List()
+	if err != nil {
+		return nil, internal("failed to list the email templates")
+	}
+	return infos, nil
+}
+
+// Preview renders a template with its sample data
+func (s *EmailService) Preview(name string, locale string) (mail.Message, error) {
+	data, err := s.templates.SampleData(name)
+	if err != nil {
+		return mail.Message{}, internal(err.Error())
+	}
+	msg, err := s.templates.Render(name, locale, data)
+	if errors.Is(err, mail.ErrTemplateNotFound) {
+		return msg, notFound("email template not found")
+	}
+	if err != nil {
+		return msg, badRequest(err.Error())
+	}
+	return msg, nil
+}
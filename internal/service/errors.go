@@ -0,0 +1,47 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Error is returned by the services for failures the caller should see,
+// Code is the http status the handlers respond with
+type Error struct {
+	Code    int
+	Message string
+	// Applied is set when the request changed something before it failed, retrying it
+	// would change it again
+	Applied bool
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func newError(code int, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func badRequest(message string) *Error {
+	return newError(http.StatusBadRequest, message)
+}
+
+func notFound(message string) *Error {
+	return newError(http.StatusNotFound, message)
+}
+
+func internal(message string) *Error {
+	return newError(http.StatusInternalServerError, message)
+}
+
+// applied marks err as returned after the request changed something
+func applied(err error) error {
+	var serviceErr *Error
+	if !errors.As(err, &serviceErr) {
+		return &Error{Code: http.StatusInternalServerError, Message: err.Error(), Applied: true}
+	}
+	marked := *serviceErr
+	marked.Applied = true
+	return &marked
+}
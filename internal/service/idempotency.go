@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/repository"
+	"log"
+	"net/http"
+	"time"
+)
+
+// IdempotencyService remembers the response to a request made with an Idempotency-Key. the
+// key is claimed before the request is handled, so a duplicate sent while the first one is
+// still running is turned away, and one sent after it gets the stored response back
+type IdempotencyService struct {
+	repos *repository.Repositories
+}
+
+func NewIdempotencyService(repos *repository.Repositories) *IdempotencyService {
+	return &IdempotencyService{repos: repos}
+}
+
+// Begin claims the owner's key for the request with the fingerprint. when the key answered
+// the same request already, the stored key is returned with replay set and the request must
+// not be handled again
+func (s *IdempotencyService) Begin(owner string, key string, fingerprint string) (record model.IdempotencyKey, replay bool, err error) {
+	if len(key) > model.IdempotencyKeyMaxLen {
+		return record, false, badRequest("Idempotency-Key is too long")
+	}
+
+	record = model.IdempotencyKey{Owner: owner, Key: key, Fingerprint: fingerprint}
+	claimed, err := s.repos.Idempotency.Claim(&record)
+	if err != nil {
+		return record, false, internal("failed to store the Idempotency-Key")
+	}
+	if claimed {
+		return record, false, nil
+	}
+
+	now := time.Now()
+	reclaimed, err := s.repos.Idempotency.Reclaim(&record,
+		now.Add(-model.IdempotencyKeyLifetime*time.Second), now.Add(-model.IdempotencyLease*time.Second))
+	if err != nil {
+		return record, false, internal("failed to store the Idempotency-Key")
+	}
+	if reclaimed {
+		return record, false, nil
+	}
+
+	existing, err := s.repos.Idempotency.Find(owner, key)
+	if errors.Is(err, repository.ErrNotFound) {
+		// released between the claim and now, the client can simply retry
+		return record, false, newError(http.StatusConflict, "a request with this Idempotency-Key is in progress")
+	}
+	if err != nil {
+		return record, false, internal("failed to fetch the Idempotency-Key")
+	}
+	if existing.Fingerprint != fingerprint {
+		return existing, false, newError(http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+	}
+	if existing.Status != model.IdempotencyCompleted {
+		return existing, false, newError(http.StatusConflict, "a request with this Idempotency-Key is in progress")
+	}
+	return existing, true, nil
+}
+
+// Complete stores the response to replay for the key. server errors release the key instead so
+// the request can be retried, unless it was applied before it failed: a retry would apply it
+// again, the error is stored and replayed like any other response
+func (s *IdempotencyService) Complete(record model.IdempotencyKey, code int, contentType string, body []byte, applied bool) {
+	var err error
+	if code >= http.StatusInternalServerError && !applied {
+		err = s.repos.Idempotency.Release(record.ID)
+	} else {
+		err = s.repos.Idempotency.Complete(record.ID, code, contentType, body)
+	}
+	if err != nil {
+		log.Printf("idempotency: failed to store the response of key %s of %s: %v", record.Key, record.Owner, err)
+	}
+}
+
+// PurgeExpired deletes the expired keys every interval until ctx is cancelled
+func (s *IdempotencyService) PurgeExpired(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		if _, err := s.repos.Idempotency.Purge(time.Now().Add(-model.IdempotencyKeyLifetime * time.Second)); err != nil {
+			log.Printf("idempotency: failed to purge expired keys: %v", err)
+		}
+	}
+}
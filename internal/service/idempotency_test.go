@@ -0,0 +1,40 @@
+package service
+
+import (
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/repository"
+	"net/http"
+	"testing"
+)
+
+func TestIdempotencyServerErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		applied    bool
+		wantReplay bool
+	}{
+		{"released when nothing was done", false, false},
+		{"replayed when the request was applied", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewIdempotencyService(repository.New(openTestDB(t)))
+			record, replay, err := s.Begin("USER:1", "key-1", "fingerprint")
+			if err != nil || replay {
+				t.Fatalf("begin: replay %v, %v", replay, err)
+			}
+			s.Complete(record, http.StatusInternalServerError, "application/json", []byte(`{"status":false}`), tt.applied)
+
+			stored, replay, err := s.Begin("USER:1", "key-1", "fingerprint")
+			if err != nil {
+				t.Fatalf("begin again: %v", err)
+			}
+			if replay != tt.wantReplay {
+				t.Fatalf("retry replayed %v, want %v", replay, tt.wantReplay)
+			}
+			if replay && (stored.Status != model.IdempotencyCompleted || stored.ResponseCode != http.StatusInternalServerError) {
+				t.Errorf("replayed a %s key with a %d, want a %s key with a %d", stored.Status, stored.ResponseCode, model.IdempotencyCompleted, http.StatusInternalServerError)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/pubsub"
+	"foodbuddy/internal/repository"
+	"log"
+	"time"
+)
+
+// KitchenService feeds the restaurants' kitchens the order items to cook and the changes to
+// them. the events are stored with the change they are about and numbered per restaurant in
+// the order they commit, so a kitchen that lost its connection replays the ones it missed
+// from its cursor before it gets the live ones
+type KitchenService struct {
+	repos  *repository.Repositories
+	events *pubsub.Hub
+}
+
+func NewKitchenService(repos *repository.Repositories, events *pubsub.Hub) *KitchenService {
+	return &KitchenService{repos: repos, events: events}
+}
+
+// kitchenTopic is the topic the kitchen events of a restaurant are published on
+func kitchenTopic(restaurantID uint) string {
+	return fmt.Sprintf("kitchen:%d", restaurantID)
+}
+
+// kitchenEventType returns the kitchen event a step of an order item makes, the kitchen only
+// hears about the items it was given to cook
+func kitchenEventType(from string, to string) string {
+	switch {
+	case from == model.OrderStatusInitiated && to == model.OrderStatusProcessing:
+		return model.KitchenEventNewItem
+	case from == model.OrderStatusProcessing && to == model.OrderStatusInitiated:
+		return model.KitchenEventItemWithdrawn
+	case from != model.OrderStatusInitiated && to == model.OrderStatusCancelled:
+		return model.KitchenEventItemCancelled
+	}
+	return ""
+}
+
+// recordKitchenEvent stores the event in tx and publishes it once tx commits
+func recordKitchenEvent(tx *repository.Repositories, hub *pubsub.Hub, event model.KitchenEvent) error {
+	if err := tx.Kitchen.Add(&event); err != nil {
+		return internal("failed to record the kitchen event")
+	}
+	tx.AfterCommit(func() {
+		hub.Publish(kitchenTopic(event.RestaurantID), pubsub.Event{Type: event.EventType, Data: event, At: event.CreatedAt})
+	})
+	return nil
+}
+
+// Follow subscribes the restaurant's kitchen to its events. the subscription starts before
+// the missed events are read, so none falls in between, and the caller closes it. reset is
+// set when events after the cursor were purged and the kitchen should reload its orders
+func (s *KitchenService) Follow(restaurantID uint, cursor uint) (subscription *pubsub.Subscription, reset bool, err error) {
+	subscription = s.events.Subscribe(kitchenTopic(restaurantID))
+	if cursor == 0 {
+		return subscription, false, nil
+	}
+	first, err := s.repos.Kitchen.FirstSeq(restaurantID)
+	if err != nil {
+		subscription.Close()
+		return nil, false, internal("failed to fetch the kitchen events")
+	}
+	return subscription, first > cursor+1, nil
+}
+
+// Missed returns the next model.KitchenReplayBatch events of the restaurant after the cursor
+func (s *KitchenService) Missed(restaurantID uint, cursor uint) ([]model.KitchenEvent, error) {
+	events, err := s.repos.Kitchen.ListAfter(restaurantID, cursor, model.KitchenReplayBatch)
+	if err != nil {
+		return nil, internal("failed to fetch the kitchen events")
+	}
+	return events, nil
+}
+
+// PurgeExpired deletes the kitchen events older than model.KitchenEventRetention every
+// interval until ctx is cancelled
+func (s *KitchenService) PurgeExpired(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		if _, err := s.repos.Kitchen.Purge(time.Now().Add(-model.KitchenEventRetention * time.Second)); err != nil {
+			log.Printf("kitchen: failed to purge old events: %v", err)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/money"
+	"foodbuddy/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LedgerService audits the double entry ledger behind the wallets. every movement of
+// wallet money is a journal entry posted with postEntry, the balance columns on users
+// and restaurants are a cache of the ledger that Check compares against it
+type LedgerService struct {
+	repos *repository.Repositories
+}
+
+func NewLedgerService(repos *repository.Repositories) *LedgerService {
+	return &LedgerService{repos: repos}
+}
+
+// Check looks for entries that don't balance and wallets whose stored balance differs from the ledger
+func (s *LedgerService) Check() (model.LedgerCheck, error) {
+	var check model.LedgerCheck
+	var err error
+
+	check.TotalDebit, check.TotalCredit, err = s.repos.Ledger.Totals()
+	if err != nil {
+		return check, internal("failed to sum the journal")
+	}
+	check.UnbalancedEntries, err = s.repos.Ledger.UnbalancedEntries()
+	if err != nil {
+		return check, internal("failed to check the journal entries")
+	}
+
+	accounts, err := s.repos.Ledger.ListAccounts()
+	if err != nil {
+		return check, internal("failed to fetch the ledger accounts")
+	}
+	balances, err := s.repos.Ledger.Balances()
+	if err != nil {
+		return check, internal("failed to fetch the ledger balances")
+	}
+	wallets := map[string]map[uint]money.Amount{}
+	if wallets[model.LedgerUserWallet], err = s.repos.Ledger.UserWallets(); err != nil {
+		return check, internal("failed to fetch user wallets")
+	}
+	if wallets[model.LedgerRestaurantWallet], err = s.repos.Ledger.RestaurantWallets(); err != nil {
+		return check, internal("failed to fetch restaurant wallets")
+	}
+
+	for _, account := range accounts {
+		stored, isWallet := wallets[account.Type]
+		if !isWallet {
+			continue
+		}
+		if balances[account.ID] != stored[account.OwnerID] {
+			check.WalletMismatches = append(check.WalletMismatches, model.LedgerWalletMismatch{
+				AccountType:   account.Type,
+				OwnerID:       account.OwnerID,
+				LedgerBalance: balances[account.ID],
+				WalletBalance: stored[account.OwnerID],
+			})
+		}
+		delete(stored, account.OwnerID)
+	}
+	// money in a wallet that never went through the ledger
+	for accountType, stored := range wallets {
+		for ownerID, balance := range stored {
+			check.WalletMismatches = append(check.WalletMismatches, model.LedgerWalletMismatch{
+				AccountType:   accountType,
+				OwnerID:       ownerID,
+				WalletBalance: balance,
+			})
+		}
+	}
+
+	check.Balanced = check.TotalDebit == check.TotalCredit && len(check.UnbalancedEntries) == 0 && len(check.WalletMismatches) == 0
+	return check, nil
+}
+
+// posting is one side of a journal entry, money leaves an account by a debit and arrives by a credit
+type posting struct {
+	accountType string
+	ownerID     uint
+	debit       money.Amount
+	credit      money.Amount
+}
+
+func debit(accountType string, ownerID uint, amount money.Amount) posting {
+	return posting{accountType: accountType, ownerID: ownerID, debit: amount}
+}
+
+func credit(accountType string, ownerID uint, amount money.Amount) posting {
+	return posting{accountType: accountType, ownerID: ownerID, credit: amount}
+}
+
+// postEntry records a balanced journal entry and applies it to the wallet balances and
+// their history, tx should be the transaction the rest of the change is made in
+func postEntry(tx *repository.Repositories, reason string, orderID string, postings ...posting) error {
+	var debits, credits money.Amount
+	for _, p := range postings {
+		if p.debit < 0 || p.credit < 0 {
+			return errors.New("ledger: negative posting")
+		}
+		debits += p.debit
+		credits += p.credit
+	}
+	if debits != credits {
+		return fmt.Errorf("ledger: entry debits %v but credits %v", debits, credits)
+	}
+	if debits == 0 {
+		return nil
+	}
+
+	entry := model.JournalEntry{Reason: reason, OrderID: orderID}
+	for _, p := range postings {
+		if p.debit == 0 && p.credit == 0 {
+			continue
+		}
+		account, err := tx.Ledger.Account(p.accountType, p.ownerID)
+		if err != nil {
+			return err
+		}
+		entry.Lines = append(entry.Lines, model.JournalLine{AccountID: account.ID, Debit: p.debit, Credit: p.credit})
+	}
+	if err := tx.Ledger.CreateEntry(&entry); err != nil {
+		return err
+	}
+
+	for _, p := range postings {
+		if err := applyToWallet(tx, p, reason, orderID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyToWallet moves the stored balance of a wallet posting and writes its history row. the
+// balance is moved in the database, never computed here, so concurrent postings can't overwrite
+// each other or take a wallet below zero
+func applyToWallet(tx *repository.Repositories, p posting, reason string, orderID string) error {
+	amount, direction := p.credit, model.WalletIncoming
+	if p.debit > 0 {
+		amount, direction = p.debit, model.WalletOutgoing
+	}
+	if amount == 0 {
+		return nil
+	}
+
+	delta := p.credit - p.debit
+
+	switch p.accountType {
+	case model.LedgerUserWallet:
+		balance, moved, err := tx.Wallets.MoveUserBalance(p.ownerID, delta)
+		if err != nil {
+			return err
+		}
+		if !moved {
+			return errors.New("insufficient wallet balance")
+		}
+		history := model.UserWalletHistory{
+			TransactionTime: time.Now(),
+			UserID:          p.ownerID,
+			Type:            direction,
+			OrderID:         orderID,
+			Amount:          amount,
+			CurrentBalance:  balance,
+			Reason:          reason,
+		}
+		if direction == model.WalletOutgoing {
+			history.WalletPaymentID = uuid.New().String()
+		}
+		return tx.Wallets.CreateUserHistory(&history)
+
+	case model.LedgerRestaurantWallet:
+		balance, moved, err := tx.Wallets.MoveRestaurantBalance(p.ownerID, delta)
+		if err != nil {
+			return err
+		}
+		if !moved {
+			return errors.New("insufficient restaurant wallet balance")
+		}
+		return tx.Wallets.CreateRestaurantHistory(&model.RestaurantWalletHistory{
+			TransactionTime: time.Now(),
+			Type:            direction,
+			OrderID:         orderID,
+			RestaurantID:    p.ownerID,
+			Amount:          amount,
+			CurrentBalance:  balance,
+			Reason:          reason,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,738 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"foodbuddy/internal/mail"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/payment"
+	"foodbuddy/internal/pubsub"
+	"foodbuddy/internal/repository"
+	"foodbuddy/internal/utils"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OrderService struct {
+	repos     *repository.Repositories
+	templates *mail.Templates
+	gateways  *payment.Registry
+	events    *pubsub.Hub
+	stock     *StockService
+
+	// AcceptanceDeadline is how long a paid order item waits for the restaurant to accept it
+	// before it is rejected
+	AcceptanceDeadline time.Duration
+}
+
+// NewOrderService refunds cancelled online orders through the gateways they were paid with,
+// publishes the changes to the orders on events and holds the stock of online orders through
+// stock. it reads ACCEPTANCE_DEADLINE, a duration like 10m, falling back to model.AcceptanceDeadline
+func NewOrderService(repos *repository.Repositories, templates *mail.Templates, gateways *payment.Registry, events *pubsub.Hub, stock *StockService) *OrderService {
+	env := utils.GetEnvVariables()
+	return &OrderService{
+		repos:              repos,
+		templates:          templates,
+		gateways:           gateways,
+		events:             events,
+		stock:              stock,
+		AcceptanceDeadline: durationOr("ACCEPTANCE_DEADLINE", env.AcceptanceDeadline, model.AcceptanceDeadline*time.Second),
+	}
+}
+
+// Place turns the user's cart at one restaurant into an order. the order row, coupon usage,
+// order items, cart cleanup and stock changes are written in one transaction. cash on delivery
+// orders take their stock, online orders hold it until they are paid
+func (s *OrderService) Place(userID uint, request model.PlaceOrder) (model.Order, error) {
+	if _, err := s.repos.Users.FindByID(userID); err != nil {
+		return model.Order{}, notFound("user doesn't exist, please verify user id")
+	}
+	if _, err := s.repos.Users.FindAddress(userID, request.AddressID); err != nil {
+		return model.Order{}, newError(http.StatusConflict, "invalid address, please retry with user's address")
+	}
+	restaurant, err := s.repos.Restaurants.FindByID(request.RestaurantID)
+	if err != nil {
+		return model.Order{}, newError(http.StatusConflict, "invalid restaurant_id, please ensure restauarant exists")
+	}
+	if restaurant.Blocked {
+		return model.Order{}, newError(http.StatusConflict, "cannot place orders of blocked restaurants")
+	}
+
+	itemCount, ok := checkStock(s.repos, userID)
+	if !ok {
+		return model.Order{}, newError(http.StatusConflict, "items in the cart are out of stock, please update the cart to ensure all items are in stock")
+	}
+
+	totalAmount, productOffer, err := cartTotal(s.repos, userID, request.RestaurantID)
+	if err != nil {
+		return model.Order{}, internal("failed to calculate cart total or the cart is empty")
+	}
+	if request.PaymentMethod == model.CashOnDelivery && totalAmount >= model.CODMaximumAmount {
+		return model.Order{}, badRequest("please switch to ONLINE payment for total amounts greater than or equal to 1000")
+	}
+
+	order := model.Order{
+		OrderID:            uuid.New().String(),
+		UserID:             userID,
+		RestaurantID:       request.RestaurantID,
+		AddressID:          request.AddressID,
+		ItemCount:          itemCount,
+		ProductOfferAmount: productOffer,
+		TotalAmount:        totalAmount,
+		FinalAmount:        totalAmount - productOffer,
+		PaymentMethod:      request.PaymentMethod,
+		PaymentStatus:      model.OnlinePaymentPending,
+		OrderedAt:          time.Now(),
+	}
+	if request.PaymentMethod == model.CashOnDelivery {
+		order.PaymentStatus = model.CODStatusPending
+	}
+
+	err = s.repos.Transaction(func(tx *repository.Repositories) error {
+		if err := tx.Orders.Create(&order); err != nil {
+			return internal("failed to create order")
+		}
+
+		if request.CouponCode != "" {
+			applied, err := applyCouponToOrder(tx, order, userID, request.CouponCode)
+			if err != nil {
+				return badRequest(err.Error())
+			}
+			order = applied
+		}
+
+		if err := cartToOrderItems(tx, s.events, userID, request.RestaurantID, order); err != nil {
+			return internal("failed to transfer cart items to order")
+		}
+
+		if request.PaymentMethod == model.CashOnDelivery {
+			return startCODItems(tx, s.events, order.OrderID, "cash on delivery order placed")
+		}
+		return s.stock.hold(tx, order.OrderID)
+	})
+	if err != nil {
+		return order, err
+	}
+
+	placed, err := s.repos.Orders.FindByID(order.OrderID)
+	if err != nil {
+		return order, applied(internal("failed fetch order details"))
+	}
+	return placed, nil
+}
+
+// RestaurantHistory lists the order items of a restaurant, optionally filtered by status
+func (s *OrderService) RestaurantHistory(restaurantID uint, status string) ([]model.OrderItem, error) {
+	var items []model.OrderItem
+	var err error
+	if status != "" {
+		items, err = s.repos.Orders.ListItemsByRestaurantAndStatus(restaurantID, status)
+	} else {
+		items, err = s.repos.Orders.ListItemsByRestaurant(restaurantID)
+	}
+	if err != nil {
+		return nil, notFound("failed to fetch orders assigned to this restaurant")
+	}
+	return items, nil
+}
+
+func (s *OrderService) RestaurantItems(restaurantID uint) ([]model.OrderItem, error) {
+	return s.repos.Orders.ListItemsByRestaurant(restaurantID)
+}
+
+func (s *OrderService) UserItems(userID uint, orderID string) ([]model.OrderItem, error) {
+	if orderID == "" {
+		return nil, nil
+	}
+	items, err := s.repos.Orders.ListItemsByUserOrder(userID, orderID)
+	if err != nil {
+		return nil, badRequest("failed to fetch specified orderitems")
+	}
+	return items, nil
+}
+
+func (s *OrderService) Info(userID uint, orderID string) (model.Order, []model.OrderItem, error) {
+	order, err := s.ownedOrder(userID, orderID)
+	if err != nil {
+		return order, nil, err
+	}
+	items, err := s.repos.Orders.ListItems(orderID)
+	if err != nil {
+		return order, nil, internal("no order items for order_id in the table,make sure the order contains order items")
+	}
+	return order, items, nil
+}
+
+// NextStatus moves an order item of the restaurant one step further through preparation and delivery
+func (s *OrderService) NextStatus(restaurantID uint, orderID string, productID uint) (model.OrderItem, error) {
+	item, err := s.repos.Orders.FindItem(orderID, productID)
+	if err != nil {
+		return item, notFound("failed to fetch order information for the specific product")
+	}
+	if item.RestaurantID != restaurantID {
+		return item, newError(http.StatusUnauthorized, "unauthorized request")
+	}
+
+	if item.OrderStatus == model.OrderStatusProcessing {
+		return item, newError(http.StatusConflict, "accept or reject the order item first")
+	}
+	next := nextStatus(item.OrderStatus, model.RestaurantRole)
+	if next == model.OrderStatusDelivered {
+		return item, notFound("Reached maximum level of order transition, confirm the delivery with the delivery code")
+	}
+	if next == "" {
+		return item, newError(http.StatusConflict, "order item is "+item.OrderStatus+", it can't be moved to the next status")
+	}
+
+	err = s.repos.Transaction(func(tx *repository.Repositories) error {
+		return moveItem(tx, s.events, &item, next, statusChange{Actor: model.RestaurantRole, ActorID: restaurantID, Reason: "moved on by the restaurant"})
+	})
+	return item, err
+}
+
+// CancelOnline cancels a paid online order, or one product of it, and refunds every cancelled
+// item to the user's wallet or, with refundTo SOURCE, through the gateway the order was paid
+// with. gateway refunds come back pending when the gateway processes them later
+func (s *OrderService) CancelOnline(ctx context.Context, userID uint, orderID string, productID uint, refundTo string) ([]model.Refund, error) {
+	order, err := s.ownedOrder(userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.PaymentMethod != model.OnlinePayment {
+		return nil, newError(http.StatusMethodNotAllowed, "order payment method is not ONLINE")
+	}
+	if order.PaymentStatus != model.OnlinePaymentConfirmed {
+		return nil, notFound("order has not received the payment, hence cannot initiate the cancellation")
+	}
+
+	source, refundTo, err := s.refundSource(orderID, refundTo)
+	if err != nil {
+		return nil, err
+	}
+
+	var refunds []model.Refund
+	err = s.repos.Transaction(func(tx *repository.Repositories) error {
+		items, err := tx.Orders.ListItemsByStatus(orderID, statusesBefore(model.OrderStatusCancelled, model.UserRole)...)
+		if err != nil {
+			return notFound("failed to fetch the order item")
+		}
+		if productID != 0 {
+			items = filterItems(items, productID)
+		}
+		if len(items) == 0 {
+			return newError(http.StatusConflict, "No eligible items found for cancellation")
+		}
+
+		items, err = cancelItems(tx, s.events, items, statusChange{Actor: model.UserRole, ActorID: userID, Reason: "cancelled by the user"})
+		if err != nil {
+			return err
+		}
+		refunds, err = refundItems(tx, order, source, refundTo, items)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.sendRefunds(ctx, refunds), nil
+}
+
+// refundSource returns where the cancelled items of the paid online order go back to when
+// refundTo is asked for, with the payment to refund through the gateway. the wallet is the
+// source of wallet payments
+func (s *OrderService) refundSource(orderID string, refundTo string) (model.Payment, string, error) {
+	switch refundTo {
+	case "", model.RefundToWallet:
+		return model.Payment{}, model.RefundToWallet, nil
+	case model.RefundToSource:
+		source, err := s.repos.Payments.FindByOrderAndStatus(orderID, model.OnlinePaymentConfirmed)
+		if err != nil {
+			return source, "", notFound("the payment of the order was not found, refund to the wallet instead")
+		}
+		if source.PaymentGateway == model.Wallet {
+			return source, model.RefundToWallet, nil
+		}
+		return source, model.RefundToSource, nil
+	}
+	return model.Payment{}, "", badRequest("refund_to should be either WALLET or SOURCE")
+}
+
+// refundItems refunds the cancelled items of the paid online order to the user's wallet or
+// through the source payment, the gateway refunds are left pending for sendRefunds
+func refundItems(tx *repository.Repositories, order model.Order, source model.Payment, refundTo string, items []model.OrderItem) ([]model.Refund, error) {
+	if refundTo == model.RefundToWallet {
+		refunds, err := refundToWallet(tx, order.UserID, items)
+		if err != nil {
+			return nil, newError(http.StatusConflict, "failed to refund to the wallet")
+		}
+		return refunds, nil
+	}
+	refunds, err := refundToSource(tx, source, order.UserID, items)
+	if err != nil {
+		return nil, newError(http.StatusConflict, "failed to refund to "+source.PaymentGateway+": "+err.Error())
+	}
+	return refunds, nil
+}
+
+// sendRefunds asks the gateways for the pending refunds once they are committed and returns
+// them with the gateways' answers, wallet refunds are returned as they are
+func (s *OrderService) sendRefunds(ctx context.Context, refunds []model.Refund) []model.Refund {
+	for i := range refunds {
+		if refunds[i].Destination == model.RefundToSource {
+			refunds[i] = sendRefund(ctx, s.repos, s.gateways, refunds[i])
+		}
+	}
+	return refunds
+}
+
+// Refunds returns the refunds of the user's order
+func (s *OrderService) Refunds(userID uint, orderID string) ([]model.Refund, error) {
+	if _, err := s.ownedOrder(userID, orderID); err != nil {
+		return nil, err
+	}
+	refunds, err := s.repos.Refunds.ListByOrder(orderID)
+	if err != nil {
+		return nil, internal("failed to fetch the refunds")
+	}
+	return refunds, nil
+}
+
+// OrderTimeline is the order's items as they stand and every step they took to get there
+type OrderTimeline struct {
+	OrderID       string                     `json:"order_id"`
+	PaymentStatus string                     `json:"payment_status"`
+	Items         []model.OrderItem          `json:"items"`
+	History       []model.OrderStatusHistory `json:"history"`
+}
+
+// Timeline returns the timeline of the order to the user who placed it, the restaurant it was
+// placed at and the admins
+func (s *OrderService) Timeline(p Principal, orderID string) (OrderTimeline, error) {
+	order, err := s.repos.Orders.FindByID(orderID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return OrderTimeline{}, notFound("order_id is not present")
+	}
+	if err != nil {
+		return OrderTimeline{}, internal("failed to fetch order information")
+	}
+	owner := p.Role == model.AdminRole ||
+		(p.Role == model.UserRole && order.UserID == p.ID) ||
+		(p.Role == model.RestaurantRole && order.RestaurantID == p.ID)
+	if !owner {
+		return OrderTimeline{}, newError(http.StatusUnauthorized, "unauthorized request")
+	}
+
+	items, err := s.repos.Orders.ListItems(orderID)
+	if err != nil {
+		return OrderTimeline{}, internal("failed to fetch order items")
+	}
+	history, err := s.repos.Orders.ListStatusHistory(orderID)
+	if err != nil {
+		return OrderTimeline{}, internal("failed to fetch the order status history")
+	}
+	return OrderTimeline{OrderID: orderID, PaymentStatus: order.PaymentStatus, Items: items, History: history}, nil
+}
+
+// CancelCOD cancels the items of a cash on delivery order that are not prepared yet
+func (s *OrderService) CancelCOD(userID uint, orderID string) error {
+	order, err := s.ownedOrder(userID, orderID)
+	if err != nil {
+		return err
+	}
+	if order.PaymentMethod != model.CashOnDelivery {
+		return newError(http.StatusMethodNotAllowed, "order payment method is not COD")
+	}
+
+	return s.repos.Transaction(func(tx *repository.Repositories) error {
+		items, err := tx.Orders.ListItemsByStatus(orderID, statusesBefore(model.OrderStatusCancelled, model.UserRole)...)
+		if err != nil {
+			return notFound("failed to fetch the order item")
+		}
+		if len(items) == 0 {
+			return notFound("No eligible items found for cancellation,order item should be either in Processing, Accepted or Preparation stage")
+		}
+		_, err = cancelItems(tx, s.events, items, statusChange{Actor: model.UserRole, ActorID: userID, Reason: "cancelled by the user"})
+		return err
+	})
+}
+
+// ChangeCookingRequest changes the cooking request of an item of the user's order until the
+// kitchen starts on it, a kitchen that got the item already is told about the change
+func (s *OrderService) ChangeCookingRequest(userID uint, request model.OrderCookingRequest) (model.OrderItem, error) {
+	if _, err := s.ownedOrder(userID, request.OrderID); err != nil {
+		return model.OrderItem{}, err
+	}
+	if len(strings.Fields(request.CookingRequest)) < 2 {
+		return model.OrderItem{}, badRequest("cooking_request must contain atleast 2 words")
+	}
+	item, err := s.repos.Orders.FindItem(request.OrderID, request.ProductID)
+	if err != nil {
+		return item, notFound("failed to retreive the order item")
+	}
+	if item.OrderStatus != model.OrderStatusInitiated && item.OrderStatus != model.OrderStatusProcessing {
+		return item, newError(http.StatusConflict, "the restaurant started on the item, the cooking request can't be changed")
+	}
+
+	err = s.repos.Transaction(func(tx *repository.Repositories) error {
+		changed, err := tx.Orders.SetItemCookingRequest(item.OrderID, item.ProductID, item.OrderStatus, request.CookingRequest)
+		if err != nil {
+			return internal("failed to update the cooking request")
+		}
+		if !changed {
+			return newError(http.StatusConflict, "the order item was updated in the meantime, please try again")
+		}
+		item.CookingRequest = request.CookingRequest
+		if item.OrderStatus != model.OrderStatusProcessing {
+			return nil
+		}
+		return recordKitchenEvent(tx, s.events, model.KitchenEvent{
+			RestaurantID:   item.RestaurantID,
+			OrderID:        item.OrderID,
+			ProductID:      item.ProductID,
+			EventType:      model.KitchenEventCookingRequest,
+			Quantity:       item.Quantity,
+			CookingRequest: item.CookingRequest,
+		})
+	})
+	return item, err
+}
+
+func (s *OrderService) Review(userID uint, request model.UserReviewonOrderItem) error {
+	if _, err := s.ownedOrder(userID, request.OrderID); err != nil {
+		return err
+	}
+	item, err := s.repos.Orders.FindItem(request.OrderID, request.ProductID)
+	if err != nil {
+		return notFound("failed to retreive the order item")
+	}
+	if item.OrderStatus != model.OrderStatusDelivered {
+		return newError(http.StatusConflict, "reviews can only be added after order is delivered")
+	}
+	item.OrderReview = request.ReviewText
+	if err := s.repos.Orders.UpdateItem(&item); err != nil {
+		return internal("failed to add order review, please try again")
+	}
+	return nil
+}
+
+// Rate records the user's rating on a delivered item and returns the product's new average rating
+func (s *OrderService) Rate(userID uint, request model.UserRatingOrderItem) (float64, error) {
+	if _, err := s.ownedOrder(userID, request.OrderID); err != nil {
+		return 0, err
+	}
+	if request.UserRating <= 0 || request.UserRating > 5 {
+		return 0, badRequest("enter a valid rating between 1 to 5")
+	}
+
+	item, err := s.repos.Orders.FindItem(request.OrderID, request.ProductID)
+	if err != nil {
+		return 0, badRequest("failed to retrieve order information")
+	}
+	if item.OrderStatus != model.OrderStatusDelivered {
+		return 0, newError(http.StatusMethodNotAllowed, "user can only rate an order after delivery")
+	}
+	if item.OrderRating != 0 {
+		return 0, newError(http.StatusMethodNotAllowed, "user already rated the order")
+	}
+
+	var average float64
+	err = s.repos.Transaction(func(tx *repository.Repositories) error {
+		if err := tx.Orders.SetItemRating(request.OrderID, request.ProductID, request.UserRating); err != nil {
+			return internal("failed to update order rating in the order item table for the specified product")
+		}
+		product, err := tx.Products.FindByID(request.ProductID)
+		if err != nil {
+			return internal("product not found")
+		}
+		sum := product.RatingSum + request.UserRating
+		count := product.RatingCount + 1
+		average = sum / float64(count)
+		if err := tx.Products.UpdateRating(product.ID, sum, count, average); err != nil {
+			return internal("failed to update product rating")
+		}
+		return nil
+	})
+	return average, err
+}
+
+// SendDeliveryCode mails the user a one time code that the delivery partner confirms the delivery with,
+// locale picks the language of the mail
+func (s *OrderService) SendDeliveryCode(userID uint, orderID string, locale string) (int64, error) {
+	order, err := s.ownedOrder(userID, orderID)
+	if err != nil {
+		return 0, err
+	}
+
+	items, err := s.repos.Orders.ListItems(orderID)
+	if err != nil {
+		return 0, newError(http.StatusNotImplemented, "failed to find orderitems")
+	}
+	for _, item := range items {
+		if item.OrderStatus == model.OrderStatusDelivered {
+			return 0, newError(http.StatusNotImplemented, "order is already delivered, cannot sent delivery code for this order")
+		}
+	}
+
+	verification, _ := s.repos.Orders.FindDeliveryVerification(orderID)
+	now := time.Now().Unix()
+	if now < int64(verification.LastSentAT+model.DeliveryVerificationOTPCooldownTime) {
+		return 0, newError(http.StatusNotImplemented, "wait for a min before trying again")
+	}
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	verification.OTP = uint(r.Intn(900000) + 100000)
+
+	user, _ := s.repos.Users.FindByID(order.UserID)
+	verification.OrderID = orderID
+	verification.UserID = order.UserID
+	verification.LastSentAT = uint(now)
+	err = s.repos.Transaction(func(tx *repository.Repositories) error {
+		if err := tx.Orders.SaveDeliveryVerification(&verification); err != nil {
+			return newError(http.StatusNotImplemented, "failed to save the delivery code")
+		}
+		err := enqueueEmail(tx, s.templates, user.Email, "delivery_code", locale, map[string]interface{}{
+			"Name":    user.Name,
+			"OrderID": orderID,
+			"OTP":     verification.OTP,
+		})
+		if err != nil {
+			return newError(http.StatusNotImplemented, "failed to send email")
+		}
+		publishOrderEvent(tx, s.events, orderID, model.OrderEventDeliveryCodeSent, DeliveryCodeSent{OrderID: orderID})
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(verification.OTP), nil
+}
+
+// ConfirmCOD marks the cash on delivery order of the restaurant as paid
+func (s *OrderService) ConfirmCOD(restaurantID uint, orderID string) error {
+	order, err := s.repos.Orders.FindByID(orderID)
+	if err != nil {
+		return notFound("order_id is not present")
+	}
+	if order.RestaurantID != restaurantID {
+		return newError(http.StatusUnauthorized, "unauthorized request,order doesnt belong to this restaurant")
+	}
+	if order.PaymentMethod != model.CashOnDelivery {
+		return newError(http.StatusMethodNotAllowed, "order_id given is not COD")
+	}
+	if order.PaymentStatus == model.CODStatusConfirmed {
+		return newError(http.StatusMethodNotAllowed, "order_id payment already done")
+	}
+	if err := s.repos.Orders.SetPaymentStatus(orderID, model.CODStatusConfirmed); err != nil {
+		return newError(http.StatusMethodNotAllowed, "failed to update COD payment status to paid, please try again")
+	}
+	publishOrderEvent(s.repos, s.events, orderID, model.OrderEventPaymentConfirmed, PaymentConfirmed{
+		OrderID:       orderID,
+		PaymentMethod: model.CashOnDelivery,
+		PaymentStatus: model.CODStatusConfirmed,
+	})
+	return nil
+}
+
+// CompleteDelivery checks the delivery code and marks every item that wasn't cancelled as delivered,
+// all of them should be out for delivery
+func (s *OrderService) CompleteDelivery(restaurantID uint, request model.ConfirmDelivery) error {
+	order, err := s.repos.Orders.FindByID(request.OrderID)
+	if err != nil {
+		return notFound("order_id is not present")
+	}
+	if order.RestaurantID != restaurantID {
+		return newError(http.StatusUnauthorized, "unauthorized request")
+	}
+	if order.PaymentStatus != model.OnlinePaymentConfirmed && order.PaymentStatus != model.CODStatusConfirmed {
+		return newError(http.StatusMethodNotAllowed, "payment should be confirmed before using this endpoint")
+	}
+	items, err := s.repos.Orders.ListItems(request.OrderID)
+	if err != nil {
+		return notFound("failed to fetch order items")
+	}
+	verification, err := s.repos.Orders.FindDeliveryVerification(request.OrderID)
+	if err != nil {
+		return notFound("failed to fetch delivery verification information")
+	}
+	if request.DeliveryOTP != verification.OTP {
+		return newError(http.StatusUnauthorized, "delivery_otp provided doesnt match with database")
+	}
+
+	return s.repos.Transaction(func(tx *repository.Repositories) error {
+		change := statusChange{Actor: model.RestaurantRole, ActorID: restaurantID, Reason: "delivery code confirmed"}
+		for i := range items {
+			if items[i].OrderStatus == model.OrderStatusCancelled {
+				continue
+			}
+			if err := moveItem(tx, s.events, &items[i], model.OrderStatusDelivered, change); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ownedOrder returns the order when it was placed by the user
+func (s *OrderService) ownedOrder(userID uint, orderID string) (model.Order, error) {
+	return findOwnedOrder(s.repos, userID, orderID)
+}
+
+// findOwnedOrder returns the order if it was placed by the user
+func findOwnedOrder(repos *repository.Repositories, userID uint, orderID string) (model.Order, error) {
+	order, err := repos.Orders.FindByID(orderID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return order, notFound("failed to fetch order information")
+	}
+	if err != nil || order.UserID != userID {
+		return order, newError(http.StatusUnauthorized, "unauthorized request")
+	}
+	return order, nil
+}
+
+// checkStock makes sure every product in the user's cart has enough stock that isn't held
+// for other orders and returns the number of items in the cart
+func checkStock(repos *repository.Repositories, userID uint) (itemCount uint, ok bool) {
+	items, err := repos.Carts.ListByUser(userID)
+	if err != nil {
+		return 0, false
+	}
+	for _, item := range items {
+		product, err := repos.Products.FindByID(item.ProductID)
+		if err != nil || item.Quantity > availableStock(product) {
+			return itemCount, false
+		}
+		itemCount += item.Quantity
+	}
+	return itemCount, true
+}
+
+// cartToOrderItems moves the user's cart at the restaurant into the order,
+// the coupon discount is shared between the items in proportion to their amount
+func cartToOrderItems(tx *repository.Repositories, hub *pubsub.Hub, userID uint, restaurantID uint, order model.Order) error {
+	cart, err := tx.Carts.ListByUserAndRestaurant(userID, restaurantID)
+	if err != nil {
+		return err
+	}
+
+	items := make([]model.OrderItem, 0, len(cart))
+	weights := make([]int64, 0, len(cart))
+	for _, cartItem := range cart {
+		product, err := tx.Products.FindByID(cartItem.ProductID)
+		if err != nil {
+			return err
+		}
+
+		item := model.OrderItem{
+			OrderID:            order.OrderID,
+			UserID:             userID,
+			ProductID:          cartItem.ProductID,
+			Quantity:           cartItem.Quantity,
+			Amount:             product.Price.Times(cartItem.Quantity),
+			ProductOfferAmount: product.OfferAmount.Times(cartItem.Quantity),
+			CookingRequest:     cartItem.CookingRequest,
+			OrderStatus:        model.OrderStatusInitiated,
+			RestaurantID:       product.RestaurantID,
+		}
+		items = append(items, item)
+		weights = append(weights, item.Amount.Paise())
+	}
+
+	// the shares always add up to the whole discount, so the restaurants are paid exactly FinalAmount
+	shares := order.CouponDiscountAmount.Allocate(weights)
+	for i := range items {
+		items[i].AfterDeduction = items[i].Amount - (items[i].ProductOfferAmount + shares[i])
+		if err := tx.Orders.CreateItem(&items[i]); err != nil {
+			return err
+		}
+		if err := recordStatus(tx, hub, items[i], "", statusChange{Actor: model.UserRole, ActorID: userID, Reason: "order placed"}); err != nil {
+			return err
+		}
+	}
+	return tx.Carts.ClearRestaurant(userID, restaurantID)
+}
+
+// cancelItems marks the items cancelled and puts their quantity back in stock
+func cancelItems(tx *repository.Repositories, hub *pubsub.Hub, items []model.OrderItem, change statusChange) ([]model.OrderItem, error) {
+	for i := range items {
+		if err := moveItem(tx, hub, &items[i], model.OrderStatusCancelled, change); err != nil {
+			return nil, err
+		}
+	}
+	if err := incrementStock(tx, items); err != nil {
+		return nil, newError(http.StatusConflict, "failed to increment order stock")
+	}
+	return items, nil
+}
+
+// releaseItems cancels the items of an order that was never paid, they never took any stock
+// and the stock they still hold is let go of
+func releaseItems(tx *repository.Repositories, hub *pubsub.Hub, orderID string) error {
+	_, err := moveOrderItems(tx, hub, orderID, model.OrderStatusCancelled,
+		statusChange{Actor: model.SystemActor, Reason: "the order was not paid in time"}, model.OrderStatusInitiated)
+	if err != nil {
+		return err
+	}
+	if err := releaseHolds(tx, orderID); err != nil {
+		return internal("failed to release the stock held for the order")
+	}
+	return nil
+}
+
+// startCODItems starts the items of a cash on delivery order waiting for their payment,
+// they don't wait for the cash and take their stock right away
+func startCODItems(tx *repository.Repositories, hub *pubsub.Hub, orderID string, reason string) error {
+	_, err := moveOrderItems(tx, hub, orderID, model.OrderStatusProcessing,
+		statusChange{Actor: model.SystemActor, Reason: reason}, model.OrderStatusInitiated)
+	if err != nil {
+		return err
+	}
+	if err := decrementStock(tx, orderID); err != nil {
+		return newError(http.StatusConflict, "failed to decrement order stock")
+	}
+	return nil
+}
+
+// incrementStock puts the quantity of the items back in stock
+func incrementStock(tx *repository.Repositories, items []model.OrderItem) error {
+	for _, item := range items {
+		if err := tx.Products.ReturnStock(item.ProductID, item.Quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decrementStock takes the quantity of the order's items in processing or preparation out of
+// stock, the stock held for the order is what it takes. every product is taken in one
+// conditional update, so concurrent orders can't take the same portion twice
+func decrementStock(tx *repository.Repositories, orderID string) error {
+	if err := releaseHolds(tx, orderID); err != nil {
+		return err
+	}
+	items, err := tx.Orders.ListItems(orderID)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if item.OrderStatus != model.OrderStatusProcessing && item.OrderStatus != model.OrderStatusInPreparation {
+			continue
+		}
+		if err := tx.Products.TakeStock(item.ProductID, item.Quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func filterItems(items []model.OrderItem, productID uint) []model.OrderItem {
+	var filtered []model.OrderItem
+	for _, item := range items {
+		if item.ProductID == productID {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/repository"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Accept takes the order items waiting for the restaurant, all of them or the one product, into
+// its kitchen. only accepted items can be prepared
+func (s *OrderService) Accept(restaurantID uint, request model.OrderDecision) ([]model.OrderItem, error) {
+	if _, err := s.restaurantOrder(restaurantID, request.OrderID); err != nil {
+		return nil, err
+	}
+	reason := strings.TrimSpace(request.Reason)
+	if reason == "" {
+		reason = "accepted by the restaurant"
+	}
+
+	var items []model.OrderItem
+	err := s.repos.Transaction(func(tx *repository.Repositories) error {
+		var err error
+		items, err = s.awaitingItems(tx, request.OrderID, request.ProductID)
+		if err != nil {
+			return err
+		}
+		change := statusChange{Actor: model.RestaurantRole, ActorID: restaurantID, Reason: reason}
+		for i := range items {
+			if err := moveItem(tx, s.events, &items[i], model.OrderStatusAccepted, change); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return items, err
+}
+
+// Reject turns down the order items waiting for the restaurant, all of them or the one product,
+// for the reason given to the user. their stock is put back and paid online items are refunded
+// the way the order was paid
+func (s *OrderService) Reject(ctx context.Context, restaurantID uint, request model.OrderDecision) ([]model.OrderItem, []model.Refund, error) {
+	order, err := s.restaurantOrder(restaurantID, request.OrderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(strings.Fields(request.Reason)) == 0 {
+		return nil, nil, badRequest("reason is required to reject an order")
+	}
+
+	change := statusChange{Actor: model.RestaurantRole, ActorID: restaurantID, Reason: "rejected by the restaurant: " + strings.TrimSpace(request.Reason)}
+	return s.rejectItems(ctx, order, change, func(tx *repository.Repositories) ([]model.OrderItem, error) {
+		return s.awaitingItems(tx, request.OrderID, request.ProductID)
+	})
+}
+
+// RejectOverdue rejects the order items the restaurants didn't accept within AcceptanceDeadline
+// every interval until ctx is cancelled
+func (s *OrderService) RejectOverdue(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		s.rejectOverdue(ctx)
+	}
+}
+
+// rejectOverdue rejects the overdue items order by order, an item the restaurant accepts in the
+// meantime fails the rejection of its order, which is left to the restaurant
+func (s *OrderService) rejectOverdue(ctx context.Context) {
+	overdue, err := s.repos.Orders.ListItemsWaitingSince(model.OrderStatusProcessing, time.Now().Add(-s.AcceptanceDeadline))
+	if err != nil {
+		log.Printf("acceptance: failed to fetch the overdue order items: %v", err)
+		return
+	}
+	orders := map[string][]model.OrderItem{}
+	var orderIDs []string
+	for _, item := range overdue {
+		if _, ok := orders[item.OrderID]; !ok {
+			orderIDs = append(orderIDs, item.OrderID)
+		}
+		orders[item.OrderID] = append(orders[item.OrderID], item)
+	}
+
+	change := statusChange{Actor: model.SystemActor, Reason: "the restaurant did not accept the order in time"}
+	for _, orderID := range orderIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		order, err := s.repos.Orders.FindByID(orderID)
+		if err != nil {
+			log.Printf("acceptance: failed to fetch order %s: %v", orderID, err)
+			continue
+		}
+		_, _, err = s.rejectItems(ctx, order, change, func(tx *repository.Repositories) ([]model.OrderItem, error) {
+			return orders[orderID], nil
+		})
+		if err != nil {
+			log.Printf("acceptance: failed to reject order %s: %v", orderID, err)
+		}
+	}
+}
+
+// rejectItems cancels the items listed in one transaction with their stock and refunds them
+// when the order was paid online, gateway refunds are sent once it commits
+func (s *OrderService) rejectItems(ctx context.Context, order model.Order, change statusChange, list func(tx *repository.Repositories) ([]model.OrderItem, error)) ([]model.OrderItem, []model.Refund, error) {
+	refundTo := ""
+	var source model.Payment
+	if order.PaymentMethod == model.OnlinePayment && order.PaymentStatus == model.OnlinePaymentConfirmed {
+		var err error
+		source, refundTo, err = s.refundSource(order.OrderID, model.RefundToSource)
+		if err != nil {
+			// nothing to refund through, the wallet takes it
+			refundTo = model.RefundToWallet
+		}
+	}
+
+	var items []model.OrderItem
+	var refunds []model.Refund
+	err := s.repos.Transaction(func(tx *repository.Repositories) error {
+		var err error
+		if items, err = list(tx); err != nil {
+			return err
+		}
+		if items, err = cancelItems(tx, s.events, items, change); err != nil {
+			return err
+		}
+		if refundTo == "" {
+			return nil
+		}
+		refunds, err = refundItems(tx, order, source, refundTo, items)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return items, s.sendRefunds(ctx, refunds), nil
+}
+
+// restaurantOrder returns the order when it was placed at the restaurant
+func (s *OrderService) restaurantOrder(restaurantID uint, orderID string) (model.Order, error) {
+	order, err := s.repos.Orders.FindByID(orderID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return order, notFound("order_id is not present")
+	}
+	if err != nil {
+		return order, internal("failed to fetch order information")
+	}
+	if order.RestaurantID != restaurantID {
+		return order, newError(http.StatusUnauthorized, "unauthorized request,order doesnt belong to this restaurant")
+	}
+	return order, nil
+}
+
+// awaitingItems returns the items of the order waiting for the restaurant to accept them,
+// only the product's when productID is set
+func (s *OrderService) awaitingItems(tx *repository.Repositories, orderID string, productID uint) ([]model.OrderItem, error) {
+	items, err := tx.Orders.ListItemsByStatus(orderID, model.OrderStatusProcessing)
+	if err != nil {
+		return nil, internal("failed to fetch the order items")
+	}
+	if productID != 0 {
+		items = filterItems(items, productID)
+	}
+	if len(items) == 0 {
+		return nil, newError(http.StatusConflict, "no order items are waiting for the restaurant to accept them")
+	}
+	return items, nil
+}
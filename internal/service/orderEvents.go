@@ -0,0 +1,48 @@
+package service
+
+import (
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/pubsub"
+	"foodbuddy/internal/repository"
+	"time"
+)
+
+// PaymentConfirmed is published when the payment of an order is confirmed
+type PaymentConfirmed struct {
+	OrderID       string `json:"order_id"`
+	PaymentMethod string `json:"payment_method"`
+	PaymentStatus string `json:"payment_status"`
+}
+
+// DeliveryCodeSent is published when the delivery code is mailed to the user, the code itself
+// is only in the mail
+type DeliveryCodeSent struct {
+	OrderID string `json:"order_id"`
+}
+
+// orderTopic is the topic the events of an order are published on
+func orderTopic(orderID string) string {
+	return "order:" + orderID
+}
+
+// publishOrderEvent publishes an event of the order once tx commits, nobody hears about a
+// change that was rolled back
+func publishOrderEvent(tx *repository.Repositories, hub *pubsub.Hub, orderID string, eventType string, data interface{}) {
+	event := pubsub.Event{Type: eventType, Data: data, At: time.Now()}
+	tx.AfterCommit(func() {
+		hub.Publish(orderTopic(orderID), event)
+	})
+}
+
+// Track subscribes the user to the events of their order and returns the order as it stands.
+// the subscription starts before the order is read, so no change falls in between, and the
+// caller closes it
+func (s *OrderService) Track(userID uint, orderID string) (OrderTimeline, *pubsub.Subscription, error) {
+	subscription := s.events.Subscribe(orderTopic(orderID))
+	timeline, err := s.Timeline(Principal{ID: userID, Role: model.UserRole}, orderID)
+	if err != nil {
+		subscription.Close()
+		return timeline, nil, err
+	}
+	return timeline, subscription, nil
+}
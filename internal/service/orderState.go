@@ -0,0 +1,163 @@
+package service
+
+import (
+	"fmt"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/pubsub"
+	"foodbuddy/internal/repository"
+	"net/http"
+)
+
+// orderTransition is a step an order item can take and the actors allowed to take it
+type orderTransition struct {
+	From   string
+	To     string
+	Actors []string
+}
+
+// orderTransitions is the order state machine. an item is INITIATED when the order is placed
+// and PROCESSING once it is paid, right away for cash on delivery. the restaurant accepts or
+// rejects it, the system rejects it once the acceptance deadline passes, and takes an accepted
+// item through preparation to the door. the user can cancel it until it is prepared and the
+// system cancels the items of orders that were never paid. no other step is allowed
+var orderTransitions = []orderTransition{
+	{"", model.OrderStatusInitiated, []string{model.UserRole}},
+	{model.OrderStatusInitiated, model.OrderStatusProcessing, []string{model.SystemActor}},
+	// a cash on delivery order switched to online payment waits for the payment again
+	{model.OrderStatusProcessing, model.OrderStatusInitiated, []string{model.SystemActor}},
+	{model.OrderStatusProcessing, model.OrderStatusAccepted, []string{model.RestaurantRole}},
+	{model.OrderStatusAccepted, model.OrderStatusInPreparation, []string{model.RestaurantRole}},
+	{model.OrderStatusInPreparation, model.OrderStatusPrepared, []string{model.RestaurantRole}},
+	{model.OrderStatusPrepared, model.OrderStatusOntheway, []string{model.RestaurantRole}},
+	{model.OrderStatusOntheway, model.OrderStatusDelivered, []string{model.RestaurantRole}},
+	{model.OrderStatusInitiated, model.OrderStatusCancelled, []string{model.SystemActor, model.AdminRole}},
+	// the restaurant rejecting an item cancels it, so does the system when the deadline passes
+	{model.OrderStatusProcessing, model.OrderStatusCancelled, []string{model.UserRole, model.RestaurantRole, model.SystemActor, model.AdminRole}},
+	{model.OrderStatusAccepted, model.OrderStatusCancelled, []string{model.UserRole, model.AdminRole}},
+	{model.OrderStatusInPreparation, model.OrderStatusCancelled, []string{model.UserRole, model.AdminRole}},
+	{model.OrderStatusPrepared, model.OrderStatusCancelled, []string{model.AdminRole}},
+}
+
+// statusChange is who moves an order item and why, the system has no actor id
+type statusChange struct {
+	Actor   string
+	ActorID uint
+	Reason  string
+}
+
+// checkTransition makes sure the actor may move an order item from one status to the other
+func checkTransition(from string, to string, actor string) error {
+	known := false
+	for _, t := range orderTransitions {
+		if t.From != from || t.To != to {
+			continue
+		}
+		known = true
+		for _, allowed := range t.Actors {
+			if allowed == actor {
+				return nil
+			}
+		}
+	}
+	if !known {
+		return newError(http.StatusConflict, fmt.Sprintf("an order item can't go from %s to %s", from, to))
+	}
+	return newError(http.StatusForbidden, fmt.Sprintf("%s can't move an order item from %s to %s", actor, from, to))
+}
+
+// statusesBefore returns the statuses the actor may move an order item to the status from
+func statusesBefore(to string, actor string) []string {
+	var statuses []string
+	for _, t := range orderTransitions {
+		if t.To != to {
+			continue
+		}
+		for _, allowed := range t.Actors {
+			if allowed == actor {
+				statuses = append(statuses, t.From)
+				break
+			}
+		}
+	}
+	return statuses
+}
+
+// nextStatus returns the status the actor takes an order item to from its status, cancelling
+// is not a step forward. it is empty when the actor can't move the item any further
+func nextStatus(from string, actor string) string {
+	for _, t := range orderTransitions {
+		if t.From != from || t.To == model.OrderStatusCancelled {
+			continue
+		}
+		for _, allowed := range t.Actors {
+			if allowed == actor {
+				return t.To
+			}
+		}
+	}
+	return ""
+}
+
+// moveItem takes an order item one step through the state machine, records the step in the
+// order's history and publishes it on the hub. the item must still be in the status it was read with
+func moveItem(tx *repository.Repositories, hub *pubsub.Hub, item *model.OrderItem, to string, change statusChange) error {
+	if err := checkTransition(item.OrderStatus, to, change.Actor); err != nil {
+		return err
+	}
+	moved, err := tx.Orders.MoveItem(item.OrderID, item.ProductID, item.OrderStatus, to)
+	if err != nil {
+		return internal("failed to update order item status")
+	}
+	if !moved {
+		return newError(http.StatusConflict, "the order item was updated in the meantime, please try again")
+	}
+	from := item.OrderStatus
+	item.OrderStatus = to
+	return recordStatus(tx, hub, *item, from, change)
+}
+
+// moveOrderItems moves the order's items in one of the from statuses and returns them
+func moveOrderItems(tx *repository.Repositories, hub *pubsub.Hub, orderID string, to string, change statusChange, from ...string) ([]model.OrderItem, error) {
+	items, err := tx.Orders.ListItemsByStatus(orderID, from...)
+	if err != nil {
+		return nil, internal("failed to fetch the order items")
+	}
+	for i := range items {
+		if err := moveItem(tx, hub, &items[i], to, change); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// recordStatus adds the step of the item from the status to its current one to the history,
+// the customers tracking the order and the kitchen hear about it once tx commits
+func recordStatus(tx *repository.Repositories, hub *pubsub.Hub, item model.OrderItem, from string, change statusChange) error {
+	entry := model.OrderStatusHistory{
+		OrderID:    item.OrderID,
+		ProductID:  item.ProductID,
+		FromStatus: from,
+		ToStatus:   item.OrderStatus,
+		Actor:      change.Actor,
+		ActorID:    change.ActorID,
+		Reason:     change.Reason,
+	}
+	if err := tx.Orders.AddStatusHistory(&entry); err != nil {
+		return internal("failed to record the order status history")
+	}
+	publishOrderEvent(tx, hub, item.OrderID, model.OrderEventItemStatus, entry)
+
+	eventType := kitchenEventType(from, item.OrderStatus)
+	if eventType == "" {
+		return nil
+	}
+	return recordKitchenEvent(tx, hub, model.KitchenEvent{
+		RestaurantID:   item.RestaurantID,
+		OrderID:        item.OrderID,
+		ProductID:      item.ProductID,
+		EventType:      eventType,
+		Quantity:       item.Quantity,
+		CookingRequest: item.CookingRequest,
+		Reason:         change.Reason,
+	})
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"foodbuddy/internal/mail"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/repository"
+	"log"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// OutboxService lets admins look at the outbox and requeue dead lettered messages
+type OutboxService struct {
+	repos *repository.Repositories
+}
+
+func NewOutboxService(repos *repository.Repositories) *OutboxService {
+	return &OutboxService{repos: repos}
+}
+
+// List returns the latest messages with the status, every status when it is empty
+func (s *OutboxService) List(status string, limit int) ([]model.OutboxMessage, error) {
+	switch status {
+	case "", model.OutboxPending, model.OutboxProcessing, model.OutboxSent, model.OutboxDead:
+	default:
+		return nil, badRequest("status should be one of PENDING, PROCESSING, SENT or DEAD")
+	}
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	messages, err := s.repos.Outbox.List(status, limit)
+	if err != nil {
+		return nil, internal("failed to fetch outbox messages")
+	}
+	return messages, nil
+}
+
+// Requeue gives a dead message a fresh set of delivery attempts
+func (s *OutboxService) Requeue(id uint) error {
+	if _, err := s.repos.Outbox.FindByID(id); err != nil {
+		return notFound("outbox message not found")
+	}
+	requeued, err := s.repos.Outbox.Requeue(id, time.Now())
+	if err != nil {
+		return internal("failed to requeue the message")
+	}
+	if !requeued {
+		return newError(http.StatusConflict, "only dead messages can be requeued")
+	}
+	return nil
+}
+
+// enqueueEmail renders the named template in the locale and puts it in the outbox, tx should
+// be the transaction of the change the mail is about so the mail is only sent if it commits
+func enqueueEmail(tx *repository.Repositories, templates *mail.Templates, to string, name string, locale string, data interface{}) error {
+	msg, err := templates.Render(name, locale, data)
+	if err != nil {
+		return err
+	}
+	return tx.Outbox.Enqueue(&model.OutboxMessage{
+		Recipient: to,
+		Template:  name,
+		Subject:   msg.Subject,
+		HTML:      msg.HTML,
+		Text:      msg.Text,
+	})
+}
+
+// OutboxWorker delivers the outbox with a pool of workers. it polls for due messages,
+// leases them so other instances leave them alone, and reschedules failed deliveries
+// with exponential backoff until they are dead lettered
+type OutboxWorker struct {
+	repos   *repository.Repositories
+	mailer  mail.Mailer
+	workers int
+
+	// PollInterval is how long the worker waits when the outbox had nothing due
+	PollInterval time.Duration
+	// Lease is how long a claimed message is left alone, a worker that crashed
+	// while sending gets its messages picked up again after it
+	Lease time.Duration
+}
+
+func NewOutboxWorker(repos *repository.Repositories, mailer mail.Mailer, workers int) *OutboxWorker {
+	if workers < 1 {
+		workers = 1
+	}
+	return &OutboxWorker{
+		repos:        repos,
+		mailer:       mailer,
+		workers:      workers,
+		PollInterval: 2 * time.Second,
+		Lease:        2 * time.Minute,
+	}
+}
+
+// Run delivers messages until ctx is cancelled, then waits for the deliveries in progress
+func (w *OutboxWorker) Run(ctx context.Context) {
+	jobs := make(chan model.OutboxMessage)
+	var wg sync.WaitGroup
+	for i := 0; i < w.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for msg := range jobs {
+				w.deliver(msg)
+			}
+		}()
+	}
+	defer func() {
+		close(jobs)
+		wg.Wait()
+	}()
+
+	batch := w.workers * 4
+	for {
+		claimed, err := w.repos.Outbox.Claim(time.Now(), batch, w.Lease)
+		if err != nil {
+			log.Printf("outbox: failed to claim messages: %v", err)
+		}
+		for _, msg := range claimed {
+			select {
+			case jobs <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+		// a full batch means more is probably due, claim again right away
+		if err == nil && len(claimed) == batch {
+			continue
+		}
+		select {
+		case <-time.After(w.PollInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (w *OutboxWorker) deliver(msg model.OutboxMessage) {
+	// the attempts were used up by workers that never got to record them
+	if msg.Attempts > model.OutboxMaxAttempts {
+		log.Printf("outbox: message %d to %s is dead after %d attempts that didn't finish", msg.ID, msg.Recipient, model.OutboxMaxAttempts)
+		if err := w.repos.Outbox.MarkFailed(msg.ID, model.OutboxMaxAttempts, model.OutboxDead, time.Now(), "the delivery was abandoned"); err != nil {
+			log.Printf("outbox: failed to record the failed delivery of message %d: %v", msg.ID, err)
+		}
+		return
+	}
+
+	err := w.mailer.Send(mail.Message{
+		To:      []string{msg.Recipient},
+		Subject: msg.Subject,
+		HTML:    msg.HTML,
+		Text:    msg.Text,
+	})
+	now := time.Now()
+	if err == nil {
+		if err := w.repos.Outbox.MarkSent(msg.ID, now); err != nil {
+			log.Printf("outbox: message %d was sent but could not be marked: %v", msg.ID, err)
+		}
+		return
+	}
+
+	// the claim counted this attempt
+	attempts := msg.Attempts
+	status, next := model.OutboxPending, now.Add(outboxBackoff(attempts))
+	if attempts >= model.OutboxMaxAttempts {
+		status, next = model.OutboxDead, now
+		log.Printf("outbox: message %d to %s is dead after %d attempts: %v", msg.ID, msg.Recipient, attempts, err)
+	}
+	if err := w.repos.Outbox.MarkFailed(msg.ID, attempts, status, next, err.Error()); err != nil {
+		log.Printf("outbox: failed to record the failed delivery of message %d: %v", msg.ID, err)
+	}
+}
+
+// outboxBackoff is the wait before the next attempt, doubling every attempt up to the maximum
+// with up to a fifth taken off at random so messages that failed together spread out
+func outboxBackoff(attempts int) time.Duration {
+	wait := time.Duration(model.OutboxRetryBase) * time.Second
+	for i := 1; i < attempts && wait < model.OutboxRetryMax*time.Second; i++ {
+		wait *= 2
+	}
+	if wait > model.OutboxRetryMax*time.Second {
+		wait = model.OutboxRetryMax * time.Second
+	}
+	return wait - time.Duration(rand.Int63n(int64(wait)/5+1))
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"foodbuddy/internal/mail"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/repository"
+	"testing"
+	"time"
+)
+
+// a message whose worker never finishes is claimed again once its lease runs out, every claim
+// counts as an attempt so it is dead lettered instead of being retried forever
+func TestOutboxAbandonedDeliveriesRunOut(t *testing.T) {
+	repos := repository.New(openTestDB(t))
+	mailer := mail.NewMemoryMailer()
+	worker := NewOutboxWorker(repos, mailer, 1)
+	msg := model.OutboxMessage{Recipient: "asha@example.com", Subject: "order placed"}
+	if err := repos.Outbox.Enqueue(&msg); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	now := time.Now()
+	for attempt := 1; attempt <= model.OutboxMaxAttempts; attempt++ {
+		claimed, err := repos.Outbox.Claim(now, 10, time.Minute)
+		if err != nil {
+			t.Fatalf("claim: %v", err)
+		}
+		if len(claimed) != 1 || claimed[0].Attempts != attempt {
+			t.Fatalf("claim %d handed out %+v, want the message on attempt %d", attempt, claimed, attempt)
+		}
+		// the worker crashes, the lease runs out
+		now = now.Add(2 * time.Minute)
+	}
+
+	claimed, err := repos.Outbox.Claim(now, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if len(claimed) != 1 {
+		t.Fatalf("%d messages claimed, want 1", len(claimed))
+	}
+	worker.deliver(claimed[0])
+	if len(mailer.Messages()) != 0 {
+		t.Errorf("sent %d messages after the attempts ran out", len(mailer.Messages()))
+	}
+	stored, err := repos.Outbox.FindByID(msg.ID)
+	if err != nil {
+		t.Fatalf("find message: %v", err)
+	}
+	if stored.Status != model.OutboxDead || stored.Attempts != model.OutboxMaxAttempts {
+		t.Errorf("message is %s after %d attempts, want %s after %d", stored.Status, stored.Attempts, model.OutboxDead, model.OutboxMaxAttempts)
+	}
+	if claimed, err := repos.Outbox.Claim(now.Add(time.Hour), 10, time.Minute); err != nil || len(claimed) != 0 {
+		t.Errorf("claimed %d dead messages: %v", len(claimed), err)
+	}
+}
@@ -0,0 +1,461 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/payment"
+	"foodbuddy/internal/pubsub"
+	"foodbuddy/internal/repository"
+	"foodbuddy/internal/utils"
+	"log"
+	"net/http"
+)
+
+type PaymentService struct {
+	repos    *repository.Repositories
+	gateways *payment.Registry
+	events   *pubsub.Hub
+	stock    *StockService
+}
+
+// NewPaymentService pays orders through the gateways, the wallet gateway is added to them.
+// the orders confirmed are published on events and the stock of the orders being paid is
+// held through stock
+func NewPaymentService(repos *repository.Repositories, gateways *payment.Registry, events *pubsub.Hub, stock *StockService) *PaymentService {
+	gateways.Register(newWalletGateway(repos))
+	return &PaymentService{repos: repos, gateways: gateways, events: events, stock: stock}
+}
+
+// Payable returns the order when it still waits for an online payment
+func (s *PaymentService) Payable(userID uint, orderID string) (model.Order, error) {
+	order, err := findOwnedOrder(s.repos, userID, orderID)
+	if err != nil {
+		return order, err
+	}
+	switch order.PaymentStatus {
+	case model.OnlinePaymentConfirmed:
+		return order, newError(http.StatusAlreadyReported, "Payment already done")
+	case model.CODStatusPending, model.CODStatusConfirmed:
+		return order, newError(http.StatusAlreadyReported, "Customer chose payment via COD")
+	case model.OnlinePaymentRefunded:
+		return order, newError(http.StatusAlreadyReported, "Payment was refunded")
+	case model.OnlinePaymentExpired:
+		return order, newError(http.StatusGone, "order expired before it was paid, please place it again")
+	}
+	return order, nil
+}
+
+// Initiate starts paying the user's order through the gateway picked in the request. the
+// checkout says how the user pays: a page to render, a url to go to, or already paid. the stock
+// of the order is held again for the checkout
+func (s *PaymentService) Initiate(ctx context.Context, userID uint, request model.InitiatePayment) (payment.Checkout, error) {
+	order, err := s.Payable(userID, request.OrderID)
+	if err != nil {
+		return payment.Checkout{}, err
+	}
+	gateway, err := s.gateways.Get(request.PaymentGateway)
+	if err != nil {
+		return payment.Checkout{}, badRequest(err.Error())
+	}
+	err = s.repos.Transaction(func(tx *repository.Repositories) error {
+		return s.stock.hold(tx, order.OrderID)
+	})
+	if err != nil {
+		return payment.Checkout{}, err
+	}
+
+	returnURL, cancelURL := callbackURLs(gateway.Name(), order.OrderID)
+	checkout, err := gateway.CreateIntent(ctx, payment.Intent{
+		OrderID:     order.OrderID,
+		UserID:      order.UserID,
+		Amount:      order.FinalAmount,
+		Currency:    "INR",
+		Description: order.PaymentMethod,
+		ReturnURL:   returnURL,
+		CancelURL:   cancelURL,
+	})
+	if err != nil {
+		s.MarkFailed(order.OrderID)
+		if errors.Is(err, payment.ErrInsufficientFunds) {
+			return checkout, newError(http.StatusPaymentRequired, "insufficient wallet balance")
+		}
+		return checkout, newError(http.StatusServiceUnavailable, err.Error())
+	}
+
+	record := model.Payment{
+		OrderID:        order.OrderID,
+		PaymentGateway: gateway.Name(),
+		PaymentStatus:  model.OnlinePaymentPending,
+		Amount:         order.FinalAmount,
+	}
+	setGatewayIDs(&record, gateway.Name(), checkout.Reference, "")
+
+	// the gateway has the checkout from here on, failing leaves it behind and a retry would
+	// start another one
+	if checkout.Status != payment.StatusPaid {
+		if err := s.repos.Payments.Create(&record); err != nil {
+			s.MarkFailed(order.OrderID)
+			return checkout, applied(internal("failed to store the payment information"))
+		}
+		return checkout, nil
+	}
+
+	// the gateway settled on the spot, the order is confirmed with the payment
+	record.PaymentStatus = model.OnlinePaymentConfirmed
+	setGatewayIDs(&record, gateway.Name(), checkout.Reference, checkout.Reference)
+	err = s.repos.Transaction(func(tx *repository.Repositories) error {
+		if err := tx.Payments.Create(&record); err != nil {
+			return internal("failed to store the payment information")
+		}
+		return confirmOrderPayment(tx, s.events, order.OrderID, paidFrom(gateway.Name()))
+	})
+	if err != nil {
+		s.MarkFailed(order.OrderID)
+		return checkout, applied(err)
+	}
+	return checkout, nil
+}
+
+// Confirm verifies the proof a checkout came back with and confirms the order when it is paid.
+// orderID is matched against the payment when the callback carries one. a checkout that was
+// confirmed already is returned as is, so repeated callbacks settle the order once
+func (s *PaymentService) Confirm(ctx context.Context, userID uint, orderID string, gatewayName string, proof payment.Proof) (model.Payment, error) {
+	gateway, err := s.gateways.Get(gatewayName)
+	if err != nil {
+		return model.Payment{}, badRequest(err.Error())
+	}
+	record, err := s.repos.Payments.FindByReference(gateway.Name(), proof.Reference)
+	if err != nil || (orderID != "" && record.OrderID != orderID) {
+		return record, notFound("payment not found")
+	}
+	if _, err := findOwnedOrder(s.repos, userID, record.OrderID); err != nil {
+		return record, err
+	}
+	if record.PaymentStatus == model.OnlinePaymentConfirmed {
+		return record, nil
+	}
+
+	result, err := gateway.Verify(ctx, proof)
+	if errors.Is(err, payment.ErrInvalidProof) {
+		failPayment(s.repos, record)
+		return record, badRequest("failed to verify")
+	}
+	if err != nil {
+		return record, internal("failed to fetch the payment from " + gateway.Name())
+	}
+	if result.Status != payment.StatusPaid {
+		failPayment(s.repos, record)
+		return record, newError(http.StatusPaymentRequired, "payment was not completed")
+	}
+
+	applied := true
+	err = s.repos.Transaction(func(tx *repository.Repositories) error {
+		applied, err = settlePayment(tx, s.events, record, result.PaymentID, proof.Signature)
+		return err
+	})
+	if err != nil {
+		// the gateway has the money, the payment stays open for the reconciliation to settle
+		return record, err
+	}
+	record, err = s.repos.Payments.FindByReference(gateway.Name(), proof.Reference)
+	if err == nil && !applied {
+		return record, newError(http.StatusConflict, "the order no longer takes the payment, it will be refunded")
+	}
+	return record, err
+}
+
+// MarkFailed flags the order's online payment as failed
+func (s *PaymentService) MarkFailed(orderID string) {
+	s.repos.Orders.SetPaymentStatus(orderID, model.OnlinePaymentFailed)
+	expireHolds(s.repos, orderID)
+}
+
+// MarkFailedFor flags the online payment of the user's order as failed
+func (s *PaymentService) MarkFailedFor(userID uint, orderID string) error {
+	if _, err := findOwnedOrder(s.repos, userID, orderID); err != nil {
+		return err
+	}
+	s.MarkFailed(orderID)
+	return nil
+}
+
+func (s *PaymentService) Details(userID uint, orderID string, status string) ([]model.Payment, error) {
+	if _, err := findOwnedOrder(s.repos, userID, orderID); err != nil {
+		return nil, err
+	}
+	var payments []model.Payment
+	var err error
+	if status != "" {
+		payments, err = s.repos.Payments.ListByOrderAndStatus(orderID, status)
+	} else {
+		payments, err = s.repos.Payments.ListByOrder(orderID)
+	}
+	if err != nil {
+		return nil, internal("failed to fetch payment information")
+	}
+	if len(payments) == 0 {
+		return nil, notFound("failed to fetch payment information of this order_id")
+	}
+	return payments, nil
+}
+
+// VerifyOnline returns the confirmed online payment of the user's order
+func (s *PaymentService) VerifyOnline(userID uint, orderID string) (model.Payment, error) {
+	order, err := s.repos.Orders.FindByID(orderID)
+	if err != nil || order.UserID != userID {
+		return model.Payment{}, newError(http.StatusUnauthorized, "please provide orderid from the orders you have initiated")
+	}
+	payment, err := s.repos.Payments.FindByOrderAndStatus(orderID, model.OnlinePaymentConfirmed)
+	if err != nil {
+		return payment, notFound("online payment is not done")
+	}
+	return payment, nil
+}
+
+// ChangeMode switches an unpaid order of the user between COD and ONLINE
+func (s *PaymentService) ChangeMode(userID uint, request model.ChangeOrderPaymentMode) (model.Order, error) {
+	order, err := s.repos.Orders.FindByID(request.OrderID)
+	if err == nil && order.UserID != userID {
+		return order, badRequest("unauthorized request")
+	}
+	if request.PaymentMethod != model.CashOnDelivery && request.PaymentMethod != model.OnlinePayment {
+		return order, notFound("payment method should be either COD or ONLINE")
+	}
+	if err != nil {
+		return order, notFound("order_id doesn't exist or failed to retrieve the order_id, try again")
+	}
+	if request.PaymentMethod == model.CashOnDelivery && order.TotalAmount >= model.CODMaximumAmount {
+		return order, newError(http.StatusMethodNotAllowed, "switch to ONLINE payment for total amounts greater than or equal to 1000")
+	}
+	if request.PaymentMethod == order.PaymentMethod {
+		return order, newError(http.StatusMethodNotAllowed, "payment method is already : "+request.PaymentMethod)
+	}
+	if order.PaymentStatus == model.OnlinePaymentConfirmed || order.PaymentStatus == model.CODStatusConfirmed {
+		return order, newError(http.StatusMethodNotAllowed, "payment is already done, cannot update the payment status")
+	}
+	if order.PaymentStatus == model.OnlinePaymentExpired || order.PaymentStatus == model.OnlinePaymentRefunded {
+		return order, newError(http.StatusMethodNotAllowed, "order is closed, cannot update the payment status")
+	}
+
+	order.PaymentMethod = request.PaymentMethod
+	order.PaymentStatus = model.OnlinePaymentPending
+	if request.PaymentMethod == model.CashOnDelivery {
+		order.PaymentStatus = model.CODStatusPending
+	}
+	err = s.repos.Transaction(func(tx *repository.Repositories) error {
+		if err := tx.Orders.Update(&order); err != nil {
+			return internal("failed to change payment method")
+		}
+		if request.PaymentMethod == model.CashOnDelivery {
+			return startCODItems(tx, s.events, order.OrderID, "switched to cash on delivery")
+		}
+		if err := stopCODItems(tx, s.events, order.OrderID); err != nil {
+			return err
+		}
+		return s.stock.hold(tx, order.OrderID)
+	})
+	return order, err
+}
+
+// stopCODItems puts the items of a cash on delivery order switched to online payment back to
+// waiting for the payment, with their stock. the restaurant must not have accepted them
+func stopCODItems(tx *repository.Repositories, hub *pubsub.Hub, orderID string) error {
+	started, err := tx.Orders.ListItemsByStatus(orderID, model.OrderStatusAccepted, model.OrderStatusInPreparation, model.OrderStatusPrepared, model.OrderStatusOntheway, model.OrderStatusDelivered)
+	if err != nil {
+		return internal("failed to fetch the order items")
+	}
+	if len(started) > 0 {
+		return newError(http.StatusMethodNotAllowed, "the restaurant accepted the order, cannot update the payment method")
+	}
+	items, err := moveOrderItems(tx, hub, orderID, model.OrderStatusInitiated,
+		statusChange{Actor: model.SystemActor, Reason: "switched to online payment"}, model.OrderStatusProcessing)
+	if err != nil {
+		return err
+	}
+	if err := incrementStock(tx, items); err != nil {
+		return internal("failed to increment order stock")
+	}
+	return nil
+}
+
+// errSoldOut is returned by confirmOrderPayment when the stock of the order went to other
+// orders while it was paid, its hold had expired
+var errSoldOut = newError(http.StatusConflict, "the stock of the order ran out")
+
+// confirmOrderPayment marks the order paid, starts its items, takes the stock and credits
+// the restaurants from the paidFrom ledger account, tx should be the transaction the payment is recorded in
+func confirmOrderPayment(tx *repository.Repositories, hub *pubsub.Hub, orderID string, paidFrom string) error {
+	if err := tx.Orders.SetPaymentStatus(orderID, model.OnlinePaymentConfirmed); err != nil {
+		return internal("failed to update payment status")
+	}
+	publishOrderEvent(tx, hub, orderID, model.OrderEventPaymentConfirmed, PaymentConfirmed{
+		OrderID:       orderID,
+		PaymentMethod: model.OnlinePayment,
+		PaymentStatus: model.OnlinePaymentConfirmed,
+	})
+	_, err := moveOrderItems(tx, hub, orderID, model.OrderStatusProcessing,
+		statusChange{Actor: model.SystemActor, Reason: "payment confirmed"}, model.OrderStatusInitiated)
+	if err != nil {
+		return internal("failed to update order status")
+	}
+	err = decrementStock(tx, orderID)
+	if errors.Is(err, repository.ErrOutOfStock) {
+		return errSoldOut
+	}
+	if err != nil {
+		return internal("failed to decrement order stock")
+	}
+	if err := splitMoneyToRestaurants(tx, orderID, paidFrom); err != nil {
+		return internal("failed to split payment for restaurant")
+	}
+	return nil
+}
+
+// settlePayment confirms the payment of a checkout and the order it pays for. the callbacks,
+// the webhooks and the reconciliation all settle through it, whichever comes first confirms
+// the order and the others find the payment settled. an order that no longer waits for a
+// payment, paid through another checkout or expired, is left alone and an order whose stock
+// ran out is closed, the payment is recorded as a refund due and settlePayment reports false
+func settlePayment(tx *repository.Repositories, hub *pubsub.Hub, record model.Payment, paymentID string, signature string) (bool, error) {
+	update := model.Payment{PaymentStatus: model.OnlinePaymentConfirmed}
+	setGatewayIDs(&update, record.PaymentGateway, "", paymentID)
+	if record.PaymentGateway == model.Razorpay {
+		update.RazorpaySignature = signature
+	}
+	settled, err := tx.Payments.Settle(record.PaymentGateway, record.GatewayReference, &update)
+	if err != nil {
+		return false, internal("failed to update payment informations")
+	}
+	if !settled {
+		return true, nil
+	}
+
+	order, err := tx.Orders.FindByID(record.OrderID)
+	if err != nil {
+		return false, internal("failed to fetch the order")
+	}
+	if !awaitsPayment(order) {
+		return false, refundDue(tx, record, paymentID, "the order is "+order.PaymentStatus)
+	}
+	err = tx.Transaction(func(tx *repository.Repositories) error {
+		return confirmOrderPayment(tx, hub, record.OrderID, paidFrom(record.PaymentGateway))
+	})
+	if errors.Is(err, errSoldOut) {
+		return false, closeSoldOut(tx, hub, record, paymentID)
+	}
+	return err == nil, err
+}
+
+// closeSoldOut keeps the payment of an order whose stock ran out confirmed as a refund due and
+// closes the order, its items are cancelled
+func closeSoldOut(tx *repository.Repositories, hub *pubsub.Hub, record model.Payment, paymentID string) error {
+	if err := refundDue(tx, record, paymentID, errSoldOut.Message); err != nil {
+		return err
+	}
+	if _, err := tx.Orders.Expire(record.OrderID); err != nil {
+		return internal("failed to update payment status")
+	}
+	_, err := moveOrderItems(tx, hub, record.OrderID, model.OrderStatusCancelled,
+		statusChange{Actor: model.SystemActor, Reason: "the stock ran out before the order was paid"}, model.OrderStatusInitiated)
+	if err != nil {
+		return internal("failed to update order status")
+	}
+	if err := releaseHolds(tx, record.OrderID); err != nil {
+		return internal("failed to release the stock held for the order")
+	}
+	return nil
+}
+
+// refundDue records a payment the gateway captured for an order that can't take it, the next
+// reconciliation run reports it for finance to refund. wallet payments haven't moved any
+// money yet, they are turned down instead
+func refundDue(tx *repository.Repositories, record model.Payment, paymentID string, reason string) error {
+	if record.PaymentGateway == model.Wallet {
+		return newError(http.StatusConflict, reason+", it can't be paid")
+	}
+	log.Printf("payment: %s, %s payment %s of order %s should be refunded", reason, record.PaymentGateway, record.GatewayReference, record.OrderID)
+	err := tx.Reconciliations.AddDiscrepancy(&model.PaymentDiscrepancy{
+		OrderID:       record.OrderID,
+		Gateway:       record.PaymentGateway,
+		Reference:     record.GatewayReference,
+		PaymentID:     paymentID,
+		Amount:        record.Amount,
+		OurStatus:     model.OnlinePaymentConfirmed,
+		GatewayStatus: payment.StatusPaid,
+		Action:        model.ReconcileRefundDue,
+		Note:          reason + ", the payment should be refunded",
+	})
+	if err != nil {
+		return internal("failed to record the refund due")
+	}
+	return nil
+}
+
+// failPayment marks the payment of a checkout failed, and its order when the order was
+// still waiting for it. confirmed payments and orders are left alone
+func failPayment(tx *repository.Repositories, record model.Payment) error {
+	if err := tx.Payments.Fail(record.PaymentGateway, record.GatewayReference); err != nil {
+		return internal("failed to update payment informations")
+	}
+	order, err := tx.Orders.FindByID(record.OrderID)
+	if err != nil {
+		return internal("failed to fetch the order")
+	}
+	if order.PaymentStatus != model.OnlinePaymentPending {
+		return nil
+	}
+	if err := tx.Orders.SetPaymentStatus(record.OrderID, model.OnlinePaymentFailed); err != nil {
+		return internal("failed to update payment status")
+	}
+	if err := expireHolds(tx, record.OrderID); err != nil {
+		return internal("failed to release the stock held for the order")
+	}
+	return nil
+}
+
+// awaitsPayment reports whether an online payment can still confirm the order
+func awaitsPayment(order model.Order) bool {
+	return order.PaymentStatus == model.OnlinePaymentPending || order.PaymentStatus == model.OnlinePaymentFailed
+}
+
+// paidFrom is the ledger account the money of a payment through the gateway comes from
+func paidFrom(gateway string) string {
+	if gateway == model.Wallet {
+		return model.LedgerUserWallet
+	}
+	return model.LedgerPaymentGateway
+}
+
+// setGatewayIDs stores the checkout reference and the payment id of the gateway, in the
+// gateway_ columns and in the columns of the gateway that older clients read
+func setGatewayIDs(record *model.Payment, gateway string, reference string, paymentID string) {
+	record.GatewayReference = reference
+	record.GatewayPaymentID = paymentID
+	switch gateway {
+	case model.Razorpay:
+		record.RazorpayOrderID, record.RazorpayPaymentID = reference, paymentID
+	case model.Stripe:
+		record.StripeSessionID, record.StripePaymentID = reference, paymentID
+	case model.Wallet:
+		record.WalletPaymentID = reference
+	}
+}
+
+// callbackURLs returns where the gateway sends the user back to after paying and after giving up
+func callbackURLs(gateway string, orderID string) (string, string) {
+	serverURL := utils.GetEnvVariables().ServerURL
+	switch gateway {
+	case model.Razorpay:
+		return fmt.Sprintf("%v/api/v1/user/order/step3/razorpaycallback/%v", serverURL, orderID),
+			fmt.Sprintf("%v/api/v1/user/order/step3/razorpaycallback/failed/%v", serverURL, orderID)
+	case model.Stripe:
+		url := fmt.Sprintf("%v/api/v1/user/order/step3/stripecallback", serverURL)
+		return url, url
+	case model.FakeGateway:
+		url := fmt.Sprintf("%v/api/v1/user/order/step3/fakecallback", serverURL)
+		return url, url
+	}
+	return "", ""
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"foodbuddy/internal/database"
+	"foodbuddy/internal/database/migrations"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/payment"
+	"foodbuddy/internal/repository"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// paymentFixture is a user with an address and a product of a restaurant in their cart
+type paymentFixture struct {
+	repos    *repository.Repositories
+	services *Services
+	fake     *payment.FakeGateway
+	user     model.User
+	address  model.Address
+	product  model.Product
+}
+
+// openTestDB migrates a fresh sqlite file
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.Open(database.Config{Driver: database.DriverSQLite, Name: filepath.Join(t.TempDir(), "foodbuddy.db")})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	db.Logger = logger.Discard
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func newPaymentFixture(t *testing.T, stock uint, quantity uint) paymentFixture {
+	t.Helper()
+	db := openTestDB(t)
+	f := paymentFixture{repos: repository.New(db), fake: payment.NewFakeGateway()}
+	f.services = New(f.repos, nil, payment.NewRegistry(f.fake))
+	f.user = model.User{ID: 1, Name: "asha", Email: "asha@example.com", PhoneNumber: "9000000001"}
+	restaurant := model.Restaurant{ID: 1, Name: "paradise", Email: "paradise@example.com"}
+	f.address = model.Address{UserID: f.user.ID, PhoneNumber: "9000000001", City: "hyderabad"}
+	f.product = model.Product{RestaurantID: restaurant.ID, CategoryID: 1, Name: "biryani", Price: 25000, MaxStock: stock, StockLeft: stock}
+	for _, row := range []interface{}{&f.user, &restaurant, &f.address, &f.product} {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("seed %T: %v", row, err)
+		}
+	}
+	cart := model.CartItems{UserID: f.user.ID, ProductID: f.product.ID, RestaurantID: restaurant.ID, Quantity: quantity}
+	if err := db.Create(&cart).Error; err != nil {
+		t.Fatalf("seed cart: %v", err)
+	}
+	return f
+}
+
+// checkout places the cart as an online order and starts paying it through the fake gateway
+func (f paymentFixture) checkout(t *testing.T) (model.Order, payment.Checkout) {
+	t.Helper()
+	order, err := f.services.Orders.Place(f.user.ID, model.PlaceOrder{AddressID: f.address.AddressID, RestaurantID: f.product.RestaurantID, PaymentMethod: model.OnlinePayment})
+	if err != nil {
+		t.Fatalf("place order: %v", err)
+	}
+	checkout, err := f.services.Payments.Initiate(context.Background(), f.user.ID, model.InitiatePayment{OrderID: order.OrderID, PaymentGateway: model.FakeGateway})
+	if err != nil {
+		t.Fatalf("initiate payment: %v", err)
+	}
+	if checkout.Status != payment.StatusPending {
+		t.Fatalf("checkout is %s, want %s", checkout.Status, payment.StatusPending)
+	}
+	return order, checkout
+}
+
+func (f paymentFixture) storedProduct(t *testing.T) model.Product {
+	t.Helper()
+	product, err := f.repos.Products.FindByID(f.product.ID)
+	if err != nil {
+		t.Fatalf("find product: %v", err)
+	}
+	return product
+}
+
+func (f paymentFixture) expectOrder(t *testing.T, orderID string, paymentStatus string, itemStatus string) {
+	t.Helper()
+	order, err := f.repos.Orders.FindByID(orderID)
+	if err != nil {
+		t.Fatalf("find order: %v", err)
+	}
+	if order.PaymentStatus != paymentStatus {
+		t.Errorf("order payment status is %s, want %s", order.PaymentStatus, paymentStatus)
+	}
+	items, err := f.repos.Orders.ListItems(orderID)
+	if err != nil {
+		t.Fatalf("list order items: %v", err)
+	}
+	if len(items) == 0 {
+		t.Fatalf("order %s has no items", orderID)
+	}
+	for _, item := range items {
+		if item.OrderStatus != itemStatus {
+			t.Errorf("item of product %d is %s, want %s", item.ProductID, item.OrderStatus, itemStatus)
+		}
+	}
+}
+
+func (f paymentFixture) expectStock(t *testing.T, stockLeft uint, reserved uint) {
+	t.Helper()
+	product := f.storedProduct(t)
+	if product.StockLeft != stockLeft || product.Reserved != reserved {
+		t.Errorf("product has %d in stock with %d reserved, want %d with %d reserved", product.StockLeft, product.Reserved, stockLeft, reserved)
+	}
+}
+
+func TestPaymentConfirmed(t *testing.T) {
+	f := newPaymentFixture(t, 5, 2)
+	order, checkout := f.checkout(t)
+	f.expectStock(t, 5, 2)
+
+	record, err := f.services.Payments.Confirm(context.Background(), f.user.ID, order.OrderID, model.FakeGateway, f.fake.Proof(checkout.Reference))
+	if err != nil {
+		t.Fatalf("confirm payment: %v", err)
+	}
+	if record.PaymentStatus != model.OnlinePaymentConfirmed {
+		t.Errorf("payment is %s, want %s", record.PaymentStatus, model.OnlinePaymentConfirmed)
+	}
+	f.expectOrder(t, order.OrderID, model.OnlinePaymentConfirmed, model.OrderStatusProcessing)
+	f.expectStock(t, 3, 0)
+
+	// the callback comes back again, the order is settled once
+	if _, err := f.services.Payments.Confirm(context.Background(), f.user.ID, order.OrderID, model.FakeGateway, f.fake.Proof(checkout.Reference)); err != nil {
+		t.Fatalf("confirm payment again: %v", err)
+	}
+	f.expectStock(t, 3, 0)
+}
+
+func TestPaymentDeclined(t *testing.T) {
+	f := newPaymentFixture(t, 5, 2)
+	order, checkout := f.checkout(t)
+	f.fake.Decline(checkout.Reference)
+
+	_, err := f.services.Payments.Confirm(context.Background(), f.user.ID, order.OrderID, model.FakeGateway, f.fake.Proof(checkout.Reference))
+	var serviceErr *Error
+	if !errors.As(err, &serviceErr) || serviceErr.Code != http.StatusPaymentRequired {
+		t.Fatalf("confirm payment returned %v, want a %d", err, http.StatusPaymentRequired)
+	}
+	record, err := f.repos.Payments.FindByReference(model.FakeGateway, checkout.Reference)
+	if err != nil {
+		t.Fatalf("find payment: %v", err)
+	}
+	if record.PaymentStatus != model.OnlinePaymentFailed {
+		t.Errorf("payment is %s, want %s", record.PaymentStatus, model.OnlinePaymentFailed)
+	}
+	f.expectOrder(t, order.OrderID, model.OnlinePaymentFailed, model.OrderStatusInitiated)
+
+	// the hold expires with the failed payment and the sweeper gives the stock back
+	f.services.Stock.releaseExpired(context.Background())
+	f.expectStock(t, 5, 0)
+}
+
+func TestPaymentCapturedAfterSoldOut(t *testing.T) {
+	f := newPaymentFixture(t, 2, 2)
+	order, checkout := f.checkout(t)
+
+	// the hold lapses while the customer is on the checkout and another order takes the stock
+	if err := f.repos.Reservations.ExpireOrder(order.OrderID, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("expire hold: %v", err)
+	}
+	f.services.Stock.releaseExpired(context.Background())
+	if err := f.repos.Products.TakeStock(f.product.ID, 2); err != nil {
+		t.Fatalf("take stock: %v", err)
+	}
+
+	_, err := f.services.Payments.Confirm(context.Background(), f.user.ID, order.OrderID, model.FakeGateway, f.fake.Proof(checkout.Reference))
+	var serviceErr *Error
+	if !errors.As(err, &serviceErr) || serviceErr.Code != http.StatusConflict {
+		t.Fatalf("confirm payment returned %v, want a %d", err, http.StatusConflict)
+	}
+	record, err := f.repos.Payments.FindByReference(model.FakeGateway, checkout.Reference)
+	if err != nil {
+		t.Fatalf("find payment: %v", err)
+	}
+	if record.PaymentStatus != model.OnlinePaymentConfirmed {
+		t.Errorf("payment is %s, want %s", record.PaymentStatus, model.OnlinePaymentConfirmed)
+	}
+	f.expectOrder(t, order.OrderID, model.OnlinePaymentExpired, model.OrderStatusCancelled)
+	f.expectStock(t, 0, 0)
+
+	due, err := f.repos.Reconciliations.ListUnassigned()
+	if err != nil {
+		t.Fatalf("list discrepancies: %v", err)
+	}
+	if len(due) != 1 {
+		t.Fatalf("%d discrepancies recorded, want 1", len(due))
+	}
+	if due[0].Action != model.ReconcileRefundDue || due[0].OrderID != order.OrderID || due[0].Amount != order.FinalAmount {
+		t.Errorf("recorded %s of %v for order %s, want %s of %v for order %s",
+			due[0].Action, due[0].Amount, due[0].OrderID, model.ReconcileRefundDue, order.FinalAmount, order.OrderID)
+	}
+}
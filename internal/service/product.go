@@ -0,0 +1,226 @@
+package service
+
+import (
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/money"
+	"foodbuddy/internal/repository"
+	"net/http"
+	"time"
+)
+
+type ProductService struct {
+	repos *repository.Repositories
+}
+
+func NewProductService(repos *repository.Repositories) *ProductService {
+	return &ProductService{repos: repos}
+}
+
+// List returns every product, their stock left is the stock that isn't held for orders waiting
+// for their payment
+func (s *ProductService) List() ([]model.Product, error) {
+	products, err := s.repos.Products.List()
+	if err != nil {
+		return nil, notFound("failed to retrieve data from the database, or the product doesn't exist")
+	}
+	for i := range products {
+		products[i].StockLeft = availableStock(products[i])
+	}
+	return products, nil
+}
+
+func (s *ProductService) ListByRestaurant(restaurantID uint) ([]model.Product, error) {
+	products, err := s.repos.Products.ListByRestaurant(restaurantID)
+	if err != nil {
+		return nil, internal("failed to retrieve products")
+	}
+	return products, nil
+}
+
+func (s *ProductService) Create(restaurantID uint, request model.AddProductRequest) (model.Product, error) {
+	if request.Veg != model.YES && request.Veg != model.NO {
+		return model.Product{}, badRequest("please specify if the product is vegetarian by 'YES' or 'NO' ")
+	}
+	if request.Price < request.OfferAmount {
+		return model.Product{}, badRequest("offer amount should not be more than the product price")
+	}
+	if _, err := s.repos.Restaurants.FindByID(restaurantID); err != nil {
+		return model.Product{}, notFound("restaurant not found")
+	}
+	if _, err := s.repos.Categories.FindByID(request.CategoryID); err != nil {
+		return model.Product{}, notFound("category doesn't exist")
+	}
+	if _, err := s.repos.Products.FindByName(restaurantID, request.Name); err == nil {
+		return model.Product{}, badRequest("product with the same name already exists in this restaurant")
+	}
+
+	product := model.Product{
+		RestaurantID: restaurantID,
+		CategoryID:   request.CategoryID,
+		Name:         request.Name,
+		Description:  request.Description,
+		ImageURL:     request.ImageURL,
+		Price:        request.Price,
+		MaxStock:     request.MaxStock,
+		StockLeft:    request.StockLeft,
+		Veg:          request.Veg,
+	}
+	if err := s.repos.Products.Create(&product); err != nil {
+		return product, internal("failed to create product")
+	}
+	return product, nil
+}
+
+func (s *ProductService) Update(restaurantID uint, request model.EditProductRequest) error {
+	product, err := s.owned(restaurantID, request.ProductID, "unauthorized request, product is not yours")
+	if err != nil {
+		return err
+	}
+	if request.Veg != model.YES && request.Veg != model.NO {
+		return badRequest("please specify if the product is vegetarian by 'YES' or 'NO' ")
+	}
+	if request.Price < request.OfferAmount {
+		return badRequest("offer amount should not be more than the product price")
+	}
+
+	product.Name = request.Name
+	product.Description = request.Description
+	product.ImageURL = request.ImageURL
+	product.Price = request.Price
+	product.MaxStock = request.MaxStock
+	product.Veg = request.Veg
+
+	// the stock submitted is the stock on sale, as the listings show it, the stock held for
+	// orders waiting for their payment stays held on top of it
+	return s.repos.Transaction(func(tx *repository.Repositories) error {
+		if err := tx.Products.Update(&product); err != nil {
+			return internal("failed to update product")
+		}
+		if err := tx.Products.SetStock(product.ID, request.StockLeft); err != nil {
+			return internal("failed to update product")
+		}
+		return nil
+	})
+}
+
+func (s *ProductService) Delete(restaurantID uint, productID uint) error {
+	product, err := s.repos.Products.FindByID(productID)
+	if err != nil {
+		return notFound("product is not present in the database")
+	}
+	if product.RestaurantID != restaurantID {
+		return newError(http.StatusUnauthorized, "unauthorized request")
+	}
+	if err := s.repos.Products.Delete(productID); err != nil {
+		return internal("unable to delete the product from the database")
+	}
+	return nil
+}
+
+// SetOffer changes the flat offer amount of a product owned by the restaurant,
+// zero removes the offer
+func (s *ProductService) SetOffer(restaurantID uint, productID uint, offerAmount money.Amount) (model.Product, error) {
+	product, err := s.owned(restaurantID, productID, "StatusUnauthorized")
+	if err != nil {
+		return product, err
+	}
+	product.OfferAmount = offerAmount
+	if err := s.repos.Products.SetOfferAmount(productID, offerAmount); err != nil {
+		if offerAmount == 0 {
+			return product, internal("failed to remove the offer amount")
+		}
+		return product, internal("failed to add the offer amount")
+	}
+	return product, nil
+}
+
+func (s *ProductService) Offers() ([]model.Product, error) {
+	products, err := s.repos.Products.ListWithOffers()
+	if err != nil {
+		return nil, notFound("failed to get product details")
+	}
+	return products, nil
+}
+
+func (s *ProductService) Veg() ([]model.ProductResponse, error) {
+	products, err := s.repos.Products.ListVeg()
+	if err != nil {
+		return nil, notFound("failed to get product information")
+	}
+	return s.responses(products)
+}
+
+func (s *ProductService) ByPrice(descending bool) ([]model.ProductResponse, error) {
+	products, err := s.repos.Products.ListByPrice(descending)
+	if err != nil {
+		return nil, notFound("failed to get product information")
+	}
+	return s.responses(products)
+}
+
+func (s *ProductService) NewArrivals(since time.Time) ([]model.ProductResponse, error) {
+	products, err := s.repos.Products.ListCreatedSince(since)
+	if err != nil {
+		return nil, notFound("failed to get product information")
+	}
+	return s.responses(products)
+}
+
+// Reviews lists the ratings and reviews users left on a product's order items
+func (s *ProductService) Reviews(productID uint) ([]map[string]interface{}, error) {
+	items, err := s.repos.Orders.ListItemsByProduct(productID)
+	if err != nil {
+		return nil, internal("failed to fetch reviews and ratings")
+	}
+
+	var response []map[string]interface{}
+	for _, item := range items {
+		if item.OrderReview != "" || item.OrderRating != 0 {
+			response = append(response, map[string]interface{}{
+				"userid": item.UserID,
+				"rating": item.OrderRating,
+				"review": item.OrderReview,
+			})
+		}
+	}
+	return response, nil
+}
+
+// owned returns the product when it belongs to the restaurant
+func (s *ProductService) owned(restaurantID uint, productID uint, unauthorized string) (model.Product, error) {
+	product, err := s.repos.Products.FindByID(productID)
+	if err != nil {
+		return product, notFound("failed to find the product")
+	}
+	if product.RestaurantID != restaurantID {
+		return product, newError(http.StatusUnauthorized, unauthorized)
+	}
+	return product, nil
+}
+
+func (s *ProductService) responses(products []model.Product) ([]model.ProductResponse, error) {
+	var response []model.ProductResponse
+	for _, product := range products {
+		category, err := s.repos.Categories.FindByID(product.CategoryID)
+		if err != nil {
+			return nil, notFound("failed to get category information")
+		}
+		restaurant, err := s.repos.Restaurants.FindByID(product.RestaurantID)
+		if err != nil {
+			return nil, notFound("failed to get restaurant information")
+		}
+		response = append(response, model.ProductResponse{
+			ID:             product.ID,
+			RestaurantName: restaurant.Name,
+			CategoryName:   category.Name,
+			Name:           product.Name,
+			Description:    product.Description,
+			ImageURL:       product.ImageURL,
+			Price:          product.Price,
+			StockLeft:      availableStock(product),
+			AverageRating:  product.AverageRating,
+			Veg:            product.Veg,
+		})
+	}
+	return response, nil
+}
@@ -0,0 +1,339 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/payment"
+	"foodbuddy/internal/pubsub"
+	"foodbuddy/internal/repository"
+	"foodbuddy/internal/utils"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ReconciliationService brings the payments in line with the gateways. it asks the gateway of
+// every open payment where the payment stands, settles or fails the ones that moved without us
+// hearing about it, expires the online orders left unpaid past PendingTTL and records what it
+// found for finance. it runs every Interval and when an admin asks for it
+type ReconciliationService struct {
+	repos    *repository.Repositories
+	gateways *payment.Registry
+	events   *pubsub.Hub
+	running  sync.Mutex
+
+	// PendingTTL is how long an online order waits for its payment before it is expired
+	PendingTTL time.Duration
+	// Interval is the time between two scheduled runs
+	Interval time.Duration
+}
+
+// unsentRefundAge is how long a refund can wait for the gateway's answer before it is reported
+const unsentRefundAge = 5 * time.Minute
+
+// lateCaptureWindow is how long the failed checkouts of an expired order are still asked
+// about, a gateway that captures one after that is left to its webhook
+const lateCaptureWindow = 24 * time.Hour
+
+// ReconciliationReport is a run with the discrepancies it found
+type ReconciliationReport struct {
+	Run           model.ReconciliationRun    `json:"run"`
+	Discrepancies []model.PaymentDiscrepancy `json:"discrepancies"`
+}
+
+// NewReconciliationService reads PAYMENT_PENDING_TTL and RECONCILE_INTERVAL, durations like
+// 30m, falling back to model.PaymentPendingTTL and model.ReconcileInterval. the orders it
+// confirms or expires are published on events
+func NewReconciliationService(repos *repository.Repositories, gateways *payment.Registry, events *pubsub.Hub) *ReconciliationService {
+	env := utils.GetEnvVariables()
+	return &ReconciliationService{
+		repos:      repos,
+		gateways:   gateways,
+		events:     events,
+		PendingTTL: durationOr("PAYMENT_PENDING_TTL", env.PaymentPendingTTL, model.PaymentPendingTTL*time.Second),
+		Interval:   durationOr("RECONCILE_INTERVAL", env.ReconcileInterval, model.ReconcileInterval*time.Second),
+	}
+}
+
+func durationOr(key string, value string, fallback time.Duration) time.Duration {
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Warning: %s=%q is not a positive duration, using %v", key, value, fallback)
+		return fallback
+	}
+	return d
+}
+
+// Schedule runs the reconciliation every Interval until ctx is cancelled
+func (s *ReconciliationService) Schedule(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		report, err := s.Run(ctx, model.ReconcileTriggerScheduled)
+		if err != nil {
+			log.Printf("reconciliation: %v", err)
+			continue
+		}
+		if report.Run.Discrepancies > 0 {
+			log.Printf("reconciliation: run %d found %d discrepancies", report.Run.ID, report.Run.Discrepancies)
+		}
+	}
+}
+
+// Run reconciles the open payments and expires the unpaid orders now. one run goes at a time,
+// the changes it makes are conditional so runs of other instances can't apply them twice
+func (s *ReconciliationService) Run(ctx context.Context, trigger string) (ReconciliationReport, error) {
+	if !s.running.TryLock() {
+		return ReconciliationReport{}, newError(http.StatusConflict, "a reconciliation is already running")
+	}
+	defer s.running.Unlock()
+
+	report := ReconciliationReport{
+		Run:           model.ReconciliationRun{Trigger: trigger, StartedAt: time.Now()},
+		Discrepancies: []model.PaymentDiscrepancy{},
+	}
+	if err := s.repos.Reconciliations.CreateRun(&report.Run); err != nil {
+		return report, internal("failed to start the reconciliation")
+	}
+	cutoff := report.Run.StartedAt.Add(-s.PendingTTL)
+
+	payments, err := s.repos.Payments.ListOpen(cutoff.Add(-lateCaptureWindow))
+	if err != nil {
+		return report, internal("failed to fetch the open payments")
+	}
+	for _, record := range payments {
+		if ctx.Err() != nil {
+			break
+		}
+		report.Run.Checked++
+		s.record(&report, s.reconcilePayment(ctx, record, cutoff))
+	}
+
+	// a refund the gateway never acknowledged may or may not have been made, resending it
+	// could refund twice so it is left to finance
+	refunds, err := s.repos.Refunds.ListUnsent(report.Run.StartedAt.Add(-unsentRefundAge))
+	if err != nil {
+		return report, internal("failed to fetch the unsent refunds")
+	}
+	for _, refund := range refunds {
+		s.record(&report, &model.PaymentDiscrepancy{
+			OrderID:   refund.OrderID,
+			Gateway:   refund.PaymentGateway,
+			Reference: refund.GatewayReference,
+			Amount:    refund.Amount,
+			OurStatus: refund.Status,
+			Action:    model.ReconcileReview,
+			Note:      fmt.Sprintf("refund %d of item %d never got an answer from the gateway", refund.ID, refund.ProductID),
+		})
+	}
+
+	orders, err := s.repos.Orders.ListUnpaid(cutoff)
+	if err != nil {
+		return report, internal("failed to fetch the unpaid orders")
+	}
+	for _, order := range orders {
+		if ctx.Err() != nil {
+			break
+		}
+		s.record(&report, s.expireOrder(order))
+	}
+
+	// payments captured for orders that couldn't take them, recorded by the callbacks and the
+	// webhooks and by this run, go into the report of the run that finds them first
+	unassigned, err := s.repos.Reconciliations.ListUnassigned()
+	if err != nil {
+		return report, internal("failed to fetch the refunds due")
+	}
+	for _, discrepancy := range unassigned {
+		assigned, err := s.repos.Reconciliations.Assign(discrepancy.ID, report.Run.ID)
+		if err != nil {
+			log.Printf("reconciliation: failed to add discrepancy %d to run %d: %v", discrepancy.ID, report.Run.ID, err)
+			continue
+		}
+		if !assigned {
+			continue
+		}
+		discrepancy.RunID = report.Run.ID
+		report.Run.Discrepancies++
+		report.Discrepancies = append(report.Discrepancies, discrepancy)
+	}
+
+	finished := time.Now()
+	report.Run.FinishedAt = &finished
+	if err := s.repos.Reconciliations.FinishRun(&report.Run); err != nil {
+		return report, internal("failed to save the reconciliation")
+	}
+	return report, nil
+}
+
+// record adds the discrepancy to the report, nil means the payment was in order
+func (s *ReconciliationService) record(report *ReconciliationReport, discrepancy *model.PaymentDiscrepancy) {
+	if discrepancy == nil {
+		return
+	}
+	discrepancy.RunID = report.Run.ID
+	switch discrepancy.Action {
+	case model.ReconcileSettled, model.ReconcileFailed:
+		report.Run.Fixed++
+	case model.ReconcileExpired:
+		report.Run.Expired++
+	}
+	report.Run.Discrepancies++
+	if err := s.repos.Reconciliations.AddDiscrepancy(discrepancy); err != nil {
+		log.Printf("reconciliation: failed to record the discrepancy of order %s: %v", discrepancy.OrderID, err)
+	}
+	report.Discrepancies = append(report.Discrepancies, *discrepancy)
+}
+
+// reconcilePayment compares an open payment with its gateway and fixes what it can
+func (s *ReconciliationService) reconcilePayment(ctx context.Context, record model.Payment, cutoff time.Time) *model.PaymentDiscrepancy {
+	discrepancy := &model.PaymentDiscrepancy{
+		OrderID:   record.OrderID,
+		Gateway:   record.PaymentGateway,
+		Reference: record.GatewayReference,
+		PaymentID: record.GatewayPaymentID,
+		Amount:    record.Amount,
+		OurStatus: record.PaymentStatus,
+	}
+	review := func(note string) *model.PaymentDiscrepancy {
+		discrepancy.Action, discrepancy.Note = model.ReconcileReview, note
+		return discrepancy
+	}
+
+	gateway, err := s.gateways.Get(record.PaymentGateway)
+	if err != nil {
+		return review("the gateway is not configured")
+	}
+	if record.GatewayReference == "" {
+		return review("the payment has no gateway reference")
+	}
+	order, err := s.repos.Orders.FindByID(record.OrderID)
+	if err != nil {
+		return review("the order of the payment doesn't exist")
+	}
+	result, err := gateway.FetchStatus(ctx, record.GatewayReference)
+	if err != nil {
+		log.Printf("reconciliation: failed to fetch %s payment %s: %v", gateway.Name(), record.GatewayReference, err)
+		return review("the gateway could not be reached")
+	}
+	discrepancy.GatewayStatus = result.Status
+	if result.PaymentID != "" {
+		discrepancy.PaymentID = result.PaymentID
+	}
+
+	switch result.Status {
+	case payment.StatusPaid:
+		if result.Amount != 0 && result.Amount != record.Amount {
+			return review(fmt.Sprintf("the gateway was paid %v, the payment is for %v", result.Amount, record.Amount))
+		}
+		applied := true
+		err = s.repos.Transaction(func(tx *repository.Repositories) error {
+			applied, err = settlePayment(tx, s.events, record, result.PaymentID, "")
+			return err
+		})
+		if err == nil && !applied {
+			// settlePayment recorded the refund due, the run adds it to its report when it ends
+			return nil
+		}
+		discrepancy.Action = model.ReconcileSettled
+	case payment.StatusFailed:
+		if record.PaymentStatus == model.OnlinePaymentFailed {
+			return nil
+		}
+		discrepancy.Action = model.ReconcileFailed
+		err = failPayment(s.repos, record)
+	case payment.StatusRefunded:
+		return review("the gateway refunded a payment that was never confirmed")
+	default:
+		// still pending at the gateway, the checkout is given up once the order is too old
+		if record.PaymentStatus == model.OnlinePaymentFailed || !order.OrderedAt.Before(cutoff) {
+			return nil
+		}
+		discrepancy.Action, discrepancy.Note = model.ReconcileFailed, "the checkout was abandoned"
+		err = failPayment(s.repos, record)
+	}
+	if err != nil {
+		return review("failed to apply the gateway state: " + err.Error())
+	}
+	return discrepancy
+}
+
+// expireOrder closes an online order left unpaid past the ttl, its items are cancelled
+func (s *ReconciliationService) expireOrder(order model.Order) *model.PaymentDiscrepancy {
+	discrepancy := &model.PaymentDiscrepancy{
+		OrderID:   order.OrderID,
+		Amount:    order.FinalAmount,
+		OurStatus: order.PaymentStatus,
+		Action:    model.ReconcileExpired,
+		Note:      "unpaid since " + order.OrderedAt.Format(time.RFC3339),
+	}
+	err := s.repos.Transaction(func(tx *repository.Repositories) error {
+		expired, err := tx.Orders.Expire(order.OrderID)
+		if err != nil {
+			return err
+		}
+		if !expired {
+			discrepancy = nil
+			return nil
+		}
+		// checkouts still open are failed, a late capture is recorded as a refund due by
+		// settlePayment when the webhook or the reconciliation finds it
+		payments, err := tx.Payments.ListByOrderAndStatus(order.OrderID, model.OnlinePaymentPending)
+		if err != nil {
+			return err
+		}
+		for _, record := range payments {
+			if err := tx.Payments.Fail(record.PaymentGateway, record.GatewayReference); err != nil {
+				return err
+			}
+			discrepancy.Gateway, discrepancy.Reference = record.PaymentGateway, record.GatewayReference
+		}
+		return releaseItems(tx, s.events, order.OrderID)
+	})
+	if err != nil {
+		log.Printf("reconciliation: failed to expire order %s: %v", order.OrderID, err)
+		return &model.PaymentDiscrepancy{
+			OrderID:   order.OrderID,
+			Amount:    order.FinalAmount,
+			OurStatus: order.PaymentStatus,
+			Action:    model.ReconcileReview,
+			Note:      "failed to expire the unpaid order",
+		}
+	}
+	return discrepancy
+}
+
+// Runs returns the latest reconciliation runs
+func (s *ReconciliationService) Runs(limit int) ([]model.ReconciliationRun, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	runs, err := s.repos.Reconciliations.ListRuns(limit)
+	if err != nil {
+		return nil, internal("failed to fetch the reconciliation runs")
+	}
+	return runs, nil
+}
+
+// Report returns a run with its discrepancies
+func (s *ReconciliationService) Report(id uint) (ReconciliationReport, error) {
+	run, err := s.repos.Reconciliations.FindRun(id)
+	if err != nil {
+		return ReconciliationReport{}, notFound("reconciliation run not found")
+	}
+	discrepancies, err := s.repos.Reconciliations.ListDiscrepancies(id)
+	if err != nil {
+		return ReconciliationReport{}, internal("failed to fetch the discrepancies")
+	}
+	return ReconciliationReport{Run: run, Discrepancies: discrepancies}, nil
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/money"
+	"foodbuddy/internal/repository"
+	"foodbuddy/internal/utils"
+	"net/http"
+)
+
+type ReferralService struct {
+	repos *repository.Repositories
+}
+
+func NewReferralService(repos *repository.Repositories) *ReferralService {
+	return &ReferralService{repos: repos}
+}
+
+type ReferralStats struct {
+	TotalReferrals      int
+	IneligibleReferrals int
+	EligibleReferrals   int64
+	ClaimsDone          int64
+	TotalClaimAmount    money.Amount
+	History             []model.UserReferralHistory
+}
+
+// Code returns the user's referral code, generating it on first use
+func (s *ReferralService) Code(userID uint) (string, error) {
+	user, err := s.repos.Users.FindByID(userID)
+	if err != nil {
+		return "", notFound("failed to get user information")
+	}
+	return ensureReferral(s.repos, user)
+}
+
+// Activate records that the user was referred by the owner of code
+func (s *ReferralService) Activate(userID uint, code string) error {
+	user, err := s.repos.Users.FindByID(userID)
+	if err != nil {
+		return notFound("failed to get user information")
+	}
+
+	referrer, err := s.repos.Referrals.FindByCode(code)
+	if err != nil || code == "" {
+		return notFound("the referral code doesnt exist")
+	}
+
+	history, err := s.repos.Referrals.FindByUser(user.ID)
+	if err != nil {
+		return notFound("failed to get user referral information")
+	}
+	if history.ReferredBy != "" {
+		return notFound("user already been referred")
+	}
+	if referrer.ReferredBy != "" && referrer.ReferredBy == history.ReferralCode {
+		return notFound("user's cannot refer each other")
+	}
+	if code == history.ReferralCode {
+		return newError(http.StatusMethodNotAllowed, "usage of same referral code restricted")
+	}
+
+	if err := s.repos.Referrals.SetReferredBy(history.ReferralCode, code); err != nil {
+		return internal("failed to update user referral information")
+	}
+	return nil
+}
+
+// Claim credits the referral reward for every referred user with a delivered order
+func (s *ReferralService) Claim(userID uint) (eligible int64, amount money.Amount, err error) {
+	user, err := s.repos.Users.FindByID(userID)
+	if err != nil {
+		return 0, 0, notFound("failed to get user information")
+	}
+
+	referrals, err := s.repos.Referrals.ListUnclaimedReferredBy(user.ReferralCode)
+	if err != nil {
+		return 0, 0, notFound("failed to get user refer history")
+	}
+	eligible = s.eligible(referrals)
+
+	if eligible < model.ReferralClaimLimit {
+		return eligible, 0, newError(http.StatusMethodNotAllowed, fmt.Sprintf("need a minimum of %v referrals with at least one order delivered to claim reward", model.ReferralClaimLimit))
+	}
+
+	amount = model.ReferralClaimAmount.Times(uint(eligible))
+	err = s.repos.Transaction(func(tx *repository.Repositories) error {
+		if err := tx.Referrals.MarkClaimed(user.ReferralCode); err != nil {
+			return internal("failed to update user refer history")
+		}
+		if err := postEntry(tx, model.WalletTxTypeReferralReward, "",
+			debit(model.LedgerReferralRewards, 0, amount),
+			credit(model.LedgerUserWallet, user.ID, amount),
+		); err != nil {
+			return internal("failed to update user")
+		}
+		return nil
+	})
+	return eligible, amount, err
+}
+
+func (s *ReferralService) Stats(userID uint) (ReferralStats, error) {
+	var stats ReferralStats
+	user, err := s.repos.Users.FindByID(userID)
+	if err != nil {
+		return stats, notFound("failed to get user information")
+	}
+
+	stats.History, err = s.repos.Referrals.ListReferredBy(user.ReferralCode)
+	if err != nil {
+		return stats, notFound("failed to get user refer history")
+	}
+	stats.TotalReferrals = len(stats.History)
+
+	var unclaimed []model.UserReferralHistory
+	for _, v := range stats.History {
+		if !v.ReferClaimed {
+			unclaimed = append(unclaimed, v)
+		}
+	}
+	stats.EligibleReferrals = s.eligible(unclaimed)
+
+	stats.ClaimsDone, err = s.repos.Referrals.CountClaimed(user.ReferralCode)
+	if err != nil {
+		return stats, internal("failed to retrieve total claims")
+	}
+
+	rewards, err := s.repos.Wallets.ListUserHistoryByReason(user.ID, model.WalletTxTypeReferralReward)
+	if err != nil {
+		return stats, internal("failed to retrieve total claimed amount")
+	}
+	for _, history := range rewards {
+		stats.TotalClaimAmount += history.Amount
+	}
+
+	stats.IneligibleReferrals = stats.TotalReferrals - int(stats.ClaimsDone) - int(stats.EligibleReferrals)
+	if stats.IneligibleReferrals < 0 {
+		stats.IneligibleReferrals = 0
+	}
+	return stats, nil
+}
+
+// eligible counts the referred users with at least one delivered order item
+func (s *ReferralService) eligible(referrals []model.UserReferralHistory) int64 {
+	var count int64
+	for _, v := range referrals {
+		delivered, err := s.repos.Orders.CountItemsByUserAndStatus(v.UserID, model.OrderStatusDelivered)
+		if err != nil {
+			continue
+		}
+		if delivered > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// ensureReferral gives the user a referral code and a referral history row if missing
+func ensureReferral(repos *repository.Repositories, user model.User) (string, error) {
+	if user.ReferralCode == "" {
+		user.ReferralCode = utils.GenerateRandomString(5)
+		if err := repos.Users.SetReferralCode(user.ID, user.ReferralCode); err != nil {
+			return "", notFound("failed to generate referral code")
+		}
+	}
+
+	_, err := repos.Referrals.FindByUser(user.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		err = repos.Referrals.Create(&model.UserReferralHistory{
+			UserID:       user.ID,
+			ReferralCode: user.ReferralCode,
+		})
+	}
+	if err != nil {
+		return "", notFound("failed to save referral history")
+	}
+	return user.ReferralCode, nil
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/money"
+	"foodbuddy/internal/payment"
+	"foodbuddy/internal/repository"
+	"log"
+	"time"
+)
+
+// refundToWallet credits the user's wallet with the cancelled items, each item gets a refund
+// that is processed right away
+func refundToWallet(tx *repository.Repositories, userID uint, items []model.OrderItem) ([]model.Refund, error) {
+	if err := refundToUserWallet(tx, userID, items); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	refunds := make([]model.Refund, len(items))
+	for i, item := range items {
+		refunds[i] = model.Refund{
+			OrderID:     item.OrderID,
+			ProductID:   item.ProductID,
+			UserID:      userID,
+			Amount:      item.AfterDeduction,
+			Destination: model.RefundToWallet,
+			Status:      model.RefundStatusProcessed,
+			ProcessedAt: &now,
+		}
+		if err := tx.Refunds.Create(&refunds[i]); err != nil {
+			return nil, err
+		}
+	}
+	return refunds, nil
+}
+
+// refundToSource books the refund of the cancelled items through the gateway that took the
+// payment: the restaurants give the money back to the gateway and the payment's refunded total
+// grows, all before the gateway is asked, so the refund webhook that follows finds nothing new
+// to book. the refunds stay pending until sendRefund hears back from the gateway
+func refundToSource(tx *repository.Repositories, record model.Payment, userID uint, items []model.OrderItem) ([]model.Refund, error) {
+	var total money.Amount
+	postings := []posting{}
+	for _, item := range items {
+		total += item.AfterDeduction
+		postings = append(postings, debit(model.LedgerRestaurantWallet, item.RestaurantID, item.AfterDeduction))
+	}
+	refunded := record.RefundedAmount + total
+	if refunded > record.Amount {
+		return nil, fmt.Errorf("refund of %v exceeds the %v left of the payment", total, record.Amount-record.RefundedAmount)
+	}
+	status := record.PaymentStatus
+	if refunded == record.Amount {
+		status = model.OnlinePaymentRefunded
+	}
+	changed, err := tx.Payments.SetRefunded(record.PaymentGateway, record.GatewayReference, record.RefundedAmount, refunded, status)
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return nil, errors.New("the payment changed while booking the refund")
+	}
+	if status == model.OnlinePaymentRefunded {
+		if err := tx.Orders.SetPaymentStatus(record.OrderID, model.OnlinePaymentRefunded); err != nil {
+			return nil, err
+		}
+	}
+	postings = append(postings, credit(model.LedgerPaymentGateway, 0, total))
+	if err := postEntry(tx, model.WalletTxTypeGatewayRefund, record.OrderID, postings...); err != nil {
+		return nil, err
+	}
+
+	refunds := make([]model.Refund, len(items))
+	for i, item := range items {
+		refunds[i] = model.Refund{
+			OrderID:          item.OrderID,
+			ProductID:        item.ProductID,
+			UserID:           userID,
+			Amount:           item.AfterDeduction,
+			Destination:      model.RefundToSource,
+			PaymentGateway:   record.PaymentGateway,
+			GatewayReference: record.GatewayReference,
+			Status:           model.RefundStatusPending,
+		}
+		if err := tx.Refunds.Create(&refunds[i]); err != nil {
+			return nil, err
+		}
+	}
+	return refunds, nil
+}
+
+// sendRefund asks the gateway for a pending refund and records its answer. the gateway is
+// called outside any transaction, a refund it turns down is credited to the wallet instead
+func sendRefund(ctx context.Context, repos *repository.Repositories, gateways *payment.Registry, refund model.Refund) model.Refund {
+	gateway, err := gateways.Get(refund.PaymentGateway)
+	if err != nil {
+		return settleRefund(repos, refund, payment.RefundFailed, "", "the gateway is not configured")
+	}
+	record, err := repos.Payments.FindByReference(refund.PaymentGateway, refund.GatewayReference)
+	if err != nil {
+		return settleRefund(repos, refund, payment.RefundFailed, "", "the payment was not found")
+	}
+
+	result, err := gateway.Refund(ctx, payment.RefundRequest{
+		Reference: record.GatewayReference,
+		PaymentID: record.GatewayPaymentID,
+		Amount:    refund.Amount,
+		Reason:    fmt.Sprintf("item %d of order %s cancelled", refund.ProductID, refund.OrderID),
+	})
+	if err != nil {
+		log.Printf("refund: %s refused refund %d: %v", gateway.Name(), refund.ID, err)
+		return settleRefund(repos, refund, payment.RefundFailed, "", gateway.Name()+" could not make the refund")
+	}
+	return settleRefund(repos, refund, result.Status, result.ID, "")
+}
+
+// settleRefund records the gateway's answer to a refund and returns the refund as stored
+func settleRefund(repos *repository.Repositories, refund model.Refund, status string, refundID string, reason string) model.Refund {
+	err := repos.Transaction(func(tx *repository.Repositories) error {
+		switch status {
+		case payment.RefundProcessed:
+			_, err := tx.Refunds.Finish(refund.ID, model.RefundStatusProcessed, refundID, "")
+			return err
+		case payment.RefundFailed:
+			if refundID != "" {
+				if err := tx.Refunds.SetGatewayRefundID(refund.ID, refundID); err != nil {
+					return err
+				}
+			}
+			return failRefund(tx, refund, reason)
+		}
+		return tx.Refunds.SetGatewayRefundID(refund.ID, refundID)
+	})
+	if err != nil {
+		log.Printf("refund: failed to record the %s answer to refund %d: %v", refund.PaymentGateway, refund.ID, err)
+	}
+	if stored, err := repos.Refunds.FindByID(refund.ID); err == nil {
+		return stored
+	}
+	return refund
+}
+
+// failRefund marks a pending gateway refund failed and credits its amount to the user's wallet,
+// the amount no longer counts as refunded on the payment
+func failRefund(tx *repository.Repositories, refund model.Refund, reason string) error {
+	if reason == "" {
+		reason = "the gateway failed the refund"
+	}
+	failed, err := tx.Refunds.Finish(refund.ID, model.RefundStatusFailed, "", reason+", refunded to the wallet instead")
+	if err != nil || !failed {
+		return err
+	}
+
+	record, err := tx.Payments.FindByReference(refund.PaymentGateway, refund.GatewayReference)
+	if err != nil {
+		return err
+	}
+	refunded := record.RefundedAmount - refund.Amount
+	if refunded < 0 {
+		refunded = 0
+	}
+	status := record.PaymentStatus
+	if status == model.OnlinePaymentRefunded {
+		status = model.OnlinePaymentConfirmed
+		if err := tx.Orders.SetPaymentStatus(record.OrderID, model.OnlinePaymentConfirmed); err != nil {
+			return err
+		}
+	}
+	changed, err := tx.Payments.SetRefunded(record.PaymentGateway, record.GatewayReference, record.RefundedAmount, refunded, status)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return errors.New("the payment changed while failing the refund")
+	}
+	return postEntry(tx, model.WalletTxTypeOrderRefund, refund.OrderID,
+		debit(model.LedgerPaymentGateway, 0, refund.Amount),
+		credit(model.LedgerUserWallet, refund.UserID, refund.Amount))
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/repository"
+	"foodbuddy/internal/utils"
+	"time"
+
+	"github.com/jung-kurt/gofpdf/v2"
+)
+
+type ReportService struct {
+	repos *repository.Repositories
+}
+
+func NewReportService(repos *repository.Repositories) *ReportService {
+	return &ReportService{repos: repos}
+}
+
+func (s *ReportService) ProductReport(productID uint) model.ProductSales {
+	report, err := s.repos.Reports.ProductSales(productID)
+	if err != nil {
+		return report
+	}
+	report.AvgRating = utils.RoundDecimalValue(report.AvgRating)
+	return report
+}
+
+// BestSelling returns the top products by order count, a zero or out of range
+// limit returns every product that was ordered at least once
+func (s *ReportService) BestSelling(limit int) ([]model.BestProduct, error) {
+	products, err := s.repos.Reports.BestSellingProducts()
+	if err != nil {
+		return nil, internal("failed to get best selling product")
+	}
+	if limit > len(products) || limit <= 0 {
+		limit = len(products)
+	}
+
+	for i, best := range products {
+		product, err := s.repos.Products.FindByID(best.ProductID)
+		if err != nil {
+			return nil, notFound("failed to find product information")
+		}
+		category, err := s.repos.Categories.FindByID(product.CategoryID)
+		if err != nil {
+			return nil, notFound("failed to find category information")
+		}
+		products[i].Name = product.Name
+		products[i].CategoryName = category.Name
+		products[i].Description = product.Description
+		products[i].ImageURL = product.ImageURL
+		products[i].Price = product.Price
+		products[i].Rating = product.AverageRating
+	}
+	return products[:limit], nil
+}
+
+// SalesReport resolves the requested period, either an explicit start and end date or
+// a limit of day, week, month or year, and totals the orders placed in it.
+// a zero restaurantID reports on the whole platform
+func (s *ReportService) SalesReport(startDate, endDate, limit, paymentStatus string, restaurantID uint) (model.OrderCount, model.AmountInformation, error) {
+	if startDate == "" && endDate == "" && limit == "" {
+		return model.OrderCount{}, model.AmountInformation{}, badRequest("please provide start date and end date, or specify the limit as day, week, month, year")
+	}
+
+	if limit != "" {
+		today := time.Now()
+		switch limit {
+		case "day":
+			startDate = today.AddDate(0, 0, -1).Format("2006-01-02")
+			endDate = today.Format("2006-01-02")
+		case "week":
+			startDate = today.AddDate(0, 0, -int(today.Weekday())).Format("2006-01-02") // Start of the week (Sunday)
+			endDate = today.AddDate(0, 0, 7-int(today.Weekday())).Format("2006-01-02")  // End of the week (Saturday)
+		case "month":
+			firstDayOfMonth := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+			startDate = firstDayOfMonth.Format("2006-01-02")
+			endDate = firstDayOfMonth.AddDate(0, 1, -1).Format("2006-01-02")
+		case "year":
+			startDate = today.AddDate(-1, 0, 0).Format("2006-01-02")
+			endDate = today.Format("2006-01-02")
+		default:
+			return model.OrderCount{}, model.AmountInformation{}, badRequest("invalid limit specified, valid options are: day, week, month, year")
+		}
+	}
+
+	count, amount, err := s.TotalOrders(startDate, endDate, paymentStatus, restaurantID)
+	if err != nil {
+		return count, amount, internal("error processing orders")
+	}
+	return count, amount, nil
+}
+
+// TotalOrders counts the order items by status and sums the order amounts
+// for orders placed between from and till (inclusive, formatted as 2006-01-02)
+func (s *ReportService) TotalOrders(from string, till string, paymentStatus string, restaurantID uint) (model.OrderCount, model.AmountInformation, error) {
+	parsedFrom, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return model.OrderCount{}, model.AmountInformation{}, fmt.Errorf("error parsing From time: %v", err)
+	}
+	parsedTill, err := time.Parse("2006-01-02", till)
+	if err != nil {
+		return model.OrderCount{}, model.AmountInformation{}, fmt.Errorf("error parsing Till time: %v", err)
+	}
+
+	startTime := time.Date(parsedFrom.Year(), parsedFrom.Month(), parsedFrom.Day(), 0, 0, 0, 0, time.UTC).Format("2006-01-02T15:04:05Z")
+	endTime := time.Date(parsedTill.Year(), parsedTill.Month(), parsedTill.Day(), 23, 59, 59, 999999999, time.UTC).Format("2006-01-02T15:04:05Z")
+
+	orders, err := s.repos.Reports.OrdersBetween(startTime, endTime, paymentStatus, restaurantID)
+	if err != nil {
+		return model.OrderCount{}, model.AmountInformation{}, fmt.Errorf("error fetching orders")
+	}
+
+	statusCounts := map[string]uint{}
+	var amount model.AmountInformation
+	for _, order := range orders {
+		amount.TotalCouponDeduction += order.CouponDiscountAmount
+		amount.TotalProductOfferDeduction += order.ProductOfferAmount
+		amount.TotalAmountBeforeDeduction += order.TotalAmount
+		amount.TotalAmountAfterDeduction += order.FinalAmount
+
+		items, err := s.repos.Orders.ListItems(order.OrderID)
+		if err != nil {
+			return model.OrderCount{}, model.AmountInformation{}, fmt.Errorf("error fetching order items")
+		}
+		for _, item := range items {
+			statusCounts[item.OrderStatus]++
+		}
+	}
+
+	count := model.OrderCount{
+		TotalProcessing:    statusCounts[model.OrderStatusProcessing],
+		TotalAccepted:      statusCounts[model.OrderStatusAccepted],
+		TotalInitiated:     statusCounts[model.OrderStatusInitiated],
+		TotalInPreparation: statusCounts[model.OrderStatusInPreparation],
+		TotalPrepared:      statusCounts[model.OrderStatusPrepared],
+		TotalOnTheWay:      statusCounts[model.OrderStatusOntheway],
+		TotalDelivered:     statusCounts[model.OrderStatusDelivered],
+		TotalCancelled:     statusCounts[model.OrderStatusCancelled],
+	}
+	count.TotalOrder = count.TotalProcessing + count.TotalAccepted + count.TotalInitiated + count.TotalInPreparation +
+		count.TotalPrepared + count.TotalOnTheWay + count.TotalDelivered + count.TotalCancelled
+	return count, amount, nil
+}
+
+// Invoice renders the pdf invoice of an order
+func (s *ReportService) Invoice(userID uint, orderID string) ([]byte, error) {
+	order, err := findOwnedOrder(s.repos, userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.repos.Orders.ListItems(orderID)
+	if err != nil {
+		return nil, internal("failed to fetch order information")
+	}
+	user, err := s.repos.Users.FindByID(order.UserID)
+	if err != nil {
+		return nil, notFound("failed to get user information")
+	}
+	address, err := s.repos.Users.FindAddressByID(order.AddressID)
+	if err != nil {
+		return nil, notFound("failed to get address")
+	}
+
+	pdf, err := GeneratePDFInvoice(order, items, address, user, s.invoiceNames)
+	if err != nil {
+		return nil, internal("failed to generate PDF")
+	}
+	return pdf, nil
+}
+
+// invoiceNames looks up the product and restaurant names printed on an invoice row
+func (s *ReportService) invoiceNames(item model.OrderItem) (productName string, restaurantName string) {
+	product, _ := s.repos.Products.FindByID(item.ProductID)
+	restaurant, _ := s.repos.Restaurants.FindByID(item.RestaurantID)
+	return product.Name, restaurant.Name
+}
+
+// GeneratePDFInvoice renders the invoice, names resolves the product and restaurant
+// names of each order item
+func GeneratePDFInvoice(order model.Order, orderItems []model.OrderItem, address model.Address, user model.User, names func(model.OrderItem) (string, string)) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "Tabloid", "")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 12)
+
+	pdf.Image("./assets/foodbuddy.png", 10, 10, 50, 0, false, "", 0, "")
+	pdf.Ln(20)
+	pdf.Cell(40, 10, "Order Invoice")
+	pdf.Ln(20)
+
+	// Order Information
+	pdf.SetFont("Arial", "", 12)
+	pdf.Cell(40, 10, fmt.Sprintf("Order ID: %s", order.OrderID))
+	pdf.Ln(10)
+	pdf.Cell(40, 10, fmt.Sprintf("Name  : %v", user.Name))
+	pdf.Ln(10)
+	pdf.Cell(40, 10, fmt.Sprintf("Email  : %v", user.Email))
+	pdf.Ln(10)
+	pdf.Cell(40, 10, fmt.Sprintf("Phone Number  : %v", user.PhoneNumber))
+	pdf.Ln(10)
+	pdf.Cell(40, 10, fmt.Sprintf("Address Info: %s, %s, %s, %s, %s,%s", address.AddressType, address.StreetName, address.StreetNumber, address.City, address.State, address.PostalCode))
+	pdf.Ln(10)
+	pdf.Cell(40, 10, fmt.Sprintf("Payment Method: %s", order.PaymentMethod))
+	pdf.Ln(10)
+	pdf.Cell(40, 10, fmt.Sprintf("Payment Status: %s", order.PaymentStatus))
+	pdf.Ln(10)
+	pdf.Cell(40, 10, fmt.Sprintf("Ordered At: %s", order.OrderedAt.Format(time.RFC1123)))
+	pdf.Ln(20)
+
+	// Table header
+	pdf.SetFont("Arial", "B", 10)
+	pdf.Cell(15, 10, "ID")
+	pdf.Cell(60, 10, "Product Name")
+	pdf.Cell(25, 10, "Offer")
+	pdf.Cell(40, 10, "Restaurant")
+	pdf.Cell(20, 10, "Qty")
+	pdf.Cell(30, 10, "Amount")
+	pdf.Cell(30, 10, "Status")
+	pdf.Ln(10)
+
+	// Table body
+	pdf.SetFont("Arial", "", 12)
+	for _, item := range orderItems {
+		productName, restaurantName := names(item)
+		pdf.Cell(15, 10, fmt.Sprintf("%d", item.ProductID))
+		pdf.Cell(60, 10, fmt.Sprintf("%v", productName))
+		pdf.Cell(25, 10, item.ProductOfferAmount.String())
+		pdf.Cell(40, 10, fmt.Sprintf("%v", restaurantName))
+		pdf.Cell(20, 10, fmt.Sprintf("%d", item.Quantity))
+		pdf.Cell(30, 10, item.Amount.String())
+		pdf.Cell(30, 10, fmt.Sprintf("%v", item.OrderStatus))
+		pdf.Ln(10)
+	}
+
+	//total amount before deduction
+	pdf.Ln(10)
+	pdf.SetFont("Arial", "B", 12)
+	pdf.Cell(40, 10, fmt.Sprintf("Gross Amount: %s", order.TotalAmount))
+	pdf.Ln(10)
+
+	//coupon discount
+	pdf.SetTextColor(255, 0, 0) //red for discount
+	pdf.Cell(40, 10, fmt.Sprintf("Coupon Discount Amount: %s", order.CouponDiscountAmount))
+	pdf.Ln(10)
+
+	//product disocunt
+	pdf.Cell(40, 10, fmt.Sprintf("Product Discount Amount: %s", order.ProductOfferAmount))
+	pdf.Ln(10)
+	pdf.SetTextColor(0, 0, 0) //reset to black
+
+	//final amount after dicounts
+	pdf.SetFont("Arial", "B", 12)
+	pdf.Cell(40, 10, fmt.Sprintf("Net Amount: %s", order.FinalAmount))
+	pdf.Ln(10)
+	pdf.SetFont("Arial", "", 12)
+
+	var pdfBytes bytes.Buffer
+	if err := pdf.Output(&pdfBytes); err != nil {
+		return nil, err
+	}
+	return pdfBytes.Bytes(), nil
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/repository"
+	"net/http"
+)
+
+type RestaurantService struct {
+	repos *repository.Repositories
+}
+
+func NewRestaurantService(repos *repository.Repositories) *RestaurantService {
+	return &RestaurantService{repos: repos}
+}
+
+func (s *RestaurantService) List() ([]model.Restaurant, error) {
+	restaurants, err := s.repos.Restaurants.List()
+	if err != nil {
+		return nil, internal("failed to retrieve data from the database")
+	}
+	return restaurants, nil
+}
+
+func (s *RestaurantService) Profile(restaurantID uint) (model.Restaurant, error) {
+	restaurant, err := s.repos.Restaurants.FindByID(restaurantID)
+	if err != nil {
+		return restaurant, badRequest("restaurant profile not found,confirm id of the restaurant")
+	}
+	return restaurant, nil
+}
+
+func (s *RestaurantService) UpdateProfile(restaurantID uint, request model.RestaurantProfileUpdate) error {
+	if _, err := s.repos.Restaurants.FindByID(restaurantID); err != nil {
+		return notFound("restaurant doesn't exist")
+	}
+	if err := s.repos.Restaurants.UpdateProfile(restaurantID, request); err != nil {
+		return internal("failed to edit the restaurant")
+	}
+	return nil
+}
+
+func (s *RestaurantService) UpdateImage(restaurantID uint, url string) error {
+	if err := s.repos.Restaurants.UpdateImage(restaurantID, url); err != nil {
+		return internal("failed to add image, please try again")
+	}
+	return nil
+}
+
+func (s *RestaurantService) Delete(restaurantID uint) error {
+	if _, err := s.repos.Restaurants.FindByID(restaurantID); err != nil {
+		return notFound("restaurant doesn't exist")
+	}
+	if err := s.repos.Restaurants.Delete(restaurantID); err != nil {
+		return internal("failed to delete the restaurant")
+	}
+	return nil
+}
+
+func (s *RestaurantService) Block(restaurantID uint) (model.Restaurant, error) {
+	return s.setBlocked(restaurantID, true)
+}
+
+func (s *RestaurantService) Unblock(restaurantID uint) (model.Restaurant, error) {
+	return s.setBlocked(restaurantID, false)
+}
+
+func (s *RestaurantService) setBlocked(restaurantID uint, blocked bool) (model.Restaurant, error) {
+	restaurant, err := s.repos.Restaurants.FindByID(restaurantID)
+	if err != nil {
+		return restaurant, notFound("restaurant not found")
+	}
+	if restaurant.Blocked == blocked {
+		if blocked {
+			return restaurant, newError(http.StatusAlreadyReported, "restaurant is already blocked")
+		}
+		return restaurant, newError(http.StatusConflict, "restaurant is already unblocked")
+	}
+	err = s.repos.Transaction(func(tx *repository.Repositories) error {
+		if err := tx.Restaurants.SetBlocked(restaurantID, blocked); err != nil {
+			return err
+		}
+		// a blocked restaurant is signed out of every session
+		if blocked {
+			return revokeSessions(tx, restaurant.Email, model.RestaurantRole)
+		}
+		return nil
+	})
+	if err != nil {
+		return restaurant, internal("failed to change the block status")
+	}
+	restaurant.Blocked = blocked
+	return restaurant, nil
+}
+
+// SetVerification changes the admin verification status, changed is false when it already had it
+func (s *RestaurantService) SetVerification(restaurantID uint, status string) (changed bool, err error) {
+	restaurant, err := s.repos.Restaurants.FindByID(restaurantID)
+	if err != nil {
+		return false, notFound(err.Error())
+	}
+	if restaurant.VerificationStatus == status {
+		return false, nil
+	}
+	if err := s.repos.Restaurants.SetVerificationStatus(restaurantID, status); err != nil {
+		return false, notFound(err.Error())
+	}
+	return true, nil
+}
+
+// OrderItems lists the order items placed at the restaurant
+func (s *RestaurantService) OrderItems(restaurantID uint) ([]model.OrderItem, error) {
+	items, err := s.repos.Orders.ListItemsByRestaurant(restaurantID)
+	if err != nil {
+		return nil, internal("Failed to retrieve order information")
+	}
+	return items, nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"foodbuddy/internal/mail"
+	"foodbuddy/internal/payment"
+	"foodbuddy/internal/pubsub"
+	"foodbuddy/internal/repository"
+)
+
+// Services bundles the business logic the http handlers are built on
+type Services struct {
+	Auth           *AuthService
+	Sessions       *SessionService
+	Admins         *AdminService
+	Emails         *EmailService
+	Outbox         *OutboxService
+	Users          *UserService
+	Restaurants    *RestaurantService
+	Products       *ProductService
+	Categories     *CategoryService
+	Carts          *CartService
+	Coupons        *CouponService
+	Orders         *OrderService
+	Payments       *PaymentService
+	Wallets        *WalletService
+	Ledger         *LedgerService
+	Referrals      *ReferralService
+	Reports        *ReportService
+	Reconciliation *ReconciliationService
+	Idempotency    *IdempotencyService
+	Kitchen        *KitchenService
+	Stock          *StockService
+}
+
+// New builds the services, the mails they render from templates go through the outbox
+// and orders are paid through the gateways. changes to the orders are published on a hub
+// shared by the services
+func New(repos *repository.Repositories, templates *mail.Templates, gateways *payment.Registry) *Services {
+	events := pubsub.NewHub()
+	stock := NewStockService(repos)
+	return &Services{
+		Auth:           NewAuthService(repos, templates),
+		Sessions:       NewSessionService(repos),
+		Admins:         NewAdminService(repos, templates),
+		Emails:         NewEmailService(templates),
+		Outbox:         NewOutboxService(repos),
+		Users:          NewUserService(repos),
+		Restaurants:    NewRestaurantService(repos),
+		Products:       NewProductService(repos),
+		Categories:     NewCategoryService(repos),
+		Carts:          NewCartService(repos),
+		Coupons:        NewCouponService(repos),
+		Orders:         NewOrderService(repos, templates, gateways, events, stock),
+		Payments:       NewPaymentService(repos, gateways, events, stock),
+		Wallets:        NewWalletService(repos),
+		Ledger:         NewLedgerService(repos),
+		Referrals:      NewReferralService(repos),
+		Reports:        NewReportService(repos),
+		Reconciliation: NewReconciliationService(repos, gateways, events),
+		Idempotency:    NewIdempotencyService(repos),
+		Kitchen:        NewKitchenService(repos, events),
+		Stock:          stock,
+	}
+}
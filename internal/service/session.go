@@ -0,0 +1,243 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/repository"
+	"foodbuddy/internal/utils"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// SessionService issues the tokens of signed in sessions. access tokens are short lived jwts
+// carrying a jti and the session id, refresh tokens are random strings stored as a hash that
+// are replaced on every refresh. revoking a session puts its current access token on the
+// revocation list so it stops working right away instead of when it expires
+type SessionService struct {
+	repos *repository.Repositories
+}
+
+func NewSessionService(repos *repository.Repositories) *SessionService {
+	return &SessionService{repos: repos}
+}
+
+var errRefreshRace = errors.New("refresh token was already used")
+
+// Tokens are the credentials handed to the client after signing in or refreshing
+type Tokens struct {
+	SessionID        string    `json:"session_id"`
+	AccessToken      string    `json:"access_token"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// Start opens a session for an account that just signed in
+func (s *SessionService) Start(email string, role string, userAgent string, ip string) (Tokens, error) {
+	now := time.Now()
+	session := model.Session{
+		ID:         uuid.New().String(),
+		Email:      email,
+		Role:       role,
+		UserAgent:  userAgent,
+		IPAddress:  ip,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(model.RefreshTokenLifetime * time.Second),
+	}
+	tokens, err := issueTokens(&session)
+	if err != nil {
+		return tokens, internal("failed to generate token, please try again")
+	}
+	if err := s.repos.Sessions.Create(&session); err != nil {
+		return tokens, internal("failed to create session, please try again")
+	}
+	return tokens, nil
+}
+
+// Refresh replaces the refresh token with a new one and issues a new access token. a refresh
+// token that was already replaced means it was copied, the whole session is revoked then
+func (s *SessionService) Refresh(refreshToken string) (Tokens, error) {
+	hash := hashToken(refreshToken)
+	session, err := s.repos.Sessions.FindByRefreshHash(hash)
+	if errors.Is(err, repository.ErrNotFound) {
+		if reused, err := s.repos.Sessions.FindByPreviousRefreshHash(hash); err == nil {
+			s.repos.Transaction(func(tx *repository.Repositories) error {
+				return revokeSession(tx, reused)
+			})
+		}
+		return Tokens{}, newError(http.StatusUnauthorized, "invalid refresh token, please login again")
+	}
+	if err != nil {
+		return Tokens{}, internal("failed to fetch session")
+	}
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return Tokens{}, newError(http.StatusUnauthorized, "session has ended, please login again")
+	}
+
+	var tokens Tokens
+	err = s.repos.Transaction(func(tx *repository.Repositories) error {
+		// the access token being replaced is still valid for a while, it is retired with the refresh token
+		if err := tx.Sessions.RevokeToken(session.AccessTokenID, session.AccessExpiresAt); err != nil {
+			return err
+		}
+		session.PreviousRefreshHash = session.RefreshTokenHash
+		session.LastUsedAt = time.Now()
+		if tokens, err = issueTokens(&session); err != nil {
+			return err
+		}
+		rotated, err := tx.Sessions.Rotate(&session, hash)
+		if err != nil {
+			return err
+		}
+		if !rotated {
+			return errRefreshRace
+		}
+		return nil
+	})
+	if errors.Is(err, errRefreshRace) {
+		return Tokens{}, newError(http.StatusUnauthorized, "invalid refresh token, please login again")
+	}
+	if err != nil {
+		return Tokens{}, internal("failed to refresh the session, please try again")
+	}
+	return tokens, nil
+}
+
+// Verify checks that the access token with the jti was not revoked
+func (s *SessionService) Verify(tokenID string) error {
+	revoked, err := s.repos.Sessions.IsTokenRevoked(tokenID)
+	if err != nil {
+		return internal("failed to verify the token")
+	}
+	if revoked {
+		return newError(http.StatusUnauthorized, "session has ended, please login again")
+	}
+	return nil
+}
+
+// List returns the active sessions of an account
+func (s *SessionService) List(email string, role string) ([]model.Session, error) {
+	sessions, err := s.repos.Sessions.ListActive(email, role)
+	if err != nil {
+		return nil, internal("failed to fetch sessions")
+	}
+	return sessions, nil
+}
+
+// Revoke ends one session of an account
+func (s *SessionService) Revoke(email string, role string, sessionID string) error {
+	session, err := s.repos.Sessions.FindByID(sessionID)
+	if err != nil || session.Email != email || session.Role != role {
+		return notFound("session not found")
+	}
+	err = s.repos.Transaction(func(tx *repository.Repositories) error {
+		return revokeSession(tx, session)
+	})
+	if err != nil {
+		return internal("failed to revoke the session")
+	}
+	return nil
+}
+
+// End ends the session a refresh token belongs to, used to logout when the access token expired
+func (s *SessionService) End(refreshToken string) error {
+	session, err := s.repos.Sessions.FindByRefreshHash(hashToken(refreshToken))
+	if err != nil {
+		return notFound("session not found")
+	}
+	err = s.repos.Transaction(func(tx *repository.Repositories) error {
+		return revokeSession(tx, session)
+	})
+	if err != nil {
+		return internal("failed to revoke the session")
+	}
+	return nil
+}
+
+// RevokeAll ends every session of an account, signing it out everywhere
+func (s *SessionService) RevokeAll(email string, role string) error {
+	err := s.repos.Transaction(func(tx *repository.Repositories) error {
+		return revokeSessions(tx, email, role)
+	})
+	if err != nil {
+		return internal("failed to revoke the sessions")
+	}
+	return nil
+}
+
+// revokeSessions ends every session of an account, tx should be the transaction of the change
+// that calls for it, like a password reset or a block
+func revokeSessions(tx *repository.Repositories, email string, role string) error {
+	sessions, err := tx.Sessions.ListActive(email, role)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if err := revokeSession(tx, session); err != nil {
+			return err
+		}
+	}
+	return tx.Sessions.DeleteExpiredRevocations(time.Now())
+}
+
+func revokeSession(tx *repository.Repositories, session model.Session) error {
+	if err := tx.Sessions.Revoke(session.ID, time.Now()); err != nil {
+		return err
+	}
+	return tx.Sessions.RevokeToken(session.AccessTokenID, session.AccessExpiresAt)
+}
+
+// issueTokens signs a new access token and generates a new refresh token for the session
+func issueTokens(session *model.Session) (Tokens, error) {
+	now := time.Now()
+	session.AccessTokenID = uuid.New().String()
+	session.AccessExpiresAt = now.Add(model.AccessTokenLifetime * time.Second)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"email": session.Email,
+		"role":  session.Role,
+		"jti":   session.AccessTokenID,
+		"sid":   session.ID,
+		"iat":   now.Unix(),
+		"exp":   session.AccessExpiresAt.Unix(),
+	})
+	accessToken, err := token.SignedString([]byte(utils.GetEnvVariables().JWTSecret))
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return Tokens{}, err
+	}
+	session.RefreshTokenHash = hashToken(refreshToken)
+
+	return Tokens{
+		SessionID:        session.ID,
+		AccessToken:      accessToken,
+		AccessExpiresAt:  session.AccessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+// randomToken returns size random bytes encoded to be safe in urls and cookies
+func randomToken(size int) (string, error) {
+	random := make([]byte, size)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(random), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
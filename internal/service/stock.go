@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/repository"
+	"foodbuddy/internal/utils"
+	"log"
+	"net/http"
+	"time"
+)
+
+// StockService holds the stock of the online orders while they wait for their payment, so two
+// customers can't pay for the last portion. a hold becomes stock taken when the order is paid
+// and is let go of by the sweeper once it expires, a failed payment expires it right away
+type StockService struct {
+	repos *repository.Repositories
+
+	// HoldTTL is how long the stock of an online order is held, from when it is placed or its
+	// payment is started
+	HoldTTL time.Duration
+}
+
+// stockReleaseBatch is how many expired holds the sweeper lets go of at a time
+const stockReleaseBatch = 200
+
+// NewStockService reads STOCK_HOLD_TTL, a duration like 15m, falling back to model.StockHoldTTL
+func NewStockService(repos *repository.Repositories) *StockService {
+	env := utils.GetEnvVariables()
+	return &StockService{
+		repos:   repos,
+		HoldTTL: durationOr("STOCK_HOLD_TTL", env.StockHoldTTL, model.StockHoldTTL*time.Second),
+	}
+}
+
+// hold holds the stock of the order's items waiting for their payment for HoldTTL. the holds the
+// order still has are extended, the items whose hold the sweeper let go of are held again if
+// the stock is still there
+func (s *StockService) hold(tx *repository.Repositories, orderID string) error {
+	expiresAt := time.Now().Add(s.HoldTTL)
+	held, err := tx.Reservations.ListByOrder(orderID)
+	if err != nil {
+		return internal("failed to fetch the stock held for the order")
+	}
+	if err := tx.Reservations.ExpireOrder(orderID, expiresAt); err != nil {
+		return internal("failed to extend the stock held for the order")
+	}
+	heldProducts := make(map[uint]bool, len(held))
+	for _, reservation := range held {
+		heldProducts[reservation.ProductID] = true
+	}
+
+	items, err := tx.Orders.ListItemsByStatus(orderID, model.OrderStatusInitiated)
+	if err != nil {
+		return internal("failed to fetch the order items")
+	}
+	for _, item := range items {
+		if heldProducts[item.ProductID] {
+			continue
+		}
+		reserved, err := tx.Products.Reserve(item.ProductID, item.Quantity)
+		if err != nil {
+			return internal("failed to hold the stock of the order")
+		}
+		if !reserved {
+			return newError(http.StatusConflict, fmt.Sprintf("product %d doesn't have %d left in stock", item.ProductID, item.Quantity))
+		}
+		reservation := model.StockReservation{OrderID: orderID, ProductID: item.ProductID, Quantity: item.Quantity, ExpiresAt: expiresAt}
+		if err := tx.Reservations.Create(&reservation); err != nil {
+			return internal("failed to hold the stock of the order")
+		}
+	}
+	return nil
+}
+
+// releaseHolds lets go of the stock held for the order, a hold the sweeper released in the
+// meantime is left alone
+func releaseHolds(tx *repository.Repositories, orderID string) error {
+	held, err := tx.Reservations.ListByOrder(orderID)
+	if err != nil {
+		return err
+	}
+	for _, reservation := range held {
+		if err := releaseHold(tx, reservation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func releaseHold(tx *repository.Repositories, reservation model.StockReservation) error {
+	deleted, err := tx.Reservations.Delete(reservation.ID)
+	if err != nil || !deleted {
+		return err
+	}
+	return unreserve(tx, reservation)
+}
+
+// unreserve gives the stock of a hold back to its product. a product holding less than the hold
+// is logged and the hold is let go of anyway, failing would keep the sweeper on it forever
+func unreserve(tx *repository.Repositories, reservation model.StockReservation) error {
+	err := tx.Products.Unreserve(reservation.ProductID, reservation.Quantity)
+	if errors.Is(err, repository.ErrNotReserved) {
+		log.Printf("stock: product %d holds less than the %d of hold %d of order %s", reservation.ProductID, reservation.Quantity, reservation.ID, reservation.OrderID)
+		return nil
+	}
+	return err
+}
+
+// expireHolds lets the sweeper release the stock held for the order on its next run, starting
+// the payment again holds it anew
+func expireHolds(repos *repository.Repositories, orderID string) error {
+	return repos.Reservations.ExpireOrder(orderID, time.Now())
+}
+
+// ReleaseExpired lets go of the expired holds every interval until ctx is cancelled
+func (s *StockService) ReleaseExpired(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		s.releaseExpired(ctx)
+	}
+}
+
+func (s *StockService) releaseExpired(ctx context.Context) {
+	for ctx.Err() == nil {
+		now := time.Now()
+		expired, err := s.repos.Reservations.ListExpired(now, stockReleaseBatch)
+		if err != nil {
+			log.Printf("stock: failed to fetch the expired holds: %v", err)
+			return
+		}
+		for _, reservation := range expired {
+			// a hold extended since it was listed is left alone
+			err := s.repos.Transaction(func(tx *repository.Repositories) error {
+				deleted, err := tx.Reservations.DeleteExpired(reservation.ID, now)
+				if err != nil || !deleted {
+					return err
+				}
+				return unreserve(tx, reservation)
+			})
+			if err != nil {
+				log.Printf("stock: failed to release hold %d of order %s: %v", reservation.ID, reservation.OrderID, err)
+				return
+			}
+		}
+		if len(expired) < stockReleaseBatch {
+			return
+		}
+	}
+}
+
+// availableStock is the stock of the product that isn't held for an order
+func availableStock(product model.Product) uint {
+	if product.Reserved >= product.StockLeft {
+		return 0
+	}
+	return product.StockLeft - product.Reserved
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"errors"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/repository"
+	"net/http"
+)
+
+// MaxUserAddresses is the number of addresses a user can keep at a time
+const MaxUserAddresses = 3
+
+type UserService struct {
+	repos *repository.Repositories
+}
+
+func NewUserService(repos *repository.Repositories) *UserService {
+	return &UserService{repos: repos}
+}
+
+func (s *UserService) Profile(userID uint) (model.User, error) {
+	user, err := s.repos.Users.FindByID(userID)
+	if err != nil {
+		return user, notFound("failed to retrieve data from the database, or the data doesn't exists")
+	}
+	return user, nil
+}
+
+func (s *UserService) List() ([]model.BlockedUserResponse, error) {
+	users, err := s.repos.Users.List()
+	if err != nil {
+		return nil, notFound("failed to retrieve data from the database, or the data doesn't exist")
+	}
+	return userResponses(users), nil
+}
+
+func (s *UserService) ListBlocked() ([]model.BlockedUserResponse, error) {
+	users, err := s.repos.Users.ListBlocked()
+	if err != nil {
+		return nil, notFound("failed to retrieve blocked user data from the database, or the data doesn't exists")
+	}
+	return userResponses(users), nil
+}
+
+func (s *UserService) Block(userID uint) error {
+	user, err := s.repos.Users.FindByID(userID)
+	if err != nil {
+		return notFound("failed to fetch user information")
+	}
+	if user.Blocked {
+		return newError(http.StatusAlreadyReported, "user is already blocked")
+	}
+	err = s.repos.Transaction(func(tx *repository.Repositories) error {
+		if err := tx.Users.SetBlocked(userID, true); err != nil {
+			return err
+		}
+		// a blocked user is signed out of every session
+		return revokeSessions(tx, user.Email, model.UserRole)
+	})
+	if err != nil {
+		return internal("failed to change the block status ")
+	}
+	return nil
+}
+
+func (s *UserService) Unblock(userID uint) error {
+	user, err := s.repos.Users.FindByID(userID)
+	if err != nil {
+		return notFound("User not found")
+	}
+	if !user.Blocked {
+		return newError(http.StatusAlreadyReported, "user is already unblocked")
+	}
+	if err := s.repos.Users.SetBlocked(userID, false); err != nil {
+		return internal("failed to change the unblock status")
+	}
+	return nil
+}
+
+func (s *UserService) AddAddress(userID uint, address model.Address) (model.Address, error) {
+	address.UserID = userID
+	//to make sure the addressid is autoincremented by the gorm
+	address.AddressID = 0
+
+	if _, err := s.repos.Users.FindByID(userID); err != nil {
+		return address, notFound("User not found")
+	}
+
+	addresses, err := s.repos.Users.ListAddresses(userID)
+	if err != nil {
+		return address, internal("failed to retrieve the existing user addresses from the database")
+	}
+	if len(addresses) >= MaxUserAddresses {
+		return address, badRequest("user already have three addresses, please delete or edit the existing addresses")
+	}
+
+	if err := s.repos.Users.CreateAddress(&address); err != nil {
+		return address, internal("failed to create the address on the database")
+	}
+	return address, nil
+}
+
+func (s *UserService) Addresses(userID uint) ([]model.Address, error) {
+	addresses, err := s.repos.Users.ListAddresses(userID)
+	if err != nil {
+		return nil, notFound("failed to get informations from the database")
+	}
+	if len(addresses) == 0 {
+		return nil, notFound("no addresses related to the userid")
+	}
+	return addresses, nil
+}
+
+func (s *UserService) EditAddress(userID uint, request model.EditUserAddress) error {
+	address, err := s.repos.Users.FindAddress(userID, request.AddressID)
+	if err != nil {
+		return notFound("address not found")
+	}
+
+	address.PhoneNumber = request.PhoneNumber
+	address.AddressType = request.AddressType
+	address.StreetName = request.StreetName
+	address.StreetNumber = request.StreetNumber
+	address.City = request.City
+	address.State = request.State
+	address.PostalCode = request.PostalCode
+
+	if err := s.repos.Users.UpdateAddress(&address); err != nil {
+		return internal("failed to update the address")
+	}
+	return nil
+}
+
+func (s *UserService) DeleteAddress(userID uint, addressID uint) error {
+	address, err := s.repos.Users.FindAddress(userID, addressID)
+	if err != nil {
+		return notFound("address not found")
+	}
+	if err := s.repos.Users.DeleteAddress(&address); err != nil {
+		return internal("failed to delete the address")
+	}
+	return nil
+}
+
+func (s *UserService) UpdateProfile(userID uint, request model.UpdateUserInformation) error {
+	if _, err := s.repos.Users.FindByID(userID); err != nil {
+		return badRequest("user doesnt exist")
+	}
+	if err := s.repos.Users.UpdateProfile(userID, request); err != nil {
+		return internal("failed to update user profile")
+	}
+	return nil
+}
+
+func (s *UserService) UpdatePicture(userID uint, url string) error {
+	if err := s.repos.Users.UpdatePicture(userID, url); err != nil {
+		return internal("failed to add image, please try again")
+	}
+	return nil
+}
+
+func (s *UserService) Favourites(userID uint) ([]model.FavouriteProduct, error) {
+	favourites, err := s.repos.Users.ListFavourites(userID)
+	if err != nil {
+		return nil, notFound("the user ID doesn't exist in the database")
+	}
+	return favourites, nil
+}
+
+func (s *UserService) AddFavourite(userID uint, productID uint) error {
+	if _, err := s.repos.Users.FindByID(userID); err != nil {
+		return badRequest("user not found")
+	}
+	if _, err := s.repos.Products.FindByID(productID); err != nil {
+		return badRequest("product not found")
+	}
+	_, err := s.repos.Users.FindFavourite(userID, productID)
+	if err == nil {
+		return badRequest("favorite product already exists")
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return internal("failed to add favorite product")
+	}
+	if err := s.repos.Users.AddFavourite(&model.FavouriteProduct{UserID: userID, ProductID: productID}); err != nil {
+		return internal("failed to add favorite product")
+	}
+	return nil
+}
+
+func (s *UserService) RemoveFavourite(userID uint, productID uint) error {
+	if _, err := s.repos.Users.FindFavourite(userID, productID); err != nil {
+		return badRequest("favorite product doesn't exist")
+	}
+	if err := s.repos.Users.RemoveFavourite(userID, productID); err != nil {
+		return internal("failed to delete favorite product")
+	}
+	return nil
+}
+
+func userResponses(users []model.User) []model.BlockedUserResponse {
+	responses := make([]model.BlockedUserResponse, 0, len(users))
+	for _, user := range users {
+		responses = append(responses, model.BlockedUserResponse{
+			ID:           user.ID,
+			Name:         user.Name,
+			Email:        user.Email,
+			PhoneNumber:  user.PhoneNumber,
+			Picture:      user.Picture,
+			ReferralCode: user.ReferralCode,
+			WalletAmount: user.WalletAmount,
+			LoginMethod:  user.LoginMethod,
+			Blocked:      user.Blocked,
+		})
+	}
+	return responses
+}
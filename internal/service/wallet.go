@@ -0,0 +1,76 @@
+package service
+
+import (
+	"errors"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/money"
+	"foodbuddy/internal/repository"
+)
+
+type WalletService struct {
+	repos *repository.Repositories
+}
+
+func NewWalletService(repos *repository.Repositories) *WalletService {
+	return &WalletService{repos: repos}
+}
+
+func (s *WalletService) UserWallet(userID uint) (money.Amount, []model.UserWalletHistory, error) {
+	user, err := s.repos.Users.FindByID(userID)
+	if err != nil {
+		return 0, nil, notFound("failed to get wallet balance")
+	}
+	history, err := s.repos.Wallets.ListUserHistory(userID)
+	if err != nil {
+		return 0, nil, notFound("failed to get wallet history")
+	}
+	return user.WalletAmount, history, nil
+}
+
+func (s *WalletService) RestaurantWallet(restaurantID uint) (money.Amount, []model.RestaurantWalletHistory, error) {
+	restaurant, _ := s.repos.Restaurants.FindByID(restaurantID)
+	history, err := s.repos.Wallets.ListRestaurantHistory(restaurantID)
+	if err != nil {
+		return 0, nil, notFound("failed to get restaurant wallet history")
+	}
+	return restaurant.WalletAmount, history, nil
+}
+
+// splitMoneyToRestaurants credits every restaurant with its share of a paid order, the money
+// comes from paidFrom, the user's wallet or the payment gateway
+func splitMoneyToRestaurants(tx *repository.Repositories, orderID string, paidFrom string) error {
+	order, err := tx.Orders.FindByID(orderID)
+	if err != nil {
+		return err
+	}
+	items, err := tx.Orders.ListItems(orderID)
+	if err != nil {
+		return err
+	}
+
+	var payer uint
+	if paidFrom == model.LedgerUserWallet {
+		payer = order.UserID
+	}
+	postings := []posting{debit(paidFrom, payer, order.FinalAmount)}
+	for _, item := range items {
+		postings = append(postings, credit(model.LedgerRestaurantWallet, item.RestaurantID, item.AfterDeduction))
+	}
+	return postEntry(tx, model.WalletTxTypeOrderPayment, orderID, postings...)
+}
+
+// refundToUserWallet takes the cancelled items back from the restaurants and credits the user
+func refundToUserWallet(tx *repository.Repositories, userID uint, items []model.OrderItem) error {
+	if len(items) == 0 {
+		return errors.New("no items to refund")
+	}
+
+	var sum money.Amount
+	var postings []posting
+	for _, item := range items {
+		postings = append(postings, debit(model.LedgerRestaurantWallet, item.RestaurantID, item.AfterDeduction))
+		sum += item.AfterDeduction
+	}
+	postings = append(postings, credit(model.LedgerUserWallet, userID, sum))
+	return postEntry(tx, model.WalletTxTypeOrderRefund, items[0].OrderID, postings...)
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"foodbuddy/internal/model"
+	"foodbuddy/internal/money"
+	"foodbuddy/internal/payment"
+	"foodbuddy/internal/repository"
+	"log"
+	"net/http"
+)
+
+// HandleWebhook verifies a webhook of the gateway and applies its event. an event is applied
+// once, in the transaction that records it, so a delivery that fails is applied again when the
+// provider retries and a delivery that succeeded is ignored the next time it arrives
+func (s *PaymentService) HandleWebhook(ctx context.Context, gatewayName string, payload []byte, header http.Header) error {
+	gateway, err := s.gateways.Get(gatewayName)
+	if err != nil {
+		return notFound("unknown payment gateway")
+	}
+	webhooks, ok := gateway.(payment.WebhookGateway)
+	if !ok {
+		return notFound(gateway.Name() + " doesn't send webhooks")
+	}
+
+	event, err := webhooks.ParseWebhook(payload, header)
+	if errors.Is(err, payment.ErrInvalidSignature) {
+		log.Printf("payment: rejected %s webhook: %v", gateway.Name(), err)
+		return badRequest("invalid webhook signature")
+	}
+	if err != nil {
+		return badRequest("failed to read the webhook")
+	}
+	if event.Type == "" {
+		return nil
+	}
+
+	return s.repos.Transaction(func(tx *repository.Repositories) error {
+		fresh, err := tx.Webhooks.Record(gateway.Name(), event.ID, event.ProviderType)
+		if err != nil {
+			return internal("failed to record the webhook event")
+		}
+		if !fresh {
+			return nil
+		}
+		if event.Type == payment.EventRefundProcessed || event.Type == payment.EventRefundFailed {
+			return applyRefundEvent(tx, gateway.Name(), event)
+		}
+
+		record, err := findEventPayment(tx, gateway.Name(), event)
+		if errors.Is(err, repository.ErrNotFound) {
+			// not a checkout of ours, there is nothing to retry
+			log.Printf("payment: %s event %s (%s) matches no payment", gateway.Name(), event.ID, event.ProviderType)
+			return nil
+		}
+		if err != nil {
+			return internal("failed to fetch the payment of the event")
+		}
+
+		switch event.Type {
+		case payment.EventPaid:
+			_, err := settlePayment(tx, s.events, record, event.PaymentID, "")
+			return err
+		case payment.EventFailed:
+			return failPayment(tx, record)
+		case payment.EventRefunded:
+			if err := recordRefund(tx, record, event.Refunded); err != nil {
+				return err
+			}
+			if event.RefundID != "" {
+				return applyRefundEvent(tx, gateway.Name(), payment.Event{Type: payment.EventRefundProcessed, RefundID: event.RefundID})
+			}
+		}
+		return nil
+	})
+}
+
+// applyRefundEvent settles the pending refund the event is about, refunds made outside the
+// platform are not ours to settle and are booked by recordRefund instead
+func applyRefundEvent(tx *repository.Repositories, gateway string, event payment.Event) error {
+	refund, err := tx.Refunds.FindByGatewayRefundID(gateway, event.RefundID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return internal("failed to fetch the refund of the event")
+	}
+	if event.Type == payment.EventRefundFailed {
+		err = failRefund(tx, refund, "the gateway failed the refund")
+	} else {
+		_, err = tx.Refunds.Finish(refund.ID, model.RefundStatusProcessed, "", "")
+	}
+	if err != nil {
+		return internal("failed to update the refund")
+	}
+	return nil
+}
+
+// findEventPayment finds the payment an event is about by the checkout reference, the
+// gateway's payment id or, failing both, the last pending payment of the order
+func findEventPayment(tx *repository.Repositories, gateway string, event payment.Event) (model.Payment, error) {
+	if event.Reference != "" {
+		return tx.Payments.FindByReference(gateway, event.Reference)
+	}
+	if event.PaymentID != "" {
+		record, err := tx.Payments.FindByPaymentID(gateway, event.PaymentID)
+		if !errors.Is(err, repository.ErrNotFound) {
+			return record, err
+		}
+	}
+	if event.OrderID != "" {
+		payments, err := tx.Payments.ListByOrderAndStatus(event.OrderID, model.OnlinePaymentPending)
+		if err != nil {
+			return model.Payment{}, err
+		}
+		for i := len(payments) - 1; i >= 0; i-- {
+			if payments[i].PaymentGateway == gateway {
+				return payments[i], nil
+			}
+		}
+	}
+	return model.Payment{}, repository.ErrNotFound
+}
+
+// recordRefund books a refund made at the gateway, refunded is everything refunded of the
+// payment so far. the part that is new is taken back from the restaurants the payment was
+// split between, in proportion to their items. refunds of cancelled items were booked when
+// they were asked for, so they are never new here
+func recordRefund(tx *repository.Repositories, record model.Payment, refunded money.Amount) error {
+	if refunded > record.Amount {
+		refunded = record.Amount
+	}
+	if refunded <= record.RefundedAmount {
+		return nil
+	}
+	status := record.PaymentStatus
+	if refunded == record.Amount {
+		status = model.OnlinePaymentRefunded
+	}
+	changed, err := tx.Payments.SetRefunded(record.PaymentGateway, record.GatewayReference, record.RefundedAmount, refunded, status)
+	if err != nil {
+		return internal("failed to update payment informations")
+	}
+	if !changed {
+		// another refund of the payment was booked meanwhile, the provider retries this one
+		return internal("the payment changed while booking the refund")
+	}
+	if record.PaymentStatus != model.OnlinePaymentConfirmed {
+		return nil
+	}
+
+	items, err := tx.Orders.ListItems(record.OrderID)
+	if err != nil {
+		return internal("failed to fetch the order items")
+	}
+	weights := make([]int64, len(items))
+	for i, item := range items {
+		weights[i] = item.AfterDeduction.Paise()
+	}
+	refund := refunded - record.RefundedAmount
+	postings := []posting{credit(model.LedgerPaymentGateway, 0, refund)}
+	for i, part := range refund.Allocate(weights) {
+		postings = append(postings, debit(model.LedgerRestaurantWallet, items[i].RestaurantID, part))
+	}
+	if err := postEntry(tx, model.WalletTxTypeGatewayRefund, record.OrderID, postings...); err != nil {
+		return internal("failed to take the refund back from the restaurants")
+	}
+
+	if status == model.OnlinePaymentRefunded {
+		if err := tx.Orders.SetPaymentStatus(record.OrderID, model.OnlinePaymentRefunded); err != nil {
+			return internal("failed to update payment status")
+		}
+	}
+	return nil
+}
@@ -24,10 +24,36 @@ func GetEnvVariables() model.EnvVariables {
 		DBUser:              os.Getenv("DBUSER"),
 		DBPassword:          os.Getenv("DBPASSWORD"),
 		DBName:              os.Getenv("DBNAME"),
+		DBDriver:            os.Getenv("DBDRIVER"),
+		DBHost:              os.Getenv("DBHOST"),
+		DBPort:              os.Getenv("DBPORT"),
+		DBTLS:               os.Getenv("DBTLS"),
+		DBMaxOpenConns:      os.Getenv("DBMAXOPENCONNS"),
+		DBMaxIdleConns:      os.Getenv("DBMAXIDLECONNS"),
+		DBConnMaxLifetime:   os.Getenv("DBCONNMAXLIFETIME"),
 		JWTSecret:           os.Getenv("JWTSECRET"),
+		Mailer:              os.Getenv("MAILER"),
+		MailFrom:            os.Getenv("MAILFROM"),
+		MailDir:             os.Getenv("MAILDIR"),
+		MailTemplateDir:     os.Getenv("MAILTEMPLATES"),
+		MailLocale:          os.Getenv("MAILLOCALE"),
+		SMTPHost:            os.Getenv("SMTPHOST"),
+		SMTPPort:            os.Getenv("SMTPPORT"),
+		SMTPUsername:        os.Getenv("SMTPUSER"),
+		SMTPPassword:        os.Getenv("SMTPAPP"),
 		CloudinaryCloudName: os.Getenv("CLOUDNAME"),
 		CloudinaryAccessKey: os.Getenv("CLOUDINARYACCESSKEY"),
 		CloudinarySecretKey: os.Getenv("CLOUDINARYSECRETKEY"),
+		RazorpayKeyID:       os.Getenv("RAZORPAY_KEY_ID"),
+		RazorpayKeySecret:   os.Getenv("RAZORPAY_KEY_SECRET"),
+		RazorpayWebhookSecret: os.Getenv("RAZORPAY_WEBHOOK_SECRET"),
+		StripeKey:           os.Getenv("STRIPE_KEY"),
+		StripeWebhookSecret: os.Getenv("STRIPE_WEBHOOK_SECRET"),
+		PaymentPendingTTL: os.Getenv("PAYMENT_PENDING_TTL"),
+		ReconcileInterval: os.Getenv("RECONCILE_INTERVAL"),
+		AcceptanceDeadline: os.Getenv("ACCEPTANCE_DEADLINE"),
+		StockHoldTTL: os.Getenv("STOCK_HOLD_TTL"),
+		FakePayments:        os.Getenv("FAKEPAYMENTS"),
 	}
 	return EnvVariables
 }
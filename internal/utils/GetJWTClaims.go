@@ -8,51 +8,62 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// GetJWTEmailClaim attempts to extract the email claim from a JWT token.
-func GetJWTClaim(c *gin.Context) (email string, role string, err error) {
+// JWTClaims are the claims of an access token
+type JWTClaims struct {
+	Email     string
+	Role      string
+	TokenID   string // jti, used to revoke the token before it expires
+	SessionID string // sid, the session the token was issued for
+	ExpiresAt time.Time
+}
+
+// GetJWTClaims reads and verifies the access token from the Authorization cookie
+func GetJWTClaims(c *gin.Context) (JWTClaims, error) {
 	JWTToken, err := c.Cookie("Authorization")
 	if JWTToken == "" || err != nil {
-		return "", "", errors.New("no authorization token available")
+		return JWTClaims{}, errors.New("no authorization token available")
 	}
+	return ParseJWT(JWTToken)
+}
 
-	hmacSecretString := GetEnvVariables().JWTSecret
-	hmacSecret := []byte(hmacSecretString)
+// ParseJWT verifies the signature and expiry of an access token and returns its claims
+func ParseJWT(JWTToken string) (JWTClaims, error) {
+	hmacSecret := []byte(GetEnvVariables().JWTSecret)
 
 	// Parse the token
 	token, err := jwt.Parse(JWTToken, func(token *jwt.Token) (interface{}, error) {
-		if err != nil {
-			return "", errors.New("request unauthorized")
-		}
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
 		}
 		return hmacSecret, nil
-	})
-
+	}, jwt.WithExpirationRequired())
 	if err != nil {
-		return "", "", errors.New("request unauthorized")
+		return JWTClaims{}, errors.New("request unauthorized")
 	}
 
-	// Check if the token is valid
-	if claims, ok := token.Claims.(jwt.MapClaims); ok {
-		// Check for expiration
-		expirationTime, ok := claims["exp"].(float64)
-		if !ok {
-			return "", "", errors.New("request unauthorized")
-		}
-
-		// Convert the expiration time to a Time value
-		expiration := time.Unix(int64(expirationTime), 0)
-
-		// Check if the token is expired
-		if time.Now().After(expiration) {
-			return "", "", errors.New("request unauthorized")
-		}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return JWTClaims{}, errors.New("request unauthorized")
+	}
+	expiration, err := claims.GetExpirationTime()
+	if err != nil || expiration == nil {
+		return JWTClaims{}, errors.New("request unauthorized")
+	}
 
-		email := claims["email"].(string)
-		role := claims["role"].(string)
-		return email, role, nil
-	} else {
-		return "", "", errors.New("request unauthorized")
+	// tokens issued before sessions existed have no jti or sid and are no longer accepted
+	email, _ := claims["email"].(string)
+	role, _ := claims["role"].(string)
+	jti, _ := claims["jti"].(string)
+	sid, _ := claims["sid"].(string)
+	if email == "" || role == "" || jti == "" || sid == "" {
+		return JWTClaims{}, errors.New("request unauthorized")
 	}
+
+	return JWTClaims{
+		Email:     email,
+		Role:      role,
+		TokenID:   jti,
+		SessionID: sid,
+		ExpiresAt: expiration.Time,
+	}, nil
 }
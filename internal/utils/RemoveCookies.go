@@ -9,5 +9,6 @@ import (
 func RemoveCookies(c *gin.Context) {
 	c.SetSameSite(http.SameSiteLaxMode) //for security against csrf and usability
 	c.SetCookie("Authorization", "", -1, "/", "", false, true)
+	c.SetCookie("RefreshToken", "", -1, "/", "", false, true)
 	c.Next()
 }
@@ -0,0 +1,9 @@
+package utils
+
+import "math"
+
+// RoundDecimalValue rounds a value like a rating to two decimal places, money uses money.Amount
+func RoundDecimalValue(value float64) float64 {
+	multiplier := math.Pow(10, 2)
+	return math.Round(value*multiplier) / multiplier
+}